@@ -0,0 +1,131 @@
+package anchor
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gocircuit/circuit/client/server"
+)
+
+// AuditLog is an append-only log of the operations applied to anchors,
+// stored as one JSON record per line.
+type AuditLog struct {
+	sync.Mutex
+	file *os.File
+}
+
+// OpenAudit opens the audit log in file for appending, creating it if need be
+func OpenAudit(file string) (*AuditLog, error) {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{file: f}, nil
+}
+
+// Write appends rec to the log
+func (a *AuditLog) Write(rec server.AuditRecord) error {
+	buf, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	a.Lock()
+	defer a.Unlock()
+	_, err = a.file.Write(append(buf, '\n'))
+	return err
+}
+
+// Since returns the records of the log made at or after since, oldest first
+func (a *AuditLog) Since(since time.Time) ([]server.AuditRecord, error) {
+	a.Lock()
+	defer a.Unlock()
+	f, err := os.Open(a.file.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r []server.AuditRecord
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 1<<24)
+	for s.Scan() {
+		var rec server.AuditRecord
+		if err := json.Unmarshal(s.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("audit log %s: %v", a.file.Name(), err)
+		}
+		if !rec.Time.Before(since) {
+			r = append(r, rec)
+		}
+	}
+	return r, s.Err()
+}
+
+// Close closes the log
+func (a *AuditLog) Close() error {
+	return a.file.Close()
+}
+
+var audit struct {
+	sync.Mutex
+	log *AuditLog
+}
+
+// UseAudit records the operations applied to the anchors of this server in
+// a. A nil log records nothing, which is the default.
+func UseAudit(a *AuditLog) {
+	audit.Lock()
+	defer audit.Unlock()
+	audit.log = a
+}
+
+func usedAudit() *AuditLog {
+	audit.Lock()
+	defer audit.Unlock()
+	return audit.log
+}
+
+// Audited returns the records of the audit log in use made at or after since
+func Audited(since time.Time) ([]server.AuditRecord, error) {
+	a := usedAudit()
+	if a == nil {
+		return nil, errors.New("server keeps no audit log")
+	}
+	return a.Since(since)
+}
+
+// Audit records that the guarded caller applied verb, with arguments arg,
+// to the anchor, and that the operation failed with err, if not nil.
+func (g Guard) Audit(verb string, arg interface{}, err error) {
+	a := usedAudit()
+	if a == nil {
+		return
+	}
+	rec := server.AuditRecord{
+		Time:     time.Now(),
+		Identity: g.identity(),
+		Verb:     verb,
+		Anchor:   g.path,
+		Kind:     g.kind,
+	}
+	if g.caller != nil && g.caller.Addr != nil {
+		rec.Caller = g.caller.Addr.String()
+	}
+	if arg != nil {
+		if buf, err := json.Marshal(arg); err == nil {
+			rec.Arg = buf
+		} else {
+			rec.Arg, _ = json.Marshal(fmt.Sprintf("%v", arg))
+		}
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	if err = a.Write(rec); err != nil {
+		log.Printf("Audit log not written (%v)", err)
+	}
+}
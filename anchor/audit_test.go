@@ -0,0 +1,45 @@
+package anchor
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gocircuit/circuit/use/circuit"
+	"github.com/gocircuit/circuit/use/n"
+)
+
+func TestAudit(t *testing.T) {
+	if _, err := Audited(time.Time{}); err == nil {
+		t.Fatalf("audit without log")
+	}
+	a, err := OpenAudit(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("open (%v)", err)
+	}
+	defer a.Close()
+	UseAudit(a)
+	defer UseAudit(nil)
+
+	g := Guard{caller: &circuit.Caller{Peer: n.Peer{Cert: "alice"}}, path: "/X1/p", kind: Proc}
+	g.Audit(Make, map[string]string{"Path": "/bin/ls"}, nil)
+	mark := time.Now()
+	g.Audit(Signal, "TERM", errors.New("no such process"))
+	Guard{path: "/X1/p", kind: Proc}.Audit(Scrub, nil, nil)
+
+	all, err := Audited(time.Time{})
+	if err != nil || len(all) != 3 {
+		t.Fatalf("audited %v (%v)", all, err)
+	}
+	if r := all[0]; r.Identity != "alice" || r.Verb != Make || r.Anchor != "/X1/p" || r.Kind != Proc || string(r.Arg) != `{"Path":"/bin/ls"}` || r.Error != "" {
+		t.Fatalf("make record %+v", r)
+	}
+	if r := all[2]; r.Identity != "" || r.Caller != "" || r.Verb != Scrub {
+		t.Fatalf("local scrub record %+v", r)
+	}
+	recent, err := Audited(mark)
+	if err != nil || len(recent) != 2 || recent[0].Error != "no such process" || string(recent[0].Arg) != `"TERM"` {
+		t.Fatalf("audited since %v (%v)", recent, err)
+	}
+}
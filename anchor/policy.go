@@ -0,0 +1,271 @@
+package anchor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/gocircuit/circuit/use/circuit"
+	"github.com/gocircuit/circuit/use/n"
+)
+
+// Verbs of the access policy
+const (
+	Make   = "make"
+	Get    = "get"
+	Scrub  = "scrub"
+	Signal = "signal"
+	Stdin  = "stdin"
+
+	// Admin applies to the server element at the root anchor of a server:
+	// killing it, rejoining it, reloading its keys, reading its audit log
+	// and profiling it
+	Admin = "admin"
+)
+
+// ErrDenied is the error of operations the access policy does not allow
+var ErrDenied = errors.New("access denied")
+
+// Anonymous is the identity of callers who present neither a certificate nor a known token
+const Anonymous = "anonymous"
+
+// Policy maps the identities of remote callers to the verbs they may apply to anchors.
+//
+// Callers presenting a client certificate are identified by its common name,
+// and callers presenting a token by the name the policy gives to the token.
+// A caller may apply a verb to an anchor if any rule allows it.
+type Policy struct {
+	// Tokens maps access tokens to the identities they authenticate
+	Tokens map[string]string `json:"tokens"`
+	Rules  []Rule            `json:"rules"`
+}
+
+// Rule allows identities to apply verbs to anchors.
+// Identities are shell patterns, as in path.Match. Verbs may be * for all verbs.
+// Anchors are paths of shell patterns, one per path element, such as /X*/web.
+// A final /... element matches the anchors below as well.
+type Rule struct {
+	Who     []string `json:"who"`
+	Verbs   []string `json:"verbs"`
+	Anchors []string `json:"anchors"`
+}
+
+// ParsePolicy parses a policy from its JSON form
+func ParsePolicy(buf []byte) (*Policy, error) {
+	p := &Policy{}
+	if err := json.Unmarshal(buf, p); err != nil {
+		return nil, err
+	}
+	for _, r := range p.Rules {
+		for _, w := range r.Who {
+			if _, err := path.Match(w, ""); err != nil {
+				return nil, fmt.Errorf("identity pattern %q: %v", w, err)
+			}
+		}
+		for _, v := range r.Verbs {
+			switch v {
+			case Make, Get, Scrub, Signal, Stdin, Admin, "*":
+			default:
+				return nil, fmt.Errorf("verb %q not known", v)
+			}
+		}
+		for _, a := range r.Anchors {
+			if !strings.HasPrefix(a, "/") {
+				return nil, fmt.Errorf("anchor pattern %q is not absolute", a)
+			}
+			for _, e := range strings.Split(a[1:], "/") {
+				if _, err := path.Match(e, ""); err != nil {
+					return nil, fmt.Errorf("anchor pattern %q: %v", a, err)
+				}
+			}
+		}
+	}
+	return p, nil
+}
+
+// LoadPolicy reads a policy from a JSON file
+func LoadPolicy(file string) (*Policy, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	p, err := ParsePolicy(buf)
+	if err != nil {
+		return nil, fmt.Errorf("policy %s: %v", file, err)
+	}
+	return p, nil
+}
+
+// Identify returns the identity of a caller presenting the credentials in peer
+func (p *Policy) Identify(peer n.Peer) string {
+	if peer.Cert != "" {
+		return peer.Cert
+	}
+	if id, ok := p.Tokens[peer.Token]; ok && peer.Token != "" {
+		return id
+	}
+	return Anonymous
+}
+
+// Allow reports whether identity may apply verb to the anchor at path
+func (p *Policy) Allow(identity, verb, path string) bool {
+	for _, r := range p.Rules {
+		if r.allow(identity, verb, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) allow(identity, verb, anchor string) bool {
+	var who, does bool
+	for _, w := range r.Who {
+		if ok, _ := path.Match(w, identity); ok {
+			who = true
+			break
+		}
+	}
+	for _, v := range r.Verbs {
+		if v == verb || v == "*" {
+			does = true
+			break
+		}
+	}
+	if !who || !does {
+		return false
+	}
+	for _, a := range r.Anchors {
+		if matchAnchor(a, anchor) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAnchor reports whether the anchor path matches pattern
+func matchAnchor(pattern, anchor string) bool {
+	pw := strings.Split(strings.Trim(pattern, "/"), "/")
+	aw := strings.Split(strings.Trim(anchor, "/"), "/")
+	for i, p := range pw {
+		if p == "..." && i == len(pw)-1 {
+			return true
+		}
+		if i >= len(aw) {
+			return false
+		}
+		if ok, _ := path.Match(p, aw[i]); !ok {
+			return false
+		}
+	}
+	return len(pw) == len(aw)
+}
+
+var policy struct {
+	sync.Mutex
+	p *Policy
+}
+
+// UsePolicy enforces p on the calls of remote callers to the terminals of
+// this server. A nil policy allows all calls, which is the default.
+func UsePolicy(p *Policy) {
+	policy.Lock()
+	defer policy.Unlock()
+	policy.p = p
+}
+
+func usedPolicy() *Policy {
+	policy.Lock()
+	defer policy.Unlock()
+	return policy.p
+}
+
+// Guard checks the verbs a caller may apply to an anchor against the policy
+// in use, and records them in the audit log in use. The zero Guard allows
+// all verbs; it guards calls from within this server.
+type Guard struct {
+	caller *circuit.Caller
+	path   string
+	kind   string
+}
+
+// Check returns an error if the guarded caller may not apply verb
+func (g Guard) Check(verb string) error {
+	if g.caller == nil {
+		return nil
+	}
+	p := usedPolicy()
+	if p == nil {
+		return nil
+	}
+	id := p.Identify(g.caller.Peer)
+	if p.Allow(id, verb, g.path) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s may not %s %s", ErrDenied, id, verb, g.path)
+}
+
+// Authorize returns an error if the guarded caller may not call method, as
+// listed in verbs. Methods verbs does not list are denied to all callers.
+// Denials of verbs other than get are recorded in the audit log, with the
+// name of the method for argument.
+func (g Guard) Authorize(verbs Verbs, method string) error {
+	verb, ok := verbs[method]
+	if !ok {
+		return fmt.Errorf("%w: method %s of %s has no verb", ErrDenied, method, g.path)
+	}
+	err := g.Check(verb)
+	if err != nil && verb != Get {
+		g.Audit(verb, method, err)
+	}
+	return err
+}
+
+// At returns the guard of the same caller, applying verbs to the element
+// of kind at the anchor at path. Elements placing other elements on behalf
+// of their caller check the caller's verbs at the anchors they place them at.
+func (g Guard) At(path, kind string) Guard {
+	return Guard{caller: g.caller, path: path, kind: kind}
+}
+
+// On returns the guard of the same caller, applying verbs to the anchor at
+// the same path on the server with the given ID. Elements placing copies of
+// themselves on other servers check the caller's verbs there.
+func (g Guard) On(server string) Guard {
+	walk := strings.Split(strings.Trim(g.path, "/"), "/")
+	walk[0] = strings.Trim(server, "/")
+	return g.At("/"+strings.Join(walk, "/"), g.kind)
+}
+
+// From reports whether the guarded caller is the worker at addr. Calls from
+// within this server come from no other worker.
+func (g Guard) From(addr n.Addr) bool {
+	return g.caller != nil && g.caller.Addr != nil && addr != nil && g.caller.Addr.WorkerID() == addr.WorkerID()
+}
+
+// identity returns the identity of the guarded caller, or the empty string for calls from within this server
+func (g Guard) identity() string {
+	if g.caller == nil {
+		return ""
+	}
+	p := usedPolicy()
+	if p == nil {
+		p = &Policy{}
+	}
+	return p.Identify(g.caller.Peer)
+}
+
+// Verbs maps the methods of the cross-interface of an element to the verbs
+// they apply to its anchor.
+type Verbs map[string]string
+
+// Guarded is implemented by elements whose cross-interfaces check the verbs
+// of their callers. GuardedX returns the cross-interface of the element to a
+// caller, whose calls are checked with g, usually by authorizing them
+// against the Verbs of the element.
+type Guarded interface {
+	GuardedX(g Guard) circuit.X
+}
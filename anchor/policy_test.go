@@ -0,0 +1,159 @@
+package anchor
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gocircuit/circuit/use/circuit"
+	"github.com/gocircuit/circuit/use/n"
+)
+
+const testPolicy = `{
+	"tokens": {"s3cret": "ci"},
+	"rules": [
+		{"who": ["*"], "verbs": ["get"], "anchors": ["/..."]},
+		{"who": ["ci"], "verbs": ["*"], "anchors": ["/X*/build/..."]},
+		{"who": ["ops-*"], "verbs": ["signal", "scrub"], "anchors": ["/X*/web"]}
+	]
+}`
+
+func TestPolicy(t *testing.T) {
+	p, err := ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("parse (%v)", err)
+	}
+	for _, bad := range []string{
+		`{"rules": [{"who": ["*"], "verbs": ["delete"], "anchors": ["/..."]}]}`,
+		`{"rules": [{"who": ["*"], "verbs": ["get"], "anchors": ["X/..."]}]}`,
+		`{"rules": [{"who": ["["], "verbs": ["get"], "anchors": ["/..."]}]}`,
+	} {
+		if _, err := ParsePolicy([]byte(bad)); err == nil {
+			t.Fatalf("policy %s parsed", bad)
+		}
+	}
+
+	for peer, id := range map[n.Peer]string{
+		{Cert: "ops-anna"}:                  "ops-anna",
+		{Cert: "ops-anna", Token: "s3cret"}: "ops-anna",
+		{Token: "s3cret"}:                   "ci",
+		{Token: "guess"}:                    Anonymous,
+		{}:                                  Anonymous,
+	} {
+		if got := p.Identify(peer); got != id {
+			t.Fatalf("peer %v identified as %s, not %s", peer, got, id)
+		}
+	}
+
+	for _, c := range []struct {
+		who, verb, path string
+		allow           bool
+	}{
+		{Anonymous, Get, "/X1/web", true},
+		{Anonymous, Scrub, "/X1/web", false},
+		{"ci", Make, "/X1/build", true},
+		{"ci", Stdin, "/X1/build/step/1", true},
+		{"ci", Make, "/X1/web", false},
+		{"ops-anna", Signal, "/X1/web", true},
+		{"ops-anna", Signal, "/X1/web/proxy", false},
+		{"ops-anna", Stdin, "/X1/web", false},
+		{"dev", Signal, "/X1/web", false},
+	} {
+		if p.Allow(c.who, c.verb, c.path) != c.allow {
+			t.Fatalf("%s %s %s allowed is not %v", c.who, c.verb, c.path, c.allow)
+		}
+	}
+
+	// Guards of remote callers follow the policy in use; local guards allow all verbs
+	g := Guard{caller: &circuit.Caller{Peer: n.Peer{Token: "guess"}}, path: "/X1/build"}
+	if err := g.Check(Make); err != nil {
+		t.Fatalf("check without policy (%v)", err)
+	}
+	UsePolicy(p)
+	defer UsePolicy(nil)
+	if err := g.Check(Make); err == nil {
+		t.Fatalf("anonymous make allowed")
+	} else if !errors.Is(err, ErrDenied) {
+		t.Fatalf("denial %v", err)
+	}
+	if err := (Guard{}).Check(Make); err != nil {
+		t.Fatalf("local make (%v)", err)
+	}
+	g.caller.Peer.Token = "s3cret"
+	if err := g.Check(Make); err != nil {
+		t.Fatalf("ci make (%v)", err)
+	}
+}
+
+// testAddr is the address of a worker
+type testAddr n.WorkerID
+
+func (a testAddr) NetAddr() net.Addr    { return nil }
+func (a testAddr) String() string       { return string(a) }
+func (a testAddr) FileName() string     { return string(a) }
+func (a testAddr) WorkerID() n.WorkerID { return n.WorkerID(a) }
+
+func TestFrom(t *testing.T) {
+	g := Guard{caller: &circuit.Caller{Addr: testAddr("Q1")}}
+	if !g.From(testAddr("Q1")) || g.From(testAddr("Q2")) || (Guard{}).From(testAddr("Q1")) {
+		t.Fatalf("callers are not told apart by their worker")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	p, err := ParsePolicy([]byte(`{"rules": [
+		{"who": ["*"], "verbs": ["get"], "anchors": ["/X1/..."]},
+		{"who": ["ops"], "verbs": ["admin", "make"], "anchors": ["/X1", "/X2/build/..."]}
+	]}`))
+	if err != nil {
+		t.Fatalf("parse (%v)", err)
+	}
+	UsePolicy(p)
+	defer UsePolicy(nil)
+
+	a, err := OpenAudit(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("open (%v)", err)
+	}
+	defer a.Close()
+	UseAudit(a)
+	defer UseAudit(nil)
+
+	verbs := Verbs{"Peek": Get, "Suicide": Admin}
+	g := Guard{caller: &circuit.Caller{Peer: n.Peer{Cert: "dev"}}, path: "/X1"}
+	if err := g.Authorize(verbs, "Peek"); err != nil {
+		t.Fatalf("peek (%v)", err)
+	}
+	if err := g.Authorize(verbs, "Suicide"); !errors.Is(err, ErrDenied) {
+		t.Fatalf("suicide (%v)", err)
+	}
+	if err := g.At("/X9", Server).Authorize(verbs, "Peek"); !errors.Is(err, ErrDenied) {
+		t.Fatalf("peek elsewhere (%v)", err)
+	}
+	// Denials of verbs other than get are audited
+	if rec, _ := Audited(time.Time{}); len(rec) != 1 || rec[0].Verb != Admin || string(rec[0].Arg) != `"Suicide"` {
+		t.Fatalf("audited %+v", rec)
+	}
+	g.caller.Peer.Cert = "ops"
+	if err := g.Authorize(verbs, "Suicide"); err != nil {
+		t.Fatalf("ops suicide (%v)", err)
+	}
+
+	// Methods without a verb are denied to all callers
+	for _, g := range []Guard{g, {}} {
+		if err := g.Authorize(verbs, "Scrub"); !errors.Is(err, ErrDenied) {
+			t.Fatalf("scrub (%v)", err)
+		}
+	}
+
+	// Guards of the same caller at other anchors
+	g.path = "/X1/build/7"
+	if err := g.On("X2").Check(Make); err != nil {
+		t.Fatalf("make on X2 (%v)", err)
+	}
+	if err := g.At("/X3/build/7", Proc).Check(Make); !errors.Is(err, ErrDenied) {
+		t.Fatalf("make on X3 (%v)", err)
+	}
+}
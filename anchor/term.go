@@ -55,6 +55,18 @@ const (
 
 	// Volume
 	Volume = "volume"
+
+	// Image
+	Image = "image"
+
+	// Cluster of circuit servers
+	Cluster = "cluster"
+
+	// Mesos resource offer exchange
+	Mesos = "mesos"
+
+	// Job of parallel tasks
+	Job = "job"
 )
 
 func init() {
@@ -63,6 +75,13 @@ func init() {
 	registerOnLeave()
 }
 
+// Errors of anchor operations. They are registered with package use/errors,
+// so that remote callers can match them.
+var (
+	ErrBusy        = errors.New("anchor already has an element")
+	ErrKindUnknown = errors.New("element kind not known")
+)
+
 var efRepo = newElementFactoryRepo()
 
 // RegisterElement registers an element factory
@@ -74,11 +93,20 @@ func RegisterElement(kind string, efactory ElementFactory, yfactory YFactory) {
 type Terminal struct {
 	genus  Genus
 	anchor *Anchor
+	maker  Guard // caller making the element, given to element factories
 }
 
 type Genus interface {
 	NewArrivals() pubsub.Consumer
 	NewDepartures() pubsub.Consumer
+
+	// Lookup returns the cross-interface to the root terminal of the live
+	// circuit server with the given ID.
+	Lookup(server string) (circuit.PermX, bool)
+
+	// Hosts returns the host descriptions of the live circuit servers, by
+	// server ID, as last announced by the servers.
+	Hosts() map[string]server.HostStat
 }
 
 // NewTerm create the root node of a new anchor file system.
@@ -87,7 +115,7 @@ func NewTerm(name string, genus Genus) (*Terminal, circuit.PermX) {
 		genus:  genus,
 		anchor: newAnchor(nil, name).use(),
 	}
-	return t, circuit.PermRef(XTerminal{t})
+	return t, circuit.PermRef(XTerminal{t: t})
 }
 
 func (t *Terminal) carrier() *Anchor {
@@ -105,6 +133,39 @@ func (t *Terminal) Path() string {
 	return t.carrier().Path()
 }
 
+// Maker returns the guard of the caller making an element at t. Factories of
+// elements that act on other anchors check the verbs of their maker there.
+func (t *Terminal) Maker() Guard {
+	return t.maker
+}
+
+// Root returns the root terminal of the circuit server hosting t.
+func (t *Terminal) Root() *Terminal {
+	a := t.carrier().anchor
+	for a.parent != nil {
+		a = a.parent
+	}
+	return &Terminal{
+		genus:  t.genus,
+		anchor: a.use(),
+	}
+}
+
+// Peer returns the root terminal of the live circuit server with the given ID.
+// The terminal of this server cannot be called through; use Root instead.
+func (t *Terminal) Peer(server string) (YTerminal, bool) {
+	x, ok := t.genus.Lookup(server)
+	if !ok {
+		return YTerminal{}, false
+	}
+	return YTerminal{x}, true
+}
+
+// Hosts returns the hosts of the live circuit servers, by server ID.
+func (t *Terminal) Hosts() map[string]server.HostStat {
+	return t.genus.Hosts()
+}
+
 func (t *Terminal) View() map[string]*Terminal {
 	r := make(map[string]*Terminal)
 	for n, a := range t.carrier().View() {
@@ -116,6 +177,22 @@ func (t *Terminal) View() map[string]*Terminal {
 	return r
 }
 
+// Count returns the number of elements below t, by kind
+func (t *Terminal) Count() map[string]int {
+	r := make(map[string]int)
+	t.count(r)
+	return r
+}
+
+func (t *Terminal) count(r map[string]int) {
+	for _, u := range t.View() {
+		if kind, elem := u.Get(); elem != nil {
+			r[kind]++
+		}
+		u.count(r)
+	}
+}
+
 type urn struct {
 	kind string
 	elem Element // valve.Valve, proc.Proc, etc
@@ -143,13 +220,13 @@ func (t *Terminal) Make(kind string, arg interface{}) (eleme Element, err error)
 	t.carrier().TxLock()
 	defer t.carrier().TxUnlock()
 	if t.carrier().Get() != nil {
-		return nil, errors.New("anchor already has an element")
+		return nil, ErrBusy
 	}
 
 	// get the element factory
 	factory, ok := efRepo.GetEF(kind)
 	if !ok {
-		return nil, fmt.Errorf("element kind not known, kind=%s", kind)
+		return nil, fmt.Errorf("%w, kind=%s", ErrKindUnknown, kind)
 	}
 
 	// create the element
@@ -16,12 +16,27 @@ import (
 
 func init() {
 	circuit.RegisterValue(XTerminal{})
+	xerrors.Register(ErrBusy)
+	xerrors.Register(ErrKindUnknown)
+	xerrors.Register(ErrDenied)
 }
 
 // XTerminal represents the server side stub that receives the remote call from
-// YTerminal
+// YTerminal. Remote calls are bound to their caller, and checked against the
+// access policy in use.
 type XTerminal struct {
-	t *Terminal
+	t      *Terminal
+	caller *circuit.Caller
+}
+
+// Bind returns the terminal x, as called by c
+func (x XTerminal) Bind(c circuit.Caller) interface{} {
+	return XTerminal{t: x.t, caller: &c}
+}
+
+// guard returns the guard of the caller of x, applying verbs to an element of kind
+func (x XTerminal) guard(kind string) Guard {
+	return Guard{caller: x.caller, path: x.t.Path(), kind: kind}
 }
 
 func (x XTerminal) Path() string {
@@ -33,35 +48,67 @@ func (x XTerminal) Walk(walk []string) circuit.X {
 	if t == nil {
 		return nil
 	}
-	return circuit.Ref(XTerminal{t})
+	return circuit.Ref(XTerminal{t: t})
 }
 
 func (x XTerminal) View() map[string]circuit.X {
 	u := make(map[string]circuit.X)
 	for p, q := range x.t.View() {
-		u[p] = circuit.Ref(XTerminal{q})
+		u[p] = circuit.Ref(XTerminal{t: q})
 	}
 	return u
 }
 
 func (x XTerminal) Make(kind string, arg interface{}) (xelm circuit.X, err error) {
-	elm, err := x.t.Make(kind, arg)
+	g := x.guard(kind)
+	if err = g.Check(Make); err != nil {
+		g.Audit(Make, arg, err)
+		return nil, xerrors.Pack(err)
+	}
+	t := *x.t
+	t.maker = g
+	elm, err := t.Make(kind, arg)
+	g.Audit(Make, arg, err)
 	if err != nil {
 		return nil, xerrors.Pack(err)
 	}
-	return elm.X(), nil
+	return x.elementX(g, elm), nil
 }
 
+// Get panics if the caller may not get the element.
 func (x XTerminal) Get() (string, circuit.X) {
 	kind, elm := x.t.Get()
 	if elm == nil {
 		return "", nil
 	}
-	return kind, elm.X()
+	g := x.guard(kind)
+	if err := g.Check(Get); err != nil {
+		panic(err)
+	}
+	return kind, x.elementX(g, elm)
 }
 
+// elementX returns the cross-interface of elm for the caller guarded by g
+func (x XTerminal) elementX(g Guard, elm Element) circuit.X {
+	if u, ok := elm.(Guarded); ok {
+		return u.GuardedX(g)
+	}
+	return elm.X()
+}
+
+// Scrub panics if the caller may not scrub the element.
 func (x XTerminal) Scrub() {
+	kind, elm := x.t.Get()
+	if elm == nil {
+		return
+	}
+	g := x.guard(kind)
+	if err := g.Check(Scrub); err != nil {
+		g.Audit(Scrub, nil, err)
+		panic(err)
+	}
 	x.t.Scrub()
+	g.Audit(Scrub, nil, nil)
 }
 
 // YTerminal represents client side stub used for making remote calls.
@@ -102,7 +149,7 @@ func (y YTerminal) Make(kind string, arg interface{}) (yelm interface{}, err err
 
 	factory, ok := efRepo.GetYF(kind)
 	if !ok {
-		return nil, fmt.Errorf("element kind=%s not known", kind)
+		return nil, fmt.Errorf("%w, kind=%s", ErrKindUnknown, kind)
 	}
 
 	return factory(x)
@@ -119,7 +166,7 @@ func (y YTerminal) Get() (kind string, yelm interface{}) {
 
 	factory, ok := efRepo.GetYF(kind)
 	if !ok {
-		return "", fmt.Errorf("element kind=%s not known", kind)
+		return "", fmt.Errorf("%w, kind=%s", ErrKindUnknown, kind)
 	}
 
 	v, _ := factory(x)
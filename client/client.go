@@ -11,6 +11,7 @@ Package client provides access to the circuit programming environment to user pr
 package client
 
 import (
+	"crypto/tls"
 	"errors"
 	"net"
 	"reflect"
@@ -19,6 +20,7 @@ import (
 	"github.com/gocircuit/circuit/anchor"
 	"github.com/gocircuit/circuit/kit/assemble"
 	_ "github.com/gocircuit/circuit/kit/debug/kill"
+	"github.com/gocircuit/circuit/kit/tele/aead"
 	"github.com/gocircuit/circuit/sys/lang"
 	_ "github.com/gocircuit/circuit/sys/tele"
 	"github.com/gocircuit/circuit/tissue"
@@ -29,9 +31,9 @@ import (
 
 var _once sync.Once
 
-func _init(key []byte) {
+func _init(sec n.Security) {
 	//rand.Seed(time.Now().UnixNano())
-	t := n.NewTransport(n.ChooseWorkerID(), &net.TCPAddr{}, key)
+	t := n.NewTransport(n.ChooseWorkerID(), &net.TCPAddr{}, sec)
 	//fmt.Println(t.Addr().String())
 	circuit.Bind(lang.New(t))
 }
@@ -45,15 +47,45 @@ type Client struct {
 // Circuit addresses are printed to standard output when a server is started with the
 // "circuit start …" command.
 //
-// If authkey is non-nil it is used as a private key and all communications are
-// secured by HMAC authentication and RC4 symmetric encryption;
-// otherwise transmissions are in plaintext.
+// If authkey is non-nil it is used as a private key shared by the circuit, and all
+// communications are authenticated and encrypted with AES-GCM or ChaCha20-Poly1305
+// under keys derived from it; otherwise transmissions are in plaintext.
 //
 // Errors in communication, such as a missing server, or invalid URL format
 // are reported through panics.
 func Dial(addr string, authkey []byte) *Client {
+	return dial(addr, n.Security{Keys: keyring(authkey)})
+}
+
+// DialKeyring is like Dial, except that it authenticates with any of the keys in
+// keys, which the circuit may be in the process of rotating.
+func DialKeyring(addr string, keys *aead.Keyring) *Client {
+	return dial(addr, n.Security{Keys: keys})
+}
+
+// DialTLS is like Dial, except that communications are secured by mutual TLS
+// authentication and encryption, as configured by config.
+// Use package kit/tele/tls to load the configuration from certificate files.
+func DialTLS(addr string, config *tls.Config) *Client {
+	return dial(addr, n.Security{TLS: config})
+}
+
+// DialSecure is like Dial, with the transport security and client token in sec.
+// The token identifies the client to servers enforcing an access policy.
+func DialSecure(addr string, sec n.Security) *Client {
+	return dial(addr, sec)
+}
+
+func keyring(authkey []byte) *aead.Keyring {
+	if authkey == nil {
+		return nil
+	}
+	return aead.NewKeyring(authkey)
+}
+
+func dial(addr string, sec n.Security) *Client {
 	_once.Do(func() {
-		_init(authkey)
+		_init(sec)
 	})
 	c := &Client{}
 	w, err := n.ParseAddr(addr)
@@ -65,12 +97,31 @@ func Dial(addr string, authkey []byte) *Client {
 }
 
 func DialDiscover(multicast string, authkey []byte) *Client {
+	return dialDiscover(multicast, n.Security{Keys: keyring(authkey)})
+}
+
+// DialDiscoverKeyring is like DialDiscover, with a keyring as in DialKeyring.
+func DialDiscoverKeyring(multicast string, keys *aead.Keyring) *Client {
+	return dialDiscover(multicast, n.Security{Keys: keys})
+}
+
+// DialDiscoverTLS is like DialDiscover, with mutual TLS security as in DialTLS.
+func DialDiscoverTLS(multicast string, config *tls.Config) *Client {
+	return dialDiscover(multicast, n.Security{TLS: config})
+}
+
+// DialDiscoverSecure is like DialDiscover, with the security in sec as in DialSecure.
+func DialDiscoverSecure(multicast string, sec n.Security) *Client {
+	return dialDiscover(multicast, sec)
+}
+
+func dialDiscover(multicast string, sec n.Security) *Client {
 	mcast, err := net.ResolveUDPAddr("udp", multicast)
 	if err != nil {
 		panic(err)
 	}
 	_once.Do(func() {
-		_init(authkey)
+		_init(sec)
 	})
 	c := &Client{}
 	dialback := assemble.NewAssembler(circuit.ServerAddr(), mcast).AssembleClient()
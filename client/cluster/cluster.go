@@ -1,5 +1,16 @@
 package cluster
 
+import (
+	"encoding/gob"
+	"time"
+
+	"github.com/gocircuit/circuit/client/server"
+)
+
+func init() {
+	gob.Register(ClusterConfig{})
+}
+
 // Cluster (maintains context for a circuit cluster)
 type Cluster interface {
 	// AddHost adds a new host to the cluster
@@ -39,8 +50,8 @@ type Cluster interface {
 	// Signal all the hosts in the cluster
 	Signal(sig string) error
 
-	// Stack provides the runtime statck trace for the requested server at host
-	Stack(hostName string)
+	// Stack returns the runtime stack trace of the server at host
+	Stack(hostName string) (string, error)
 
 	// Start a stopped host in the cluster
 	Start(hostName string) error
@@ -51,13 +62,27 @@ type Cluster interface {
 	// Stats provide live stream of resouce usage statistics for a server in the cluster
 	//Stats(name string)
 
-	// Top displays the running processes
-	Top(hostName string)
+	// Top samples the resource usage of the server at host
+	Top(hostName string) (*server.ServerTop, error)
 }
 
+// Launchers available to host configurations
+const (
+	// LauncherLocal starts the server as a child process of the circuit
+	// server hosting the cluster element
+	LauncherLocal = "local"
+
+	// LauncherSSH starts the server on HostConfig.Host over ssh
+	LauncherSSH = "ssh"
+)
+
 type ClusterConfig struct {
 	Name  string       `json:"name,omitempty"`
 	Hosts []HostConfig `json:"hosts,omitempty"`
+
+	// Join is the address of a circuit member the cluster joins. When empty,
+	// the first host to start forms a new circuit.
+	Join string `json:"join,omitempty"`
 }
 
 type HostConfig struct {
@@ -70,14 +95,71 @@ type HostConfig struct {
 	// Host network interface
 	If string `json:"if,omitempty"`
 
-	//
+	// Files are paths at the host, read by the server started there. No
+	// launcher copies them to the host; they must be in place beforehand.
+
+	// File with the HMAC credentials of the circuit, at the host
 	Hmac string `json:"hmac,omitempty"`
 
+	// Files with the TLS certificate, private key and authority of the server, at the host
+	TLSCert string `json:"tls_cert,omitempty"`
+	TLSKey  string `json:"tls_key,omitempty"`
+	TLSCA   string `json:"tls_ca,omitempty"`
+
+	// File listing the serial numbers of revoked certificates, at the host
+	TLSRevoked string `json:"tls_revoked,omitempty"`
+
+	// Access token identifying the server to peers enforcing an access policy.
+	// The server receives it in the environment CIRCUIT_TOKEN, not as an argument.
+	Token string `json:"token,omitempty"`
+
+	// File with the access policy of the server, at the host
+	Policy string `json:"policy,omitempty"`
+
+	// File or OTLP collector URL to export traces of the server to
+	Trace string `json:"trace,omitempty"`
+
+	// Launcher starting the server, LauncherLocal when empty
+	Launcher string `json:"launcher,omitempty"`
+
+	// Host is the machine reached by remote launchers, e.g. user@host for ssh
+	Host string `json:"host,omitempty"`
+
+	// Circuit is the path of the circuit executable at the host
+	Circuit string `json:"circuit,omitempty"`
+
+	// Var is the lock and log directory of the server
+	Var string `json:"var,omitempty"`
+
 	// Export to dns flag
 }
 
+// HostState is the life cycle state of a host in the cluster
+type HostState string
+
+const (
+	HostStarting HostState = "starting"
+	HostRunning  HostState = "running"
+	HostStopped  HostState = "stopped"
+	HostFailed   HostState = "failed"
+)
+
 type InspectClusterConfig struct {
+	Name  string     `json:"name,omitempty"`
+	Join  string     `json:"join,omitempty"`
+	Hosts []HostInfo `json:"hosts,omitempty"`
 }
 
 type HostInfo struct {
+	Config HostConfig `json:"config"`
+	State  HostState  `json:"state"`
+
+	// Addr is the circuit address of the running server
+	Addr string `json:"addr,omitempty"`
+
+	Started time.Time `json:"started,omitempty"`
+	Stopped time.Time `json:"stopped,omitempty"`
+
+	// Err is the reason the server failed
+	Err string `json:"err,omitempty"`
 }
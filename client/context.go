@@ -0,0 +1,323 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+
+	"github.com/gocircuit/circuit/anchor"
+	"github.com/gocircuit/circuit/use/circuit"
+	"github.com/gocircuit/circuit/use/n"
+)
+
+// Errors of the context-aware API. Errors it returns match one of these, or
+// the error of the context, under errors.Is.
+var (
+	// ErrServerGone reports that the circuit server hosting an anchor is
+	// dead or unreachable, or not a member of the circuit
+	ErrServerGone = errors.New("circuit server is gone")
+
+	// ErrBusy reports that an element cannot be made at an anchor which has one
+	ErrBusy = anchor.ErrBusy
+
+	// ErrKindUnknown reports an element kind which the server does not support
+	ErrKindUnknown = anchor.ErrKindUnknown
+
+	// ErrDenied reports an operation which the access policy of the server does not allow
+	ErrDenied = anchor.ErrDenied
+)
+
+// Error is an error of a remote call, classified by one of the errors of the context-aware API.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// remoteMark starts the server-side stack trace the runtime appends to panics of remote calls
+const remoteMark = "\nserver-side runtime.call("
+
+// remoteError is the error of a panic of a remote method, whose text omits
+// the server-side stack trace.
+type remoteError struct {
+	err error
+}
+
+func (e *remoteError) Error() string {
+	s := e.err.Error()
+	if i := strings.Index(s, remoteMark); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func (e *remoteError) Unwrap() error {
+	return e.err
+}
+
+// classify returns err as an Error, if it matches one of the anchor errors
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	for _, kind := range []error{ErrBusy, ErrKindUnknown, ErrDenied} {
+		if errors.Is(err, kind) {
+			return &Error{Kind: kind, Err: err}
+		}
+	}
+	return err
+}
+
+// recovered returns the error of panic r, raised by a remote call, or nil if r
+// is not the panic of a remote call. Panics of the remote method are
+// classified, those of calls given up on with their context are returned as
+// is, and those of calls that cannot reach the server report a dead server.
+func recovered(r interface{}) error {
+	err, ok := r.(error)
+	switch {
+	case !ok:
+		return nil
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	case errors.Is(err, circuit.ErrUnreachable):
+		return &Error{Kind: ErrServerGone, Err: err}
+	case errors.Is(err, circuit.ErrRemote):
+		return classify(&remoteError{err})
+	}
+	return nil
+}
+
+// Do calls f, returning its error or the error of a panic of a call to the
+// elements of a circuit it raises, as errors of the context-aware API. Other
+// panics of f are raised again by Do. If ctx is done first, Do returns the
+// error of the context and leaves f to complete in the background.
+func Do(ctx context.Context, f func() error) error {
+	_, err := do(ctx, func() (struct{}, error) {
+		return struct{}{}, f()
+	})
+	return err
+}
+
+func do[T any](ctx context.Context, f func() (T, error)) (r T, err error) {
+	if err = ctx.Err(); err != nil {
+		return r, err
+	}
+	type result struct {
+		r     T
+		err   error
+		panic interface{}
+	}
+	ch := make(chan result, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				if err := recovered(p); err != nil {
+					ch <- result{err: err}
+				} else {
+					ch <- result{panic: p}
+				}
+			}
+		}()
+		r, err := f()
+		ch <- result{r: r, err: classify(err)}
+	}()
+	select {
+	case u := <-ch:
+		if u.panic != nil {
+			panic(u.panic)
+		}
+		return u.r, u.err
+	case <-ctx.Done():
+		return r, ctx.Err()
+	}
+}
+
+// DialContext is like Dial, with the security in sec as in DialSecure. Rather
+// than panicking, it returns an error if the server cannot be reached or ctx is
+// done first.
+func DialContext(ctx context.Context, addr string, sec n.Security) (*Client, error) {
+	if _, err := n.ParseAddr(addr); err != nil {
+		return nil, fmt.Errorf("circuit address does not parse (%v)", err)
+	}
+	return do(ctx, func() (*Client, error) {
+		c := dial(addr, sec)
+		c.ServerID()
+		return c, nil
+	})
+}
+
+// DialDiscoverContext is like DialDiscover, with the errors of DialContext.
+func DialDiscoverContext(ctx context.Context, multicast string, sec n.Security) (*Client, error) {
+	if _, err := net.ResolveUDPAddr("udp", multicast); err != nil {
+		return nil, err
+	}
+	return do(ctx, func() (*Client, error) {
+		c := dialDiscover(multicast, sec)
+		c.ServerID()
+		return c, nil
+	})
+}
+
+// AnchorContext is implemented by the anchors of the client, which are
+// Anchors. It is a separate interface so that Anchors implemented elsewhere
+// remain Anchors. Its methods are like those of Anchor, except that they
+// return errors, rather than panic, and give up when ctx is done. Errors match
+// ErrServerGone, ErrBusy, ErrKindUnknown, ErrDenied or the error of ctx under
+// errors.Is. The anchors they return are AnchorContexts as well.
+type AnchorContext interface {
+	Anchor
+	WalkContext(ctx context.Context, walk []string) (Anchor, error)
+	ViewContext(ctx context.Context) (map[string]Anchor, error)
+	MakeContext(ctx context.Context, typ reflect.Type, arg any) (any, error)
+	GetContext(ctx context.Context) (any, error)
+	ScrubContext(ctx context.Context) error
+}
+
+// contextOf returns a as an AnchorContext. Anchors implemented elsewhere are
+// adapted, so that their panics are returned as errors.
+func contextOf(a Anchor) AnchorContext {
+	if ac, ok := a.(AnchorContext); ok {
+		return ac
+	}
+	return anchorContext{a}
+}
+
+// anchorContext adapts an Anchor to AnchorContext
+type anchorContext struct {
+	Anchor
+}
+
+func (a anchorContext) WalkContext(ctx context.Context, walk []string) (Anchor, error) {
+	return do(ctx, func() (Anchor, error) {
+		return a.Walk(walk), nil
+	})
+}
+
+func (a anchorContext) ViewContext(ctx context.Context) (map[string]Anchor, error) {
+	return do(ctx, func() (map[string]Anchor, error) {
+		return a.View(), nil
+	})
+}
+
+func (a anchorContext) MakeContext(ctx context.Context, typ reflect.Type, arg any) (any, error) {
+	return do(ctx, func() (any, error) {
+		return a.Make(typ, arg)
+	})
+}
+
+func (a anchorContext) GetContext(ctx context.Context) (any, error) {
+	return do(ctx, func() (any, error) {
+		return a.Get(), nil
+	})
+}
+
+func (a anchorContext) ScrubContext(ctx context.Context) error {
+	return Do(ctx, func() error {
+		a.Scrub()
+		return nil
+	})
+}
+
+// WalkContext is like Walk. It returns ErrServerGone if the first element of
+// walk is not a live circuit server.
+func (c *Client) WalkContext(ctx context.Context, walk []string) (Anchor, error) {
+	if len(walk) == 0 {
+		return c, nil
+	}
+	return do(ctx, func() (Anchor, error) {
+		a := c.Walk(walk)
+		if a == nil {
+			return nil, &Error{Kind: ErrServerGone, Err: fmt.Errorf("server %s is not in the circuit", walk[0])}
+		}
+		return a, nil
+	})
+}
+
+// ViewContext is like View.
+func (c *Client) ViewContext(ctx context.Context) (map[string]Anchor, error) {
+	return do(ctx, func() (map[string]Anchor, error) {
+		return c.View(), nil
+	})
+}
+
+// MakeContext fails, as Make does, for the root-level anchor.
+func (c *Client) MakeContext(ctx context.Context, typ reflect.Type, arg any) (any, error) {
+	return nil, errors.New("cannot create elements outside of servers")
+}
+
+// GetContext is an Anchor interface method, not applicable to the root-level anchor.
+func (c *Client) GetContext(ctx context.Context) (any, error) {
+	return nil, nil
+}
+
+// ScrubContext is an Anchor interface method, not applicable to the root-level anchor.
+func (c *Client) ScrubContext(ctx context.Context) error {
+	return nil
+}
+
+func (t terminal) WalkContext(ctx context.Context, walk []string) (Anchor, error) {
+	return anchorContext{t}.WalkContext(ctx, walk)
+}
+
+func (t terminal) ViewContext(ctx context.Context) (map[string]Anchor, error) {
+	return anchorContext{t}.ViewContext(ctx)
+}
+
+func (t terminal) MakeContext(ctx context.Context, typ reflect.Type, arg any) (any, error) {
+	return anchorContext{t}.MakeContext(ctx, typ, arg)
+}
+
+func (t terminal) GetContext(ctx context.Context) (any, error) {
+	return anchorContext{t}.GetContext(ctx)
+}
+
+func (t terminal) ScrubContext(ctx context.Context) error {
+	return anchorContext{t}.ScrubContext(ctx)
+}
+
+// MakeElementContext is like MakeElement, with the errors of the context-aware
+// API. The anchor a need not be an AnchorContext.
+func MakeElementContext[T any](ctx context.Context, a Anchor, arg any, path ...string) (t T, err error) {
+	if a, err = contextOf(a).WalkContext(ctx, path); err != nil {
+		return t, err
+	}
+	el, err := contextOf(a).MakeContext(ctx, reflect.TypeOf((*T)(nil)).Elem(), arg)
+	if err != nil {
+		return t, err
+	}
+	t, _ = el.(T)
+	return t, nil
+}
+
+// GetElementContext is like GetElement, with the errors of the context-aware
+// API. It returns the zero T and no error if the anchor holds no element of
+// type T. The anchor a need not be an AnchorContext.
+func GetElementContext[T any](ctx context.Context, a Anchor, path []string) (t T, err error) {
+	if a, err = contextOf(a).WalkContext(ctx, path); err != nil {
+		return t, err
+	}
+	el, err := contextOf(a).GetContext(ctx)
+	if err != nil {
+		return t, err
+	}
+	t, _ = el.(T)
+	return t, nil
+}
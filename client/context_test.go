@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gocircuit/circuit/use/circuit"
+	xerrors "github.com/gocircuit/circuit/use/errors"
+)
+
+// callPanic is like the errors cross-calls panic with
+type callPanic struct {
+	kind, err error
+}
+
+func (e *callPanic) Error() string        { return e.err.Error() }
+func (e *callPanic) Is(target error) bool { return target == e.kind }
+func (e *callPanic) Unwrap() error        { return e.err }
+
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+	for _, c := range []struct {
+		f    func() error
+		kind error
+	}{
+		{func() error { return xerrors.Pack(ErrBusy) }, ErrBusy},
+		{func() error { return xerrors.Pack(fmt.Errorf("%w, kind=vm", ErrKindUnknown)) }, ErrKindUnknown},
+		{func() error {
+			err := xerrors.Annotate(fmt.Errorf("%w: anonymous may not scrub /X1/p", ErrDenied), remoteMark+"…)")
+			panic(&callPanic{circuit.ErrRemote, err})
+		}, ErrDenied},
+		{func() error { panic(&callPanic{circuit.ErrUnreachable, io.ErrUnexpectedEOF}) }, ErrServerGone},
+		{func() error { panic(context.Canceled) }, context.Canceled},
+	} {
+		err := Do(ctx, c.f)
+		if !errors.Is(err, c.kind) {
+			t.Fatalf("error %v is not %v", err, c.kind)
+		}
+	}
+	// Errors are matched by identity, not by their text
+	if err := Do(ctx, func() error { return errors.New(ErrBusy.Error()) }); errors.Is(err, ErrBusy) {
+		t.Fatalf("lookalike %v is busy", err)
+	}
+	err := Do(ctx, func() error { panic(&callPanic{circuit.ErrRemote, xerrors.NewError("boom" + remoteMark + "…)")}) })
+	if err == nil || err.Error() != "boom" {
+		t.Fatalf("remote panic %v", err)
+	}
+	// Panics other than those of calls are raised again
+	for _, p := range []interface{}{"no method ‘Scrub’", io.ErrUnexpectedEOF} {
+		func() {
+			defer func() {
+				if r := recover(); r != p {
+					t.Fatalf("panic %v, expected %v", r, p)
+				}
+			}()
+			Do(ctx, func() error { panic(p) })
+		}()
+	}
+	if err = Do(ctx, func() error { return nil }); err != nil {
+		t.Fatalf("success %v", err)
+	}
+
+	// Calls outliving their context are abandoned
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err = Do(ctx, func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("deadline %v", err)
+	}
+	if err = Do(ctx, func() error { return nil }); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("done context %v", err)
+	}
+}
+
+// Anchors of the client are AnchorContexts
+var (
+	_ AnchorContext = (*Client)(nil)
+	_ AnchorContext = terminal{}
+)
+
+// foreignAnchor is an Anchor implemented outside of the client
+type foreignAnchor struct {
+	Anchor
+	el any
+}
+
+func (a foreignAnchor) Walk(walk []string) Anchor {
+	if len(walk) > 0 && walk[0] == "gone" {
+		panic(&callPanic{circuit.ErrUnreachable, io.ErrUnexpectedEOF})
+	}
+	return a
+}
+
+func (a foreignAnchor) Get() any {
+	return a.el
+}
+
+func TestForeignAnchor(t *testing.T) {
+	ctx := context.Background()
+	a := foreignAnchor{el: "element"}
+	if el, err := GetElementContext[string](ctx, a, []string{"x"}); err != nil || el != "element" {
+		t.Fatalf("element %q (%v)", el, err)
+	}
+	if _, err := GetElementContext[string](ctx, a, []string{"gone"}); !errors.Is(err, ErrServerGone) {
+		t.Fatalf("error %v is not %v", err, ErrServerGone)
+	}
+}
@@ -0,0 +1,101 @@
+// Package job provides the client interface of the job element, which runs
+// an array of parallel tasks across circuit servers.
+package job
+
+import (
+	"encoding/gob"
+
+	"github.com/gocircuit/circuit/client"
+)
+
+func init() {
+	gob.Register(Spec{})
+}
+
+// Failure policies
+const (
+	// Continue runs the remaining tasks when a task fails
+	Continue = "continue"
+	// Abort kills the running tasks, and starts no more, when a task fails
+	Abort = "abort"
+)
+
+// Spec describes a job of parallel tasks
+type Spec struct {
+	// Tasks is the number of tasks
+	Tasks int `json:"tasks"`
+
+	// Cmd is the command of every task. Its Env, Dir, Path and Args are
+	// templates, in the syntax of text/template, of {{.Index}}, the index of
+	// the task from 0, and {{.Tasks}}, the number of tasks.
+	Cmd client.Cmd `json:"cmd"`
+
+	// Where lists the constraints on the servers tasks are placed on, in the
+	// syntax of client.ParseConstraints. Tasks are spread across the servers
+	// meeting them.
+	Where string `json:"where,omitempty"`
+
+	// Parallel is the largest number of tasks running at once, or zero for
+	// no limit
+	Parallel int `json:"parallel,omitempty"`
+
+	// Retries is the number of times a failed task is run again, each time on
+	// another server when there is one
+	Retries int `json:"retries,omitempty"`
+
+	// Policy is Continue, the default, or Abort
+	Policy string `json:"policy,omitempty"`
+}
+
+// States of jobs and tasks
+const (
+	Pending = "pending"
+	Running = "running"
+	Done    = "done"
+	Failed  = "failed"
+	Aborted = "aborted"
+)
+
+// TaskStat is the state of a task
+type TaskStat struct {
+	Index    int    `json:"index"`
+	State    string `json:"state"`
+	Attempts int    `json:"attempts"`
+
+	// Server and Anchor locate the process of the current or last attempt
+	Server string `json:"server,omitempty"`
+	Anchor string `json:"anchor,omitempty"`
+
+	// Exit describes the failure of the last failed attempt
+	Exit string `json:"exit,omitempty"`
+}
+
+// Stat is the state of a job. A job is Running until all tasks are done,
+// failed or aborted. It is then Done if all tasks are done, and Failed
+// otherwise.
+type Stat struct {
+	State   string     `json:"state"`
+	Pending int        `json:"pending"`
+	Running int        `json:"running"`
+	Done    int        `json:"done"`
+	Failed  int        `json:"failed"`
+	Aborted int        `json:"aborted"`
+	Tasks   []TaskStat `json:"tasks"`
+}
+
+// Job provides access to a circuit job element.
+// All methods panic if the hosting circuit server dies.
+type Job interface {
+
+	// Wait blocks until the job is no longer running and returns its final state.
+	// An error is returned only if the wait is aborted by a call to Scrub.
+	Wait() (Stat, error)
+
+	// Peek asynchronously returns the current state of the job.
+	Peek() Stat
+
+	PeekBytes() []byte
+
+	// Scrub kills the running tasks and removes the job element.
+	Scrub()
+}
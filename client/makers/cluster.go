@@ -0,0 +1,21 @@
+package makers
+
+import (
+	"reflect"
+
+	"github.com/gocircuit/circuit/client"
+	"github.com/gocircuit/circuit/client/cluster"
+)
+
+var ClusterType = reflect.TypeOf((*cluster.Cluster)(nil)).Elem()
+
+func init() {
+	client.RegisterElementMaker(&clusterElementMaker{
+		client.NewBaseElementMaker("cluster", ClusterType),
+	})
+}
+
+// implementation for a specific maker
+type clusterElementMaker struct {
+	client.BaseElementMaker
+}
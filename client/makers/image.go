@@ -0,0 +1,21 @@
+package makers
+
+import (
+	"reflect"
+
+	"github.com/gocircuit/circuit/client"
+	"github.com/gocircuit/circuit/client/podman"
+)
+
+var ImageType = reflect.TypeOf((*podman.Image)(nil)).Elem()
+
+func init() {
+	client.RegisterElementMaker(&imageElementMaker{
+		client.NewBaseElementMaker("image", ImageType),
+	})
+}
+
+// implementation for a specific maker
+type imageElementMaker struct {
+	client.BaseElementMaker
+}
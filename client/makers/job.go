@@ -0,0 +1,21 @@
+package makers
+
+import (
+	"reflect"
+
+	"github.com/gocircuit/circuit/client"
+	"github.com/gocircuit/circuit/client/job"
+)
+
+var JobType = reflect.TypeOf((*job.Job)(nil)).Elem()
+
+func init() {
+	client.RegisterElementMaker(&jobElementMaker{
+		client.NewBaseElementMaker("job", JobType),
+	})
+}
+
+// implementation for a specific maker
+type jobElementMaker struct {
+	client.BaseElementMaker
+}
@@ -0,0 +1,21 @@
+package makers
+
+import (
+	"reflect"
+
+	"github.com/gocircuit/circuit/client"
+	"github.com/gocircuit/circuit/client/mesos"
+)
+
+var MesosType = reflect.TypeOf((*mesos.Mesos)(nil)).Elem()
+
+func init() {
+	client.RegisterElementMaker(&mesosElementMaker{
+		client.NewBaseElementMaker("mesos", MesosType),
+	})
+}
+
+// implementation for a specific maker
+type mesosElementMaker struct {
+	client.BaseElementMaker
+}
@@ -1,6 +1,7 @@
 package makers
 
 import (
+	"context"
 	"reflect"
 
 	"github.com/gocircuit/circuit/anchor"
@@ -67,6 +68,14 @@ func (y yprocProc) Wait() (client.ProcStat, error) {
 	return s, nil
 }
 
+func (y yprocProc) WaitContext(ctx context.Context) (client.ProcStat, error) {
+	s, err := y.YProc.WaitContext(ctx)
+	if err != nil {
+		return client.ProcStat{}, err
+	}
+	return s, nil
+}
+
 func (y yprocProc) GetCmd() client.Cmd {
 	return y.YProc.GetCmd()
 }
@@ -0,0 +1,124 @@
+// Package mesos provides the client interface of the mesos element, a
+// real-time resource negotiation exchange inspired by Apache Mesos.
+package mesos
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of the resources every circuit server offers. Memory and disk are
+// counted in MiB, and offered as far as they are free. Labels of a server whose values are integers, such as
+// gpu=2, are offered as resources as well.
+const (
+	CPU  = "cpu"
+	Mem  = "mem"
+	Disk = "disk"
+)
+
+// Resource maps resource names to numbers of units
+type Resource map[string]int
+
+// ParseResource parses a comma-separated list of name=units pairs, such as
+// "cpu=2,mem=512"
+func ParseResource(s string) (Resource, error) {
+	r := make(Resource)
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(t, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("resource %q is not of the form name=units", t)
+		}
+		u, err := strconv.Atoi(v)
+		if err != nil || u < 0 {
+			return nil, fmt.Errorf("resource %q does not have a count of units", t)
+		}
+		r[k] = u
+	}
+	return r, nil
+}
+
+func (r Resource) String() string {
+	s := make([]string, 0, len(r))
+	for k, v := range r {
+		s = append(s, fmt.Sprintf("%s=%d", k, v))
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
+
+// Covers reports whether r has at least the units of every resource in q
+func (r Resource) Covers(q Resource) bool {
+	for k, v := range q {
+		if r[k] < v {
+			return false
+		}
+	}
+	return true
+}
+
+// Grant is a lease of resources of a worker to a framework. Grants are held
+// until released. A grant is released by the exchange when the worker leaves
+// the circuit, or when the framework is an anchor path, such as
+// /X1234/job/1, and its server leaves the circuit, or the element it held
+// when the grant was made is scrubbed.
+type Grant struct {
+	ID        string    `json:"id"`
+	Framework string    `json:"framework"`
+	Worker    string    `json:"worker"`
+	Resource  Resource  `json:"resource"`
+	Time      time.Time `json:"time"`
+}
+
+// Offer describes the resources a worker offers to the exchange
+type Offer struct {
+	Worker string `json:"worker"`
+
+	// Offered is the resource offered by the worker; Spare is the part of it
+	// which is not granted.
+	Offered Resource `json:"offered"`
+	Spare   Resource `json:"spare"`
+
+	// Pinned offers were set by a call to Offer, rather than taken from what
+	// the server announces about its host.
+	Pinned bool `json:"pinned,omitempty"`
+}
+
+// Stat is the state of the exchange
+type Stat struct {
+	Offers []Offer `json:"offers"`
+	Grants []Grant `json:"grants"`
+}
+
+// Mesos is a resource exchange. Every live circuit server offers the
+// resources of its host, and frameworks ask for leases against the offers.
+// All methods panic if the hosting circuit server dies.
+type Mesos interface {
+
+	// Offer pins the resource offered by the server worker to rsc. A nil rsc
+	// returns the worker to offering what its host announces. Like Ask and
+	// Release, Offer needs the make verb at the anchor of the exchange. Unless
+	// it is called by worker itself, it needs the admin verb there as well.
+	Offer(worker string, rsc Resource) error
+
+	// Ask grants rsc to framework from the worker with the most spare CPU
+	// among those that can cover it. Ask does not block for resources to
+	// become available.
+	Ask(framework string, rsc Resource) (*Grant, error)
+
+	// Release returns the resources of a grant to its worker
+	Release(grant string) error
+
+	// Peek returns the outstanding offers and grants
+	Peek() Stat
+
+	PeekBytes() []byte
+
+	// Scrub releases all grants and abandons the exchange
+	Scrub()
+}
@@ -21,11 +21,25 @@ type Container interface {
 	// Pause the processes in the container
 	Pause() error
 
-	// Stats()
-	// Peek at the container's resource ussage statistics
+	// Peek at the container's configuration and state
 	Peek() (*InspectContainerData, error)
 	PeekBytes() []byte
 
+	// Stats subscribes to resource usage samples (*ContainerStats) of the
+	// running container. The first sample is the most recent one, if any.
+	Stats() Subscription
+
+	// Events subscribes to lifecycle events (*ContainerEvent) of the container.
+	Events() Subscription
+
+	// Migrate checkpoints the container, restores it under the same anchor path
+	// on the circuit server with the given ID and scrubs the original anchor.
+	// If the restore fails, the container is restored in place. If that fails
+	// too, or the target cannot tell whether it restored the container, the
+	// error names the checkpoint archive, which is kept for manual recovery.
+	// Migrate returns the path of the new anchor.
+	Migrate(server string) (string, error)
+
 	// Get port mappings
 	Ports() []string
 
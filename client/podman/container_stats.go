@@ -0,0 +1,62 @@
+package podman
+
+import (
+	"encoding/gob"
+	"time"
+)
+
+// Container event statuses published on the subscription returned by Container.Events.
+const (
+	EventStart        = "start"
+	EventDie          = "die"
+	EventOOM          = "oom"
+	EventHealthStatus = "health_status"
+)
+
+// ContainerStats is a sample of the resource usage of a container.
+// Field names match the stats reported by podman.
+type ContainerStats struct {
+	// Time the sample was taken.
+	Time time.Time `json:"Time,omitempty"`
+	// CPU is the CPU usage in percent since the previous sample.
+	CPU float64 `json:"CPU"`
+	// MemUsage and MemLimit are in bytes, MemPerc in percent.
+	MemUsage uint64  `json:"MemUsage"`
+	MemLimit uint64  `json:"MemLimit"`
+	MemPerc  float64 `json:"MemPerc"`
+	// NetInput and NetOutput are the bytes received and sent on all interfaces.
+	NetInput  uint64 `json:"NetInput"`
+	NetOutput uint64 `json:"NetOutput"`
+	// BlockInput and BlockOutput are the bytes read from and written to block devices.
+	BlockInput  uint64 `json:"BlockInput"`
+	BlockOutput uint64 `json:"BlockOutput"`
+	PIDs        uint64 `json:"PIDs"`
+}
+
+// ContainerEvent is a lifecycle event of a container.
+type ContainerEvent struct {
+	// Status is one of EventStart, EventDie, EventOOM or EventHealthStatus.
+	Status string
+	// Time the event occurred.
+	Time time.Time
+	// ExitCode of the container, set on EventDie.
+	ExitCode int
+	// HealthStatus of the container, set on EventHealthStatus.
+	HealthStatus string
+	// Attributes are the labels and other details reported with the event.
+	Attributes map[string]string
+}
+
+// Subscription is a stream of values published by a podman element.
+type Subscription interface {
+	// Consume blocks until the next value is available; ok is false once the stream is closed.
+	Consume() (v interface{}, ok bool)
+
+	// Scrub abandons the subscription.
+	Scrub()
+}
+
+func init() {
+	gob.Register(&ContainerStats{})
+	gob.Register(&ContainerEvent{})
+}
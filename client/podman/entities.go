@@ -9,6 +9,8 @@ import (
 
 func init() {
 	gob.Register(ContainerCreateOptions{})
+	gob.Register(PodCreateOptions{})
+	gob.Register(ImageCreateOptions{})
 }
 
 func appendS(args []string, opt string, v string) []string {
@@ -264,7 +266,7 @@ func (c *ContainerCreateOptions) CmdLine(name string) []string {
 	args = appendSA(args, "--dns-search", c.DNSSearch)
 	args = appendSA(args, "--dns", c.DNSServers)
 	if c.Entrypoint != nil {
-		args = appendS(args, "--entry-point", *c.Entrypoint)
+		args = appendS(args, "--entrypoint", *c.Entrypoint)
 	}
 	args = appendSA(args, "--env", c.Env)
 	args = appendB(args, "--env-host", c.EnvHost)
@@ -449,7 +451,10 @@ func (o *ContainerRestoreOptions) CmdLine(name string) []string {
 	args = appendS(args, "--publish", o.Publish)
 	args = appendB(args, "--tcp-established", o.TcpEstablished)
 
-	args = append(args, name)
+	// a container imported from an archive is not named on the command line
+	if name != "" {
+		args = append(args, name)
+	}
 
 	return args
 }
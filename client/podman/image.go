@@ -0,0 +1,134 @@
+package podman
+
+import "encoding/gob"
+
+// Image is the interface for managing a container image on a circuit server.
+type Image interface {
+	// Build the image from a context directory on the host of the element
+	Build(opts *ImageBuildOptions) error
+
+	// Exists reports an error if the image is not present on the host
+	Exists() error
+
+	// Inspect the image
+	Inspect() (*InspectImageData, error)
+
+	// Load the image from an archive file on the host
+	Load(file string) error
+
+	PeekBytes() []byte
+
+	// Progress subscribes to the progress (*ImageProgress) of the pull, build
+	// and push operations of the element.
+	Progress() Subscription
+
+	// Pull the image from its registry
+	Pull(opts *ImagePullOptions) error
+
+	// Push copies the image to the circuit servers with the given IDs over the
+	// circuit transport. The image is held by an image element under the same
+	// anchor path on every target server.
+	Push(servers ...string) error
+
+	// Save the image to an archive file on the host
+	Save(file string) error
+
+	// Scrub removes the element, and the image if the element was created with Scrub
+	Scrub()
+
+	// Tag adds names to the image
+	Tag(tags ...string) error
+}
+
+// Engines managing the images of an image element.
+const (
+	EnginePodman = "podman"
+	EngineDocker = "docker"
+)
+
+type ImageCreateOptions struct {
+	// Name is the reference of the image, e.g. docker.io/library/alpine:latest
+	Name string `json:"name,omitempty"`
+	// Engine is EnginePodman (the default) or EngineDocker
+	Engine string `json:"engine,omitempty"`
+
+	// Scrub removes the image from the host when the element is scrubbed
+	Scrub bool `json:"scrub,omitempty"`
+}
+
+// ImagePullOptions configure a pull. Policy and TLSVerify are podman options,
+// which image elements of the docker engine refuse.
+type ImagePullOptions struct {
+	Platform  string `json:"platform,omitempty"`
+	Policy    string `json:"policy,omitempty"`
+	TLSVerify *bool  `json:"tls_verify,omitempty"`
+}
+
+func (o *ImagePullOptions) CmdLine(name string) []string {
+	var args = []string{"pull"}
+
+	args = appendS(args, "--platform", o.Platform)
+	args = appendS(args, "--policy", o.Policy)
+	if o.TLSVerify != nil && !*o.TLSVerify {
+		args = append(args, "--tls-verify=false")
+	}
+
+	args = append(args, name)
+
+	return args
+}
+
+type ImageBuildOptions struct {
+	// Context is the build context directory on the host of the element
+	Context   string   `json:"context,omitempty"`
+	File      string   `json:"file,omitempty"`
+	BuildArgs []string `json:"build_args,omitempty"`
+	Labels    []string `json:"labels,omitempty"`
+	Target    string   `json:"target,omitempty"`
+	Platform  string   `json:"platform,omitempty"`
+	NoCache   bool     `json:"no_cache,omitempty"`
+	Pull      bool     `json:"pull,omitempty"`
+}
+
+func (o *ImageBuildOptions) CmdLine(tag string) []string {
+	var args = []string{"build", "--tag", tag}
+
+	args = appendS(args, "--file", o.File)
+	args = appendSA(args, "--build-arg", o.BuildArgs)
+	args = appendSA(args, "--label", o.Labels)
+	args = appendS(args, "--target", o.Target)
+	args = appendS(args, "--platform", o.Platform)
+	args = appendB(args, "--no-cache", o.NoCache)
+	args = appendB(args, "--pull", o.Pull)
+
+	args = append(args, o.Context)
+
+	return args
+}
+
+// Image operations reported by ImageProgress.
+const (
+	ImagePull  = "pull"
+	ImageBuild = "build"
+	ImagePush  = "push"
+)
+
+// ImageProgress reports the progress of an operation on an image.
+type ImageProgress struct {
+	// Op is one of ImagePull, ImageBuild or ImagePush.
+	Op string
+	// Server is the target server of a push.
+	Server string
+	// Message is a line of output of a pull or build.
+	Message string
+	// Bytes of the image archive sent to Server out of Total.
+	Bytes int64
+	Total int64
+	// Done is set on the last report of an operation, with Err if it failed.
+	Done bool
+	Err  string
+}
+
+func init() {
+	gob.Register(&ImageProgress{})
+}
@@ -0,0 +1,37 @@
+package podman
+
+import "time"
+
+// InspectImageData is the output of Inspect() on an image. It holds the fields
+// common to 'podman image inspect' and 'docker image inspect'.
+type InspectImageData struct {
+	// ID is the ID of the image.
+	ID string `json:"Id"`
+	// Digest is the digest of the image manifest (podman only).
+	Digest string `json:"Digest,omitempty"`
+	// RepoTags are the names of the image.
+	RepoTags []string `json:"RepoTags"`
+	// RepoDigests are the names of the image by digest.
+	RepoDigests []string `json:"RepoDigests"`
+	// Created is the time the image was built.
+	Created time.Time `json:"Created"`
+	// Size of the image in bytes.
+	Size int64 `json:"Size"`
+	// Architecture and Os the image was built for.
+	Architecture string `json:"Architecture"`
+	Os           string `json:"Os"`
+	// Config holds the default run configuration of the image.
+	Config *ImageConfig `json:"Config,omitempty"`
+}
+
+// ImageConfig is the default run configuration of an image.
+type ImageConfig struct {
+	User         string              `json:"User,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	StopSignal   string              `json:"StopSignal,omitempty"`
+}
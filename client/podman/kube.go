@@ -0,0 +1,347 @@
+package podman
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KubePod is a pod of a Kubernetes manifest, translated to the options of
+// the pod element and of the container elements it holds.
+type KubePod struct {
+	Pod        PodCreateOptions
+	Containers []KubeContainer
+}
+
+// KubeContainer is a container of a KubePod. Anchor is the name of the
+// container in the manifest, used as the name of its child anchor.
+type KubeContainer struct {
+	Anchor  string
+	Options ContainerCreateOptions
+}
+
+// ParseKube translates the Pod and Deployment documents of a Kubernetes YAML
+// manifest the way podman kube play does: a Deployment yields a single pod
+// named after it with a -pod suffix, and containers are named <pod>-<container>.
+// Documents of other kinds are ignored.
+func ParseKube(manifest []byte) ([]*KubePod, error) {
+	var pods []*KubePod
+	dec := yaml.NewDecoder(bytes.NewReader(manifest))
+	for {
+		var doc kubeObject
+		if err := dec.Decode(&doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("parsing kube manifest: %v", err)
+		}
+		var (
+			meta kubeMeta
+			spec kubePodSpec
+		)
+		switch doc.Kind {
+		case "Pod":
+			if err := doc.Spec.Decode(&spec); err != nil {
+				return nil, fmt.Errorf("pod %s: %v", doc.Metadata.Name, err)
+			}
+			meta = doc.Metadata
+		case "Deployment":
+			var d kubeDeploymentSpec
+			if err := doc.Spec.Decode(&d); err != nil {
+				return nil, fmt.Errorf("deployment %s: %v", doc.Metadata.Name, err)
+			}
+			meta, spec = d.Template.Metadata, d.Template.Spec
+			meta.Name = doc.Metadata.Name + "-pod"
+		default:
+			continue
+		}
+		p, err := kubePod(meta, &spec)
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, p)
+	}
+	if len(pods) == 0 {
+		return nil, errors.New("kube manifest defines no pods or deployments")
+	}
+	return pods, nil
+}
+
+func kubePod(meta kubeMeta, spec *kubePodSpec) (*KubePod, error) {
+	if meta.Name == "" {
+		return nil, errors.New("kube pod without a name")
+	}
+	if len(spec.Containers) == 0 {
+		return nil, fmt.Errorf("pod %s: no containers", meta.Name)
+	}
+	p := &KubePod{
+		Pod: PodCreateOptions{
+			Name:     meta.Name,
+			Hostname: spec.Hostname,
+			Labels:   keyValueList(meta.Labels),
+		},
+	}
+	switch spec.RestartPolicy {
+	case "", "Always":
+		p.Pod.Restart = "always"
+	case "OnFailure":
+		p.Pod.Restart = "on-failure"
+	case "Never":
+		p.Pod.Restart = "no"
+	default:
+		return nil, fmt.Errorf("pod %s: unknown restart policy %s", meta.Name, spec.RestartPolicy)
+	}
+	if spec.HostNetwork {
+		p.Pod.Networks = []string{"host"}
+	}
+	for _, a := range spec.HostAliases {
+		for _, h := range a.Hostnames {
+			p.Pod.AddHosts = append(p.Pod.AddHosts, h+":"+a.IP)
+		}
+	}
+
+	volumes := make(map[string]kubeVolume)
+	for _, v := range spec.Volumes {
+		volumes[v.Name] = v
+	}
+	for _, kc := range spec.Containers {
+		if kc.Name == "" || kc.Image == "" {
+			return nil, fmt.Errorf("pod %s: container without a name or image", meta.Name)
+		}
+		opts, err := containerOptions(meta.Name, &kc, volumes)
+		if err != nil {
+			return nil, fmt.Errorf("pod %s: container %s: %v", meta.Name, kc.Name, err)
+		}
+		// Ports are published by the pod, which owns the network namespace.
+		for _, port := range kc.Ports {
+			if port.HostPort == 0 {
+				continue
+			}
+			spec := fmt.Sprintf("%d:%d", port.HostPort, port.ContainerPort)
+			if port.HostIP != "" {
+				spec = port.HostIP + ":" + spec
+			}
+			if port.Protocol != "" {
+				spec += "/" + strings.ToLower(port.Protocol)
+			}
+			p.Pod.PublishPorts = append(p.Pod.PublishPorts, spec)
+		}
+		p.Containers = append(p.Containers, KubeContainer{Anchor: kc.Name, Options: *opts})
+	}
+	return p, nil
+}
+
+func containerOptions(pod string, kc *kubeContainer, volumes map[string]kubeVolume) (*ContainerCreateOptions, error) {
+	opts := &ContainerCreateOptions{
+		Name:    pod + "-" + kc.Name,
+		Pod:     pod,
+		Image:   kc.Image,
+		Workdir: kc.WorkingDir,
+		TTY:     kc.TTY,
+	}
+
+	// The command of a Kubernetes container replaces the entrypoint of the image,
+	// its arguments replace the command of the image.
+	cmd := kc.Args
+	if len(kc.Command) > 0 {
+		ep := kc.Command[0]
+		opts.Entrypoint = &ep
+		cmd = append(append([]string{}, kc.Command[1:]...), kc.Args...)
+	}
+	if len(cmd) > 0 {
+		opts.Command, opts.Args = cmd[0], cmd[1:]
+	}
+
+	switch kc.ImagePullPolicy {
+	case "":
+	case "Always":
+		opts.Pull = "always"
+	case "IfNotPresent":
+		opts.Pull = "missing"
+	case "Never":
+		opts.Pull = "never"
+	default:
+		return nil, fmt.Errorf("unknown image pull policy %s", kc.ImagePullPolicy)
+	}
+
+	for _, e := range kc.Env {
+		opts.Env = append(opts.Env, e.Name+"="+e.Value)
+	}
+
+	for _, m := range kc.VolumeMounts {
+		v, ok := volumes[m.Name]
+		if !ok {
+			return nil, fmt.Errorf("volume %s not defined", m.Name)
+		}
+		var spec string
+		switch {
+		case v.HostPath != nil:
+			spec = v.HostPath.Path + ":" + m.MountPath
+		case v.PersistentVolumeClaim != nil:
+			spec = v.PersistentVolumeClaim.ClaimName + ":" + m.MountPath
+		case v.EmptyDir != nil:
+			spec = m.MountPath
+		default:
+			return nil, fmt.Errorf("volume %s: only hostPath, persistentVolumeClaim and emptyDir volumes are supported", m.Name)
+		}
+		if m.ReadOnly {
+			spec += ":ro"
+		}
+		opts.Volume = append(opts.Volume, spec)
+	}
+
+	if mem, ok := kc.Resources.Limits["memory"]; ok {
+		b, err := kubeQuantity(mem)
+		if err != nil {
+			return nil, err
+		}
+		opts.Memory = strconv.FormatInt(int64(b), 10)
+	}
+	if cpu, ok := kc.Resources.Limits["cpu"]; ok {
+		n, err := kubeQuantity(cpu)
+		if err != nil {
+			return nil, err
+		}
+		opts.CPUS = n
+	}
+
+	if sc := kc.SecurityContext; sc != nil {
+		if sc.Privileged != nil {
+			opts.Privileged = *sc.Privileged
+		}
+		if sc.ReadOnlyRootFilesystem != nil {
+			opts.ReadOnly = *sc.ReadOnlyRootFilesystem
+		}
+		if sc.RunAsUser != nil {
+			opts.User = strconv.FormatInt(*sc.RunAsUser, 10)
+			if sc.RunAsGroup != nil {
+				opts.User += ":" + strconv.FormatInt(*sc.RunAsGroup, 10)
+			}
+		}
+		if sc.Capabilities != nil {
+			opts.CapAdd = sc.Capabilities.Add
+			opts.CapDrop = sc.Capabilities.Drop
+		}
+	}
+	return opts, nil
+}
+
+// kubeQuantity parses a Kubernetes resource quantity such as 512Mi, 1G or 250m.
+func kubeQuantity(q string) (float64, error) {
+	suffixes := []struct {
+		s string
+		m float64
+	}{
+		{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+		{"k", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12}, {"m", 1e-3},
+	}
+	mult := 1.0
+	for _, x := range suffixes {
+		if strings.HasSuffix(q, x.s) {
+			q, mult = strings.TrimSuffix(q, x.s), x.m
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(q, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid resource quantity %q", q)
+	}
+	return n * mult, nil
+}
+
+func keyValueList(m map[string]string) []string {
+	var r []string
+	for k, v := range m {
+		r = append(r, k+"="+v)
+	}
+	sort.Strings(r)
+	return r
+}
+
+// Subset of the Kubernetes object model understood by ParseKube.
+
+type kubeObject struct {
+	Kind     string    `yaml:"kind"`
+	Metadata kubeMeta  `yaml:"metadata"`
+	Spec     yaml.Node `yaml:"spec"`
+}
+
+type kubeMeta struct {
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+// kubeDeploymentSpec ignores replicas, as podman kube play does.
+type kubeDeploymentSpec struct {
+	Template struct {
+		Metadata kubeMeta    `yaml:"metadata"`
+		Spec     kubePodSpec `yaml:"spec"`
+	} `yaml:"template"`
+}
+
+type kubePodSpec struct {
+	Containers    []kubeContainer `yaml:"containers"`
+	Volumes       []kubeVolume    `yaml:"volumes"`
+	RestartPolicy string          `yaml:"restartPolicy"`
+	Hostname      string          `yaml:"hostname"`
+	HostNetwork   bool            `yaml:"hostNetwork"`
+	HostAliases   []struct {
+		IP        string   `yaml:"ip"`
+		Hostnames []string `yaml:"hostnames"`
+	} `yaml:"hostAliases"`
+}
+
+type kubeContainer struct {
+	Name            string   `yaml:"name"`
+	Image           string   `yaml:"image"`
+	ImagePullPolicy string   `yaml:"imagePullPolicy"`
+	Command         []string `yaml:"command"`
+	Args            []string `yaml:"args"`
+	WorkingDir      string   `yaml:"workingDir"`
+	TTY             bool     `yaml:"tty"`
+	Env             []struct {
+		Name  string `yaml:"name"`
+		Value string `yaml:"value"`
+	} `yaml:"env"`
+	Ports []struct {
+		ContainerPort int    `yaml:"containerPort"`
+		HostPort      int    `yaml:"hostPort"`
+		HostIP        string `yaml:"hostIP"`
+		Protocol      string `yaml:"protocol"`
+	} `yaml:"ports"`
+	VolumeMounts []struct {
+		Name      string `yaml:"name"`
+		MountPath string `yaml:"mountPath"`
+		ReadOnly  bool   `yaml:"readOnly"`
+	} `yaml:"volumeMounts"`
+	Resources struct {
+		Limits map[string]string `yaml:"limits"`
+	} `yaml:"resources"`
+	SecurityContext *struct {
+		Privileged             *bool  `yaml:"privileged"`
+		ReadOnlyRootFilesystem *bool  `yaml:"readOnlyRootFilesystem"`
+		RunAsUser              *int64 `yaml:"runAsUser"`
+		RunAsGroup             *int64 `yaml:"runAsGroup"`
+		Capabilities           *struct {
+			Add  []string `yaml:"add"`
+			Drop []string `yaml:"drop"`
+		} `yaml:"capabilities"`
+	} `yaml:"securityContext"`
+}
+
+type kubeVolume struct {
+	Name     string `yaml:"name"`
+	HostPath *struct {
+		Path string `yaml:"path"`
+	} `yaml:"hostPath"`
+	PersistentVolumeClaim *struct {
+		ClaimName string `yaml:"claimName"`
+	} `yaml:"persistentVolumeClaim"`
+	EmptyDir *struct{} `yaml:"emptyDir"`
+}
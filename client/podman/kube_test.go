@@ -0,0 +1,107 @@
+package podman
+
+import "testing"
+
+const testManifest = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      restartPolicy: OnFailure
+      volumes:
+      - name: data
+        hostPath:
+          path: /srv/data
+      - name: cache
+        emptyDir: {}
+      containers:
+      - name: nginx
+        image: docker.io/library/nginx
+        command: ["nginx"]
+        args: ["-g", "daemon off;"]
+        env:
+        - name: A
+          value: "1"
+        ports:
+        - containerPort: 80
+          hostPort: 8080
+          protocol: TCP
+        - containerPort: 443
+        volumeMounts:
+        - name: data
+          mountPath: /data
+          readOnly: true
+        - name: cache
+          mountPath: /cache
+        resources:
+          limits:
+            memory: 512Mi
+            cpu: 500m
+      - name: sidecar
+        image: alpine
+        args: ["sleep", "infinity"]
+`
+
+func TestParseKube(t *testing.T) {
+	pods, err := ParseKube([]byte(testManifest))
+	if err != nil {
+		t.Fatalf("parse (%v)", err)
+	}
+	if len(pods) != 1 {
+		t.Fatalf("expecting one pod, got %d", len(pods))
+	}
+	p := pods[0]
+	if p.Pod.Name != "web-pod" || p.Pod.Restart != "on-failure" || len(p.Pod.Labels) != 1 || p.Pod.Labels[0] != "app=web" {
+		t.Fatalf("pod %+v", p.Pod)
+	}
+	if len(p.Pod.PublishPorts) != 1 || p.Pod.PublishPorts[0] != "8080:80/tcp" {
+		t.Fatalf("ports %v", p.Pod.PublishPorts)
+	}
+	if len(p.Containers) != 2 {
+		t.Fatalf("containers %+v", p.Containers)
+	}
+
+	nginx := p.Containers[0]
+	if nginx.Anchor != "nginx" || nginx.Options.Name != "web-pod-nginx" || nginx.Options.Pod != "web-pod" {
+		t.Fatalf("nginx %+v", nginx)
+	}
+	o := nginx.Options
+	if o.Entrypoint == nil || *o.Entrypoint != "nginx" || o.Command != "-g" || len(o.Args) != 1 || o.Args[0] != "daemon off;" {
+		t.Fatalf("command %v %q %q", o.Entrypoint, o.Command, o.Args)
+	}
+	if len(o.Volume) != 2 || o.Volume[0] != "/srv/data:/data:ro" || o.Volume[1] != "/cache" {
+		t.Fatalf("volumes %v", o.Volume)
+	}
+	if o.Memory != "536870912" || o.CPUS != 0.5 || len(o.Env) != 1 || o.Env[0] != "A=1" {
+		t.Fatalf("options %+v", o)
+	}
+
+	sidecar := p.Containers[1].Options
+	if sidecar.Entrypoint != nil || sidecar.Command != "sleep" || sidecar.Args[0] != "infinity" {
+		t.Fatalf("sidecar %+v", sidecar)
+	}
+}
+
+func TestParseKubeErrors(t *testing.T) {
+	for _, m := range []string{
+		"kind: Service\nmetadata:\n  name: x\n",
+		"kind: Pod\nmetadata:\n  name: x\nspec:\n  containers: []\n",
+		"kind: Pod\nmetadata:\n  name: x\nspec:\n  containers:\n  - name: a\n    image: b\n    volumeMounts:\n    - name: v\n      mountPath: /v\n",
+	} {
+		if _, err := ParseKube([]byte(m)); err == nil {
+			t.Fatalf("expecting error for %q", m)
+		}
+	}
+}
@@ -8,6 +8,9 @@ type Pod interface {
 	// Exists checks if pod exists in storage.
 	Exists() error
 
+	// Generate returns the Kubernetes YAML of the pod and its containers
+	Generate() ([]byte, error)
+
 	// Inspect the configuration of the pod
 	Inspect() (*InspectPodData, error)
 
@@ -8,6 +8,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 )
@@ -52,6 +53,16 @@ type Proc interface {
 	Stderr() io.ReadCloser
 }
 
+// ProcContext is implemented by the process elements of the client, which are
+// Procs. It is a separate interface so that Procs implemented elsewhere remain Procs.
+type ProcContext interface {
+	Proc
+
+	// WaitContext is like Wait, except that it gives up when ctx is done,
+	// returning the error of ctx. The wait on the server is given up as well.
+	WaitContext(ctx context.Context) (ProcStat, error)
+}
+
 // Cmd describes the execution parameters for an OS process.
 type Cmd struct {
 
@@ -0,0 +1,253 @@
+package client
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	srv "github.com/gocircuit/circuit/client/server"
+	"github.com/pkg/errors"
+)
+
+// ErrNoServer is returned by Schedule when no server meets the constraints,
+// or placement failed on every server that does.
+var ErrNoServer = errors.New("no suitable server")
+
+// Constraint is a requirement on the host of a server. A constraint with an
+// empty Op requires the host to have the capability or label Key.
+//
+// Keys cpu, mem, memfree, disk and load compare against the host's number of
+// CPUs, total and free memory, free disk and 1-minute load average. Keys os,
+// arch, hostname and version compare against the host's description. Any
+// other key names a label.
+type Constraint struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+// operators in the order they are tried, longest first
+var constraintOps = []string{">=", "<=", "!=", ">", "<", "="}
+
+// ParseConstraints parses a comma-separated list of constraints, such as
+// "cpu>=4,mem>=8G,podman,zone=east". Memory and disk sizes take the suffixes
+// K, M, G and T, in powers of 1024.
+func ParseConstraints(s string) ([]Constraint, error) {
+	var r []Constraint
+	for _, t := range strings.Split(s, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		c := Constraint{Key: t}
+		for _, op := range constraintOps {
+			if i := strings.Index(t, op); i >= 0 {
+				c = Constraint{
+					Key:   strings.TrimSpace(t[:i]),
+					Op:    op,
+					Value: strings.TrimSpace(t[i+len(op):]),
+				}
+				break
+			}
+		}
+		if c.Key == "" {
+			return nil, errors.Errorf("constraint %q has no key", t)
+		}
+		if _, ok := hostNumber(srv.HostStat{}, c.Key); ok && c.Op != "" {
+			if _, err := parseQuantity(c.Value); err != nil {
+				return nil, errors.Errorf("constraint %q: %v", t, err)
+			}
+		}
+		r = append(r, c)
+	}
+	return r, nil
+}
+
+func (c Constraint) String() string {
+	return c.Key + c.Op + c.Value
+}
+
+// Match reports whether host h meets the constraint
+func (c Constraint) Match(h srv.HostStat) bool {
+	if c.Op == "" {
+		if h.Capable(c.Key) {
+			return true
+		}
+		_, ok := h.Labels[c.Key]
+		return ok
+	}
+	if x, ok := hostNumber(h, c.Key); ok {
+		y, err := parseQuantity(c.Value)
+		return err == nil && compare(c.Op, x, y)
+	}
+	var v string
+	switch c.Key {
+	case "os":
+		v = h.OS
+	case "arch":
+		v = h.Arch
+	case "hostname":
+		v = h.Hostname
+	case "version":
+		v = h.Version
+	default:
+		var ok bool
+		if v, ok = h.Labels[c.Key]; !ok {
+			return false
+		}
+	}
+	switch c.Op {
+	case "=":
+		return v == c.Value
+	case "!=":
+		return v != c.Value
+	}
+	// Ordering of labels holding numbers, such as gpu>=2
+	x, err1 := strconv.ParseFloat(v, 64)
+	y, err2 := strconv.ParseFloat(c.Value, 64)
+	return err1 == nil && err2 == nil && compare(c.Op, x, y)
+}
+
+// hostNumber returns the quantity of h named by key, if key names one
+func hostNumber(h srv.HostStat, key string) (float64, bool) {
+	switch key {
+	case "cpu":
+		return float64(h.NumCPU), true
+	case "mem":
+		return float64(h.MemTotal), true
+	case "memfree":
+		return float64(h.MemFree), true
+	case "disk":
+		return float64(h.DiskFree), true
+	case "load":
+		return h.Load[0], true
+	}
+	return 0, false
+}
+
+func parseQuantity(s string) (float64, error) {
+	var m float64 = 1
+	if k := len(s); k > 0 {
+		if i := strings.IndexByte("KMGT", s[k-1]&^0x20); i >= 0 {
+			s, m = s[:k-1], float64(uint64(1)<<(10*(i+1)))
+		}
+	}
+	x, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a quantity", s)
+	}
+	return x * m, nil
+}
+
+func compare(op string, x, y float64) bool {
+	switch op {
+	case ">=":
+		return x >= y
+	case "<=":
+		return x <= y
+	case ">":
+		return x > y
+	case "<":
+		return x < y
+	case "=":
+		return x == y
+	case "!=":
+		return x != y
+	}
+	return false
+}
+
+// Candidates returns the IDs of the servers in hosts which meet all
+// constraints, least loaded first. Load is the 1-minute load average per
+// CPU; servers with equal load are ordered by free memory, most first.
+func Candidates(hosts map[string]srv.HostStat, constraints []Constraint) []string {
+	var r []string
+	for id, h := range hosts {
+		ok := true
+		for _, c := range constraints {
+			if !c.Match(h) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			r = append(r, id)
+		}
+	}
+	sort.Slice(r, func(i, j int) bool {
+		a, b := hosts[r[i]], hosts[r[j]]
+		if la, lb := relativeLoad(a), relativeLoad(b); la != lb {
+			return la < lb
+		}
+		if a.MemFree != b.MemFree {
+			return a.MemFree > b.MemFree
+		}
+		return r[i] < r[j]
+	})
+	return r
+}
+
+func relativeLoad(h srv.HostStat) float64 {
+	if h.NumCPU == 0 {
+		return h.Load[0]
+	}
+	return h.Load[0] / float64(h.NumCPU)
+}
+
+// Hosts returns the host descriptions of all live circuit servers, keyed by
+// server ID, as last announced by the servers.
+// Errors in communication are reported as panics.
+func (c *Client) Hosts() map[string]srv.HostStat {
+	r := make(map[string]srv.HostStat)
+	for k, p := range c.y.GetPeers() {
+		r[k] = p.Host
+	}
+	return r
+}
+
+// Schedule creates an element of type typ with argument arg on a server
+// chosen by the comma-separated constraints (see ParseConstraints), at
+// the anchor path suffix under that server. Servers are tried least loaded
+// first, and placement moves on to the next server when it fails.
+// Schedule returns the anchor of the new element and the element.
+func (c *Client) Schedule(constraints, suffix string, typ reflect.Type, arg any) (Anchor, any, error) {
+	cs, err := ParseConstraints(constraints)
+	if err != nil {
+		return nil, nil, err
+	}
+	walk := Split(suffix)
+	if len(walk) == 0 {
+		return nil, nil, errors.New("placement needs a non-empty anchor suffix")
+	}
+	ids := Candidates(c.Hosts(), cs)
+	if len(ids) == 0 {
+		return nil, nil, errors.Wrapf(ErrNoServer, "constraints %q", constraints)
+	}
+	var failed []string
+	for _, id := range ids {
+		a, v, err := place(c, append([]string{id}, walk...), typ, arg)
+		if err == nil {
+			return a, v, nil
+		}
+		failed = append(failed, fmt.Sprintf("%s: %v", id, err))
+	}
+	return nil, nil, errors.Wrapf(ErrNoServer, "placement failed (%s)", strings.Join(failed, "; "))
+}
+
+// place makes an element at walk, reporting a dead server as an error
+func place(c *Client, walk []string, typ reflect.Type, arg any) (a Anchor, v any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			a, v, err = nil, nil, fmt.Errorf("server unreachable: %v", r)
+		}
+	}()
+	if a = c.Walk(walk); a == nil {
+		return nil, nil, errors.New("server is gone")
+	}
+	if v, err = a.Make(typ, arg); err != nil {
+		return nil, nil, err
+	}
+	return a, v, nil
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	srv "github.com/gocircuit/circuit/client/server"
+)
+
+func TestParseConstraints(t *testing.T) {
+	cs, err := ParseConstraints("cpu>=4, mem>=8G,podman,zone=east,gpu!=0")
+	if err != nil {
+		t.Fatalf("parse (%v)", err)
+	}
+	want := []Constraint{
+		{"cpu", ">=", "4"},
+		{"mem", ">=", "8G"},
+		{"podman", "", ""},
+		{"zone", "=", "east"},
+		{"gpu", "!=", "0"},
+	}
+	if !reflect.DeepEqual(cs, want) {
+		t.Fatalf("parsed %v, expected %v", cs, want)
+	}
+	for _, s := range []string{"cpu>=many", ">=4", "mem<1X"} {
+		if _, err := ParseConstraints(s); err == nil {
+			t.Fatalf("%q parsed", s)
+		}
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	hosts := map[string]srv.HostStat{
+		"X1": {NumCPU: 2, MemTotal: 4 << 30, Load: [3]float64{0.2}, Capabilities: []string{"podman"}},
+		"X2": {NumCPU: 8, MemTotal: 16 << 30, MemFree: 1 << 30, Load: [3]float64{4}, Labels: map[string]string{"zone": "east"}},
+		"X3": {NumCPU: 8, MemTotal: 16 << 30, MemFree: 8 << 30, Load: [3]float64{4}, Capabilities: []string{"podman"}},
+		"X4": {NumCPU: 16, MemTotal: 64 << 30, Load: [3]float64{16}, Labels: map[string]string{"zone": "west", "gpu": "2"}},
+	}
+	for where, want := range map[string][]string{
+		"":                 {"X1", "X3", "X2", "X4"},
+		"cpu>=4":           {"X3", "X2", "X4"},
+		"cpu>=4,podman":    {"X3"},
+		"mem>8G,zone":      {"X2", "X4"},
+		"zone=west,gpu>=2": {"X4"},
+		"gpu>=4":           nil,
+		"os=plan9":         nil,
+	} {
+		cs, err := ParseConstraints(where)
+		if err != nil {
+			t.Fatalf("parse %q (%v)", where, err)
+		}
+		if got := Candidates(hosts, cs); !reflect.DeepEqual(got, want) {
+			t.Fatalf("candidates for %q are %v, expected %v", where, got, want)
+		}
+	}
+}
@@ -8,8 +8,11 @@
 package server
 
 import (
+	"encoding/json"
 	"io"
 	"time"
+
+	"github.com/gocircuit/circuit/sys/acid"
 )
 
 // Server…
@@ -20,15 +23,109 @@ type Server interface {
 	PeekBytes() []byte
 	Rejoin(string) error
 	Suicide()
+	Top() ServerTop
+
+	// ReloadKeys rereads the keyring file the server was started with,
+	// so that the circuit's shared keys can be rotated one server at a time.
+	// Servers using TLS reread their list of revoked certificates instead.
+	ReloadKeys() error
+
+	// Audit returns the records of the server's audit log made at or after since
+	Audit(since time.Time) ([]AuditRecord, error)
+
+	// RuntimeStat lists the handles to values of the server that other
+	// workers hold, to find handles that are never released
+	RuntimeStat() (*acid.RuntimeStat, error)
 }
 
 // ServerStat encloses subscription state information.
 type ServerStat struct {
 	Addr   string    `json:"addr,omitempty"`
 	Joined time.Time `json:"joined,omitempty"`
-
-	// TODO - put some runtime metrics for the server here and also add a  map for adding metadata
-	// for the server.  This can be used for scheduling elements and runtime behavior reporting.
+	Host   HostStat  `json:"host"`
 
 	// TODO access policies, how to implement
 }
+
+// HostStat describes the host of a server and what the server can run there.
+// Quantities the host does not report are zero.
+type HostStat struct {
+	Hostname string `json:"hostname,omitempty"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	NumCPU   int    `json:"num_cpu"`
+
+	// MemTotal and MemFree are the memory of the host, in bytes. MemFree
+	// counts memory available to new processes.
+	MemTotal uint64 `json:"mem_total,omitempty"`
+	MemFree  uint64 `json:"mem_free,omitempty"`
+
+	// DiskFree is the space available in the var directory of the server, in bytes
+	DiskFree uint64 `json:"disk_free,omitempty"`
+
+	// Load is the 1, 5 and 15 minute load average of the host
+	Load [3]float64 `json:"load"`
+
+	// Version is the version of the circuit executable
+	Version string `json:"version,omitempty"`
+
+	// Capabilities lists what the server can run beyond processes, such as
+	// docker, podman and wasm
+	Capabilities []string `json:"capabilities,omitempty"`
+
+	// Labels are the key=value pairs the server was started with, for use
+	// in placement constraints
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Capable reports whether the host has capability c
+func (h HostStat) Capable(c string) bool {
+	for _, x := range h.Capabilities {
+		if x == c {
+			return true
+		}
+	}
+	return false
+}
+
+// ServerTop is a sample of the resource usage of a server.
+type ServerTop struct {
+	Addr string    `json:"addr,omitempty"`
+	Time time.Time `json:"time"`
+
+	// CPUTime is the user and system CPU time used by the server process.
+	// Usage is the change of CPUTime between two samples.
+	CPUTime time.Duration `json:"cpu_time"`
+	NumCPU  int           `json:"num_cpu"`
+
+	// Mem is the memory obtained from the OS by the server, in bytes
+	Mem uint64 `json:"mem"`
+
+	// Load is the 1, 5 and 15 minute load average of the host, where known
+	Load [3]float64 `json:"load"`
+
+	Goroutines int `json:"goroutines"`
+
+	// Elements counts the elements of the server by kind
+	Elements map[string]int `json:"elements,omitempty"`
+}
+
+// AuditRecord records an operation applied to an anchor of a server.
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+
+	// Caller is the circuit address of the calling worker, and Identity its
+	// identity under the access policy of the server. Both are empty for
+	// operations from within the server.
+	Caller   string `json:"caller,omitempty"`
+	Identity string `json:"identity,omitempty"`
+
+	// Verb is make, scrub, signal or stdin
+	Verb   string          `json:"verb"`
+	Anchor string          `json:"anchor"`
+	Kind   string          `json:"kind,omitempty"`
+	Arg    json.RawMessage `json:"arg,omitempty"`
+
+	// Error describes why the operation failed; it is empty on success
+	Error string `json:"error,omitempty"`
+}
@@ -3,6 +3,7 @@ package wasm
 import (
 	"encoding/gob"
 	"encoding/json"
+	"time"
 )
 
 // init register any global types.
@@ -10,6 +11,13 @@ func init() {
 	gob.Register(Execute{})
 }
 
+// Runtimes able to execute modules. WasmEdge is only available on servers
+// built with the wasmedge build tag.
+const (
+	RuntimeWazero   = "wazero"
+	RuntimeWasmEdge = "wasmedge"
+)
+
 type LogLevel string
 
 const (
@@ -20,13 +28,28 @@ const (
 
 // Execute parameterizes the execution of a wasm application module or function
 // Provides configuration for runtime objects (VM, WASI, etc)
+//
+// The module is either read from Path on the hosting server, or uploaded with
+// the request in Module, which takes precedence when set. Runtime selects the
+// engine, the server's default when empty.
+//
+// MaxMemoryPageSize caps the linear memory of the module in 64KiB pages, Fuel
+// bounds the work it may do and Timeout its wall-clock running time. Zero
+// values leave a resource unbounded. WasmEdge meters fuel in instruction cost,
+// wazero in function calls. As wazero's fuel does not bound loops that make no
+// calls, wazero refuses Fuel without a Timeout.
 type Execute struct {
+	Path              string
+	Module            []byte
+	Runtime           string
 	LogLevel          LogLevel
 	Function          Functional
 	WasiConf          WasiConf
 	AddProposals      Proposals
 	RemoveProposals   Proposals
 	MaxMemoryPageSize uint
+	Fuel              uint
+	Timeout           time.Duration
 	ForceInterpreter  bool
 	CompileOpts       CompileOpts
 	StatsOpts         StatisticsOpts
@@ -39,12 +62,23 @@ type Limit struct {
 	Max uint
 }
 
+// Functional selects function mode, in which the module stays loaded at its
+// anchor and serves invocations of its exported functions instead of running
+// _start. Up to Instances instances, the number of CPUs by default, serve
+// invocations concurrently. Name, with Args, is called on each new instance
+// before it serves invocations; when empty, _initialize is called if the
+// module exports it, as for WASI reactors.
 type Functional struct {
 	IsFunction bool
 	Name       string
 	Args       []string
+	Instances  int
 }
 
+// WasiConf configures the WASI host module. Args are passed after the module
+// name as argv[0], Env holds KEY=VALUE pairs and PreOpens holds guest:host
+// directory mappings. The standard streams of a module are only wired to the
+// element when WASI is enabled.
 type WasiConf struct {
 	Enable   bool
 	Args     []string
@@ -21,8 +21,17 @@ type Status struct {
 	VolumesRW      map[string]bool
 	//HostConfig      HostConfig
 
-	//
+	Running  bool
+	ExitCode int
+	Err      string
+	Limit    LimitKind
+	Started  time.Time
+	Finished time.Time
+	Stats    Statistics
+
+	Invocations uint
 
+	Runtime string
 	Version Version
 }
 
@@ -33,10 +42,22 @@ type Version struct {
 	Patch uint
 }
 
+// LimitKind names the resource limit of an Execute which terminated a module
+type LimitKind string
+
+const (
+	LimitMemory  LimitKind = "memory"
+	LimitFuel    LimitKind = "fuel"
+	LimitTimeout LimitKind = "timeout"
+)
+
+// Result is the outcome of running a module: its exit code, the error of the
+// runtime if any, and the limit which terminated it if one tripped.
 type Result struct {
 	Code     uint
 	Category uint
 	Err      string
+	Limit    LimitKind
 }
 
 type Statistics struct {
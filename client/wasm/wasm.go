@@ -9,6 +9,10 @@ type Wasm interface {
 	PeekBytes() []byte
 	Signal(sig string) error
 	Wait() (*Status, error)
+	// Invoke calls an exported function of a module in function mode. Arguments
+	// are converted to the parameter types of the function, and results are
+	// returned as int32, int64, float32 or float64 values.
+	Invoke(name string, args ...any) ([]any, error)
 	Stdin() io.WriteCloser
 	Stdout() io.ReadCloser
 	Stderr() io.ReadCloser
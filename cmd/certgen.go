@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	teletls "github.com/gocircuit/circuit/kit/tele/tls"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	var cmds = []*cli.Command{
+		{
+			Name:  "certgen",
+			Usage: "Generate certificates for mutual TLS transport security",
+			Subcommands: []*cli.Command{
+				{
+					Name:   "ca",
+					Usage:  "Create a local certificate authority, written to ca.crt and ca.key",
+					Action: certgenCA,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dir", Value: ".", Usage: "Directory of the certificate files"},
+						&cli.IntFlag{Name: "days", Value: 3650, Usage: "Days the certificate is valid for"},
+					},
+				},
+				{
+					Name:      "node",
+					Usage:     "Issue a certificate for a circuit server from the local authority, written to NAME.crt and NAME.key",
+					Args:      true,
+					ArgsUsage: "name",
+					Action:    certgenNode,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dir", Value: ".", Usage: "Directory of the certificate files"},
+						&cli.IntFlag{Name: "days", Value: 365, Usage: "Days the certificate is valid for"},
+						&cli.StringSliceFlag{Name: "host", Usage: "Host name or IP address of the server"},
+					},
+				},
+				{
+					Name:      "client",
+					Usage:     "Issue a certificate for a circuit client from the local authority, written to NAME.crt and NAME.key",
+					Args:      true,
+					ArgsUsage: "name",
+					Action:    certgenClient,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dir", Value: ".", Usage: "Directory of the certificate files"},
+						&cli.IntFlag{Name: "days", Value: 365, Usage: "Days the certificate is valid for"},
+					},
+				},
+			},
+		},
+	}
+
+	RegisterCommand(cmds...)
+}
+
+func validity(x *cli.Context) time.Duration {
+	return time.Duration(x.Int("days")) * 24 * time.Hour
+}
+
+// circuit certgen ca --dir /etc/circuit
+func certgenCA(x *cli.Context) (err error) {
+	cert, key, err := teletls.NewAuthority("circuit", validity(x))
+	if err != nil {
+		return errors.Wrapf(err, "certificate authority error: %v", err)
+	}
+	return writeCert(x.String("dir"), "ca", cert, key)
+}
+
+// circuit certgen node --host 10.0.0.1 host1
+func certgenNode(x *cli.Context) (err error) {
+	return issueCert(x, teletls.RoleNode, x.StringSlice("host"))
+}
+
+// circuit certgen client alice
+func certgenClient(x *cli.Context) (err error) {
+	return issueCert(x, teletls.RoleClient, nil)
+}
+
+func issueCert(x *cli.Context, role string, hosts []string) error {
+	if x.Args().Len() != 1 {
+		return errors.New("certgen needs a name argument")
+	}
+	name, dir := x.Args().First(), x.String("dir")
+	caCert, err := os.ReadFile(filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		return errors.Wrapf(err, "certificate authority not readable, create it with circuit certgen ca: %v", err)
+	}
+	caKey, err := os.ReadFile(filepath.Join(dir, "ca.key"))
+	if err != nil {
+		return errors.Wrapf(err, "certificate authority key not readable: %v", err)
+	}
+	cert, key, err := teletls.Issue(caCert, caKey, name, role, hosts, validity(x))
+	if err != nil {
+		return errors.Wrapf(err, "certificate error: %v", err)
+	}
+	if err = writeCert(dir, name, cert, key); err != nil {
+		return err
+	}
+	// The serial number revokes the certificate, if listed in circuit start -tls-revoked
+	serial, err := teletls.SerialPEM(cert)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "serial %s\n", serial)
+	return nil
+}
+
+func writeCert(dir, name string, cert, key []byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	crt, k := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	if err := os.WriteFile(crt, cert, 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(k, key, 0600); err != nil {
+		return err
+	}
+	fmt.Println(crt)
+	fmt.Println(k)
+	return nil
+}
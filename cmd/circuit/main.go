@@ -13,9 +13,13 @@ import (
 	"os"
 
 	"github.com/gocircuit/circuit/cmd"
+	_ "github.com/gocircuit/circuit/element/cluster"
 	_ "github.com/gocircuit/circuit/element/dns"
 	_ "github.com/gocircuit/circuit/element/docker"
+	_ "github.com/gocircuit/circuit/element/job"
+	_ "github.com/gocircuit/circuit/element/mesos"
 	_ "github.com/gocircuit/circuit/element/podman/container"
+	_ "github.com/gocircuit/circuit/element/podman/image"
 	_ "github.com/gocircuit/circuit/element/podman/network"
 	_ "github.com/gocircuit/circuit/element/podman/pod"
 	_ "github.com/gocircuit/circuit/element/podman/volume"
@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	cl "github.com/gocircuit/circuit/client/cluster"
+	"github.com/gocircuit/circuit/client/makers"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	cmds := []*cli.Command{
+		{
+			Name:  "cluster",
+			Usage: "Cluster commands",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "create",
+					Usage:     "Create a cluster element starting circuit servers on hosts, reading the cluster JSON from stdin",
+					Args:      true,
+					ArgsUsage: "anchor",
+					Action:    createCluster,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "add",
+					Usage:     "Add a host to a cluster and start its server, reading the host JSON from stdin",
+					Args:      true,
+					ArgsUsage: "anchor",
+					Action:    addClusterHost,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "rm",
+					Usage:     "Stop and remove a host of a cluster",
+					Args:      true,
+					ArgsUsage: "anchor host",
+					Action:    removeClusterHost,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "start",
+					Usage:     "Start the server of a stopped host",
+					Args:      true,
+					ArgsUsage: "anchor host",
+					Action:    startClusterHost,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "stop",
+					Usage:     "Stop the server of a host",
+					Args:      true,
+					ArgsUsage: "anchor host",
+					Action:    stopClusterHost,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "shutdown",
+					Usage:     "Stop the servers of all hosts",
+					Args:      true,
+					ArgsUsage: "anchor",
+					Action:    shutdownCluster,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "join",
+					Usage:     "Merge the circuit of a cluster with the circuit of a member",
+					Args:      true,
+					ArgsUsage: "anchor address",
+					Action:    joinCluster,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "inspect",
+					Usage:     "Show the state of a cluster or of one of its hosts",
+					Args:      true,
+					ArgsUsage: "anchor [host]",
+					Action:    inspectCluster,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+			},
+		},
+	}
+
+	RegisterCommand(cmds...)
+}
+
+// getCluster returns the cluster element at the anchor argument
+func getCluster(x *cli.Context) (cl.Cluster, error) {
+	c := dial(x)
+	w, _ := parseGlob(x.Args().First())
+	u, ok := c.Walk(w).Get().(cl.Cluster)
+	if !ok {
+		return nil, errors.New("anchor is not a cluster element")
+	}
+	return u, nil
+}
+
+// circuit cluster create /X1234/hola/charlie << EOF
+// { "name": "charlie", "hosts": [{ "Name": "a" }, { "Name": "b" }] }
+// EOF
+func createCluster(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	c := dial(x)
+	args := x.Args()
+	if args.Len() != 1 {
+		return errors.New("cluster create needs an anchor argument")
+	}
+	w, _ := parseGlob(args.First())
+	buf, _ := io.ReadAll(os.Stdin)
+	var config cl.ClusterConfig
+	if err = json.Unmarshal(buf, &config); err != nil {
+		return errors.Wrapf(err, "cluster json not parsing: %v", err)
+	}
+	if _, err = c.Walk(w).Make(makers.ClusterType, config); err != nil {
+		return errors.Wrapf(err, "cluster create error: %s", err)
+	}
+	return
+}
+
+// circuit cluster add /X1234/hola/charlie << EOF
+// { "Name": "c", "launcher": "ssh", "host": "user@10.0.0.3", "addr": "10.0.0.3:11022" }
+// EOF
+func addClusterHost(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	if x.Args().Len() != 1 {
+		return errors.New("cluster add needs an anchor argument")
+	}
+	u, err := getCluster(x)
+	if err != nil {
+		return err
+	}
+	buf, _ := io.ReadAll(os.Stdin)
+	var hc cl.HostConfig
+	if err = json.Unmarshal(buf, &hc); err != nil {
+		return errors.Wrapf(err, "host json not parsing: %v", err)
+	}
+	if err = u.AddHost(&hc); err != nil {
+		return errors.Wrapf(err, "cluster add error: %v", err)
+	}
+	return
+}
+
+// clusterAction returns an action calling f with the second argument, named arg
+func clusterAction(name, arg string, f func(u cl.Cluster, a string) error) cli.ActionFunc {
+	return func(x *cli.Context) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+			}
+		}()
+		if x.Args().Len() != 2 {
+			return fmt.Errorf("cluster %s needs an anchor and a %s argument", name, arg)
+		}
+		u, err := getCluster(x)
+		if err != nil {
+			return err
+		}
+		if err = f(u, x.Args().Get(1)); err != nil {
+			return errors.Wrapf(err, "cluster %s error: %v", name, err)
+		}
+		return
+	}
+}
+
+var (
+	removeClusterHost = clusterAction("rm", "host", cl.Cluster.RemoveHost)
+	startClusterHost  = clusterAction("start", "host", cl.Cluster.Start)
+	stopClusterHost   = clusterAction("stop", "host", cl.Cluster.Stop)
+	joinCluster       = clusterAction("join", "address", cl.Cluster.Join)
+)
+
+func shutdownCluster(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	if x.Args().Len() != 1 {
+		return errors.New("cluster shutdown needs an anchor argument")
+	}
+	u, err := getCluster(x)
+	if err != nil {
+		return err
+	}
+	if err = u.Shutdown(); err != nil {
+		return errors.Wrapf(err, "cluster shutdown error: %v", err)
+	}
+	return
+}
+
+func inspectCluster(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	args := x.Args()
+	if args.Len() != 1 && args.Len() != 2 {
+		return errors.New("cluster inspect needs an anchor and an optional host argument")
+	}
+	u, err := getCluster(x)
+	if err != nil {
+		return err
+	}
+	var v any
+	if args.Len() == 2 {
+		v, err = u.InspectHost(args.Get(1))
+	} else {
+		v, err = u.Inspect()
+	}
+	if err != nil {
+		return errors.Wrapf(err, "cluster inspect error: %v", err)
+	}
+	buf, _ := json.MarshalIndent(v, "", "\t")
+	fmt.Println(string(buf))
+	return
+}
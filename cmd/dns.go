@@ -27,7 +27,11 @@ func init() {
 			Flags: []cli.Flag{
 				&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
 				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
-				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing HMAC credentials. Use RC4 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
 			},
 		},
 		{
@@ -39,7 +43,11 @@ func init() {
 			Flags: []cli.Flag{
 				&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
 				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
-				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing HMAC credentials. Use RC4 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
 			},
 		},
 		{
@@ -51,7 +59,11 @@ func init() {
 			Flags: []cli.Flag{
 				&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
 				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
-				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing HMAC credentials. Use RC4 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
 			},
 		},
 	}
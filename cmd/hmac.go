@@ -8,21 +8,28 @@
 package cmd
 
 import (
+	"crypto/rand"
 	"crypto/sha512"
 	"encoding/base64"
 	"fmt"
-	"math/rand"
-	"time"
+	"os"
 
+	"github.com/gocircuit/circuit/kit/tele/aead"
+	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
 
 func init() {
 	var cmds = []*cli.Command{
 		{
-			Name:   "keygen",
-			Usage:  "Generate a new random HMAC key",
-			Action: keygen,
+			Name:      "keygen",
+			Usage:     "Generate a new random key, or rotate the keys in a keyring file",
+			Args:      true,
+			ArgsUsage: "[keyring]",
+			Action:    keygen,
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "rotate", Usage: "make a new key current in the keyring file, keeping the current key as the previous one"},
+			},
 		},
 	}
 
@@ -30,15 +37,32 @@ func init() {
 }
 
 func keygen(c *cli.Context) (err error) {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	//rand.Seed(time.Now().UnixNano())
+	key, err := newKey()
+	if err != nil {
+		return err
+	}
+	if !c.Bool("rotate") {
+		fmt.Println(base64.StdEncoding.EncodeToString(key))
+		return
+	}
+	if c.Args().Len() != 1 {
+		return errors.New("keygen --rotate needs a keyring file argument")
+	}
+	file := c.Args().First()
+	keys, err := loadKeys(file)
+	if err != nil {
+		return err
+	}
+	// The new key becomes current, and the current key previous.
+	// Older keys are retired.
+	return os.WriteFile(file, aead.FormatKeyring([][]byte{key, keys[0]}), 0600)
+}
+
+func newKey() ([]byte, error) {
 	seed := make([]byte, 32)
-	for i := range seed {
-		//seed[i] = byte(rand.Int31())
-		seed[i] = byte(r.Int31())
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
 	}
 	key := sha512.Sum512(seed)
-	text := base64.StdEncoding.EncodeToString(key[:])
-	fmt.Println(text)
-	return
+	return key[:], nil
 }
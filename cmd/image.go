@@ -0,0 +1,425 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gocircuit/circuit/anchor"
+	"github.com/gocircuit/circuit/client/makers"
+	"github.com/gocircuit/circuit/client/podman"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	cmds := []*cli.Command{
+		{
+			Name:    "image",
+			Aliases: []string{"img"},
+			Usage:   "image commands",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "create",
+					Usage:     "create an image element for the named image",
+					Args:      true,
+					ArgsUsage: "anchor name",
+					Action:    createImage,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "engine", Value: podman.EnginePodman, Usage: "engine managing the image, podman or docker"},
+						&cli.BoolFlag{Name: "scrub", Usage: "remove the image from the host when the anchor is scrubbed"},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "build",
+					Usage:     "build the image from a context directory on the host of the anchor",
+					Args:      true,
+					ArgsUsage: "anchor context",
+					Action:    buildImage,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Usage: "containerfile, relative to the context directory"},
+						&cli.StringSliceFlag{Name: "build-arg", Usage: "build argument, key=value"},
+						&cli.StringSliceFlag{Name: "label", Usage: "image label, key=value"},
+						&cli.StringFlag{Name: "target", Usage: "target build stage"},
+						&cli.StringFlag{Name: "platform", Usage: "target platform, os/arch[/variant]"},
+						&cli.BoolFlag{Name: "no-cache", Usage: "do not use cached layers"},
+						&cli.BoolFlag{Name: "pull", Usage: "always pull base images"},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "exists",
+					Usage:     "checks if the image exists on the host of the anchor",
+					Args:      true,
+					ArgsUsage: "anchor",
+					Action:    checkImage,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "inspect",
+					Usage:     "display information for the image hosted at anchor",
+					Args:      true,
+					ArgsUsage: "anchor",
+					Action:    inspectImage,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "load",
+					Usage:     "load the image from an archive file on the host of the anchor",
+					Args:      true,
+					ArgsUsage: "anchor file",
+					Action:    loadImage,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "pull",
+					Usage:     "pull the image hosted at anchor from its registry",
+					Args:      true,
+					ArgsUsage: "anchor",
+					Action:    pullImage,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "platform", Usage: "platform of the image, os/arch[/variant]"},
+						&cli.StringFlag{Name: "policy", Usage: "pull policy, always, missing, never or newer (podman only)"},
+						&cli.BoolFlag{Name: "tls-verify", Value: true, Usage: "verify certificates of the registry (podman only)"},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "push",
+					Usage:     "copy the image hosted at anchor to other circuit servers, under the same anchor path",
+					Args:      true,
+					ArgsUsage: "anchor server...",
+					Action:    pushImage,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "save",
+					Usage:     "save the image to an archive file on the host of the anchor",
+					Args:      true,
+					ArgsUsage: "anchor file",
+					Action:    saveImage,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "scrub",
+					Usage:     "remove the image element hosted at anchor",
+					Args:      true,
+					ArgsUsage: "anchor",
+					Action:    scrb,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "tag",
+					Usage:     "add names to the image hosted at anchor",
+					Args:      true,
+					ArgsUsage: "anchor tag...",
+					Action:    tagImage,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+			},
+		},
+	}
+	RegisterCommand(cmds...)
+}
+
+func createImage(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = wrapError(r)
+		}
+	}()
+
+	c := dial(x)
+	var w []string
+	if w, err = ParseAnchor(x); err != nil {
+		return err
+	}
+	if x.Args().Len() != 2 {
+		return errors.New("create needs an anchor and an image name")
+	}
+
+	opts := podman.ImageCreateOptions{
+		Name:   x.Args().Get(1),
+		Engine: x.String("engine"),
+		Scrub:  x.Bool("scrub"),
+	}
+
+	if _, err = c.Walk(w).Make(makers.ImageType, opts); err != nil {
+		return errors.Wrapf(err, "createImage error: %s", err)
+	}
+
+	return
+}
+
+func buildImage(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = wrapError(r)
+		}
+	}()
+
+	var img podman.Image
+	if img, err = getAnchorType[podman.Image](x, anchor.Image); err != nil {
+		return
+	}
+	if x.Args().Len() != 2 {
+		return errors.New("build needs an anchor and a context directory")
+	}
+
+	opts := &podman.ImageBuildOptions{
+		Context:   x.Args().Get(1),
+		File:      x.String("file"),
+		BuildArgs: x.StringSlice("build-arg"),
+		Labels:    x.StringSlice("label"),
+		Target:    x.String("target"),
+		Platform:  x.String("platform"),
+		NoCache:   x.Bool("no-cache"),
+		Pull:      x.Bool("pull"),
+	}
+
+	done := followProgress(img.Progress(), 1)
+	if err = img.Build(opts); err != nil {
+		return errors.Wrapf(err, "build image error")
+	}
+	<-done
+
+	return
+}
+
+func checkImage(x *cli.Context) error {
+	return checkAnchorType[podman.Image](x, anchor.Image)
+}
+
+func inspectImage(x *cli.Context) error {
+	return inspectAnchorType[podman.Image](x, anchor.Image)
+}
+
+func loadImage(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = wrapError(r)
+		}
+	}()
+
+	var img podman.Image
+	if img, err = getAnchorType[podman.Image](x, anchor.Image); err != nil {
+		return
+	}
+	if x.Args().Len() != 2 {
+		return errors.New("load needs an anchor and an archive file")
+	}
+
+	if err = img.Load(x.Args().Get(1)); err != nil {
+		return errors.Wrapf(err, "load image error")
+	}
+
+	return
+}
+
+func pullImage(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = wrapError(r)
+		}
+	}()
+
+	var img podman.Image
+	if img, err = getAnchorType[podman.Image](x, anchor.Image); err != nil {
+		return
+	}
+
+	opts := &podman.ImagePullOptions{
+		Platform: x.String("platform"),
+		Policy:   x.String("policy"),
+	}
+	if x.IsSet("tls-verify") {
+		verify := x.Bool("tls-verify")
+		opts.TLSVerify = &verify
+	}
+
+	done := followProgress(img.Progress(), 1)
+	if err = img.Pull(opts); err != nil {
+		return errors.Wrapf(err, "pull image error")
+	}
+	<-done
+
+	return
+}
+
+func pushImage(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = wrapError(r)
+		}
+	}()
+
+	var img podman.Image
+	if img, err = getAnchorType[podman.Image](x, anchor.Image); err != nil {
+		return
+	}
+	servers := x.Args().Tail()
+	if len(servers) == 0 {
+		return errors.New("push needs an anchor and at least one server")
+	}
+
+	done := followProgress(img.Progress(), len(servers))
+	if err = img.Push(servers...); err != nil {
+		return errors.Wrapf(err, "push image error")
+	}
+	<-done
+
+	return
+}
+
+func saveImage(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = wrapError(r)
+		}
+	}()
+
+	var img podman.Image
+	if img, err = getAnchorType[podman.Image](x, anchor.Image); err != nil {
+		return
+	}
+	if x.Args().Len() != 2 {
+		return errors.New("save needs an anchor and an archive file")
+	}
+
+	if err = img.Save(x.Args().Get(1)); err != nil {
+		return errors.Wrapf(err, "save image error")
+	}
+
+	return
+}
+
+func tagImage(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = wrapError(r)
+		}
+	}()
+
+	var img podman.Image
+	if img, err = getAnchorType[podman.Image](x, anchor.Image); err != nil {
+		return
+	}
+	tags := x.Args().Tail()
+	if len(tags) == 0 {
+		return errors.New("tag needs an anchor and at least one tag")
+	}
+
+	if err = img.Tag(tags...); err != nil {
+		return errors.Wrapf(err, "tag image error")
+	}
+
+	return
+}
+
+// followProgress prints the progress reports of an image element until n
+// operations are done. The returned channel is closed when printing ends.
+func followProgress(s podman.Subscription, n int) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer s.Scrub()
+		for n > 0 {
+			v, ok := s.Consume()
+			if !ok {
+				return
+			}
+			p, ok := v.(*podman.ImageProgress)
+			if !ok {
+				continue
+			}
+			switch {
+			case p.Done && p.Err != "":
+				fmt.Printf("%s %s failed: %s\n", p.Server, p.Op, p.Err)
+				n--
+			case p.Done && p.Op == podman.ImagePush:
+				fmt.Printf("%s pushed\n", p.Server)
+				n--
+			case p.Done:
+				n--
+			case p.Op == podman.ImagePush:
+				fmt.Printf("%s %d/%d bytes\n", p.Server, p.Bytes, p.Total)
+			default:
+				fmt.Println(p.Message)
+			}
+		}
+	}()
+	return done
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	cl "github.com/gocircuit/circuit/client/job"
+	"github.com/gocircuit/circuit/client/makers"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	cmds := []*cli.Command{
+		{
+			Name:  "job",
+			Usage: "Job commands",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "create",
+					Usage:     "Create a job element running parallel tasks across servers, reading the job JSON from stdin",
+					Args:      true,
+					ArgsUsage: "anchor",
+					Action:    createJob,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+						&cli.BoolFlag{Name: "wait", Aliases: []string{"w"}, Usage: "wait for the job to finish and print its status"},
+					},
+				},
+				{
+					Name:      "status",
+					Usage:     "Show the done, running and failed tasks of a job",
+					Args:      true,
+					ArgsUsage: "anchor",
+					Action:    statusJob,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "wait",
+					Usage:     "Wait for a job to finish and show its status",
+					Args:      true,
+					ArgsUsage: "anchor",
+					Action:    waitJob,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+			},
+		},
+	}
+
+	RegisterCommand(cmds...)
+}
+
+func getJob(x *cli.Context) (cl.Job, error) {
+	c := dial(x)
+	w, _ := parseGlob(x.Args().First())
+	j, ok := c.Walk(w).Get().(cl.Job)
+	if !ok {
+		return nil, errors.New("anchor is not a job element")
+	}
+	return j, nil
+}
+
+// circuit job create /X1234/batch << EOF
+// { "tasks": 5, "retries": 1, "cmd": { "path": "/bin/sleep", "args": ["{{.Index}}"] } }
+// EOF
+func createJob(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	c := dial(x)
+	args := x.Args()
+	if args.Len() != 1 {
+		return errors.New("job create needs an anchor argument")
+	}
+	w, _ := parseGlob(args.First())
+	buf, _ := io.ReadAll(os.Stdin)
+	var spec cl.Spec
+	if err = json.Unmarshal(buf, &spec); err != nil {
+		return errors.Wrapf(err, "job json not parsing: %v", err)
+	}
+	v, err := c.Walk(w).Make(makers.JobType, spec)
+	if err != nil {
+		return errors.Wrapf(err, "job create error: %s", err)
+	}
+	if !x.Bool("wait") {
+		return
+	}
+	return waitFor(v.(cl.Job))
+}
+
+func statusJob(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	if x.Args().Len() != 1 {
+		return errors.New("job status needs an anchor argument")
+	}
+	j, err := getJob(x)
+	if err != nil {
+		return err
+	}
+	printJob(os.Stdout, j.Peek())
+	return
+}
+
+func waitJob(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	if x.Args().Len() != 1 {
+		return errors.New("job wait needs an anchor argument")
+	}
+	j, err := getJob(x)
+	if err != nil {
+		return err
+	}
+	return waitFor(j)
+}
+
+// waitFor waits for j, prints its status and fails if the job failed
+func waitFor(j cl.Job) error {
+	s, err := j.Wait()
+	if err != nil {
+		return errors.Wrapf(err, "job wait error: %v", err)
+	}
+	printJob(os.Stdout, s)
+	if s.State != cl.Done {
+		return errors.Errorf("job %s", s.State)
+	}
+	return nil
+}
+
+// printJob writes the task counts of a job, followed by a table of its tasks
+func printJob(w io.Writer, s cl.Stat) {
+	fmt.Fprintf(w, "%s: %d done, %d running, %d failed, %d pending, %d aborted\n",
+		s.State, s.Done, s.Running, s.Failed, s.Pending, s.Aborted)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "TASK\tSTATE\tATTEMPTS\tANCHOR\tEXIT")
+	for _, t := range s.Tasks {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\n", t.Index, t.State, t.Attempts, t.Anchor, t.Exit)
+	}
+	tw.Flush()
+}
@@ -19,6 +19,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/gocircuit/circuit/anchor"
+	srv "github.com/gocircuit/circuit/element/server"
 	_ "github.com/gocircuit/circuit/kit/debug/kill"
 	"github.com/gocircuit/circuit/kit/lockfile"
 	"github.com/gocircuit/circuit/sys/lang"
@@ -33,7 +35,7 @@ func init() {
 	// }
 }
 
-func load(addr *net.TCPAddr, vardir string, key []byte) n.Addr {
+func load(addr *net.TCPAddr, vardir string, sec n.Security) *lang.Runtime {
 	//debug.InstallCtrlCPanic()
 
 	// Randomize execution
@@ -51,6 +53,7 @@ func load(addr *net.TCPAddr, vardir string, key []byte) n.Addr {
 	if err := os.MkdirAll(dir, 0700); err != nil {
 		log.Fatalf("mkdir %s (%s)", dir, err)
 	}
+	srv.UseVarDir(dir)
 
 	// Create a lock file in the chroot directory so its not managed by two circuit instances at the same time
 	lockname := path.Join(dir, ".lock")
@@ -59,14 +62,25 @@ func load(addr *net.TCPAddr, vardir string, key []byte) n.Addr {
 	}
 	log.Printf("Created and locked %s", lockname)
 
+	// Open the audit log of anchor operations
+	audit, err := anchor.OpenAudit(path.Join(dir, "audit.log"))
+	if err != nil {
+		log.Fatalf("open audit log (%s)", err)
+	}
+	anchor.UseAudit(audit)
+
 	// Initialize networking
-	if len(key) > 0 {
-		log.Println("Using symmetric HMAC authentication and RC4 encryption.")
+	switch {
+	case sec.TLS != nil:
+		log.Println("Using mutual TLS authentication and encryption.")
+	case sec.Keys != nil:
+		log.Println("Using shared key authentication and AEAD encryption.")
 	}
-	t := n.NewTransport(id, addr, key)
+	t := n.NewTransport(id, addr, sec)
 	fmt.Println(t.Addr().String())
 
 	// Initialize language runtime
-	circuit.Bind(lang.New(t))
-	return t.Addr()
+	rt := lang.New(t)
+	circuit.Bind(rt)
+	return rt
 }
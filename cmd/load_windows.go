@@ -18,6 +18,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/gocircuit/circuit/anchor"
+	srv "github.com/gocircuit/circuit/element/server"
 	_ "github.com/gocircuit/circuit/kit/debug/kill"
 	"github.com/gocircuit/circuit/sys/lang"
 	_ "github.com/gocircuit/circuit/sys/tele"
@@ -25,7 +27,7 @@ import (
 	"github.com/gocircuit/circuit/use/n"
 )
 
-func load(addr *net.TCPAddr, vardir string, key []byte) n.Addr {
+func load(addr *net.TCPAddr, vardir string, sec n.Security) *lang.Runtime {
 	//debug.InstallCtrlCPanic()
 
 	// Randomize execution
@@ -43,15 +45,27 @@ func load(addr *net.TCPAddr, vardir string, key []byte) n.Addr {
 	if err := os.MkdirAll(dir, 0700); err != nil {
 		log.Fatalf("mkdir %s (%s)", dir, err)
 	}
+	srv.UseVarDir(dir)
+
+	// Open the audit log of anchor operations
+	audit, err := anchor.OpenAudit(filepath.Join(dir, "audit.log"))
+	if err != nil {
+		log.Fatalf("open audit log (%s)", err)
+	}
+	anchor.UseAudit(audit)
 
 	// Initialize networking
-	if len(key) > 0 {
-		log.Println("Using symmetric HMAC authentication and RC4 encryption.")
+	switch {
+	case sec.TLS != nil:
+		log.Println("Using mutual TLS authentication and encryption.")
+	case sec.Keys != nil:
+		log.Println("Using shared key authentication and AEAD encryption.")
 	}
-	t := n.NewTransport(id, addr, key)
+	t := n.NewTransport(id, addr, sec)
 	fmt.Println(t.Addr().String())
 
 	// Initialize language runtime
-	circuit.Bind(lang.New(t))
-	return t.Addr()
+	rt := lang.New(t)
+	circuit.Bind(rt)
+	return rt
 }
@@ -31,7 +31,11 @@ func init() {
 				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
 				&cli.BoolFlag{Name: "long", Aliases: []string{"l"}, Usage: "show detailed anchor information"},
 				&cli.BoolFlag{Name: "depth", Aliases: []string{"de"}, Usage: "traverse anchors in depth-first order (leaves first)"},
-				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing HMAC credentials. Use RC4 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
 			},
 		},
 	}
@@ -71,17 +75,7 @@ func list(level int, prefix string, anchor client.Anchor, recurse, long, depth b
 	//println(fmt.Sprintf("prefix=%v a=%v/%T r=%v", prefix, anchor, anchor, recurse))
 	var c children
 	for n, a := range anchor.View() {
-		e := &entry{n: n, a: a}
-		if v := a.Get(); v == nil {
-			e.k = "."
-		} else {
-			if maker := client.FindElementMaker(v); maker != nil {
-				e.k = maker.Name()
-			} else {
-				e.k = "."
-			}
-		}
-		c = append(c, e)
+		c = append(c, &entry{n: n, a: a, k: kind(a)})
 	}
 	sort.Sort(c)
 	for _, e := range c {
@@ -99,6 +93,21 @@ func list(level int, prefix string, anchor client.Anchor, recurse, long, depth b
 	}
 }
 
+// kind returns the kind of element at a, or - if the access policy of the server hides it
+func kind(a client.Anchor) (k string) {
+	defer func() {
+		if r := recover(); r != nil {
+			k = "-"
+		}
+	}()
+	if v := a.Get(); v != nil {
+		if maker := client.FindElementMaker(v); maker != nil {
+			return maker.Name()
+		}
+	}
+	return "."
+}
+
 type entry struct {
 	n string
 	a client.Anchor
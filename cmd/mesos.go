@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gocircuit/circuit/client/makers"
+	ms "github.com/gocircuit/circuit/client/mesos"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	cmds := []*cli.Command{
+		{
+			Name:  "mesos",
+			Usage: "Resource exchange commands",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "create",
+					Usage:     "Create a mesos element, which every circuit server offers its spare resources to",
+					Args:      true,
+					ArgsUsage: "anchor",
+					Action:    createMesos,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "offer",
+					Usage:     "Pin the resources a server offers, or return it to offering its spare resources when none are given",
+					Args:      true,
+					ArgsUsage: "anchor server [cpu=4,mem=2048,...]",
+					Action:    offerMesos,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "ask",
+					Usage:     "Ask for a grant of resources on behalf of a framework, and print the grant",
+					Args:      true,
+					ArgsUsage: "anchor framework cpu=2,mem=512,...",
+					Action:    askMesos,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+				{
+					Name:      "release",
+					Usage:     "Release a grant",
+					Args:      true,
+					ArgsUsage: "anchor grant",
+					Action:    releaseMesos,
+					Flags: []cli.Flag{
+						&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+						&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+						&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+						&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+						&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+						&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+						&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+					},
+				},
+			},
+		},
+	}
+
+	RegisterCommand(cmds...)
+}
+
+func getMesos(x *cli.Context) (ms.Mesos, error) {
+	c := dial(x)
+	w, _ := parseGlob(x.Args().First())
+	m, ok := c.Walk(w).Get().(ms.Mesos)
+	if !ok {
+		return nil, errors.New("anchor is not a mesos element")
+	}
+	return m, nil
+}
+
+// circuit mesos create /X1234/mesos
+func createMesos(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	c := dial(x)
+	args := x.Args()
+	if args.Len() != 1 {
+		return errors.New("mesos create needs an anchor argument")
+	}
+	w, _ := parseGlob(args.First())
+	if _, err = c.Walk(w).Make(makers.MesosType, ""); err != nil {
+		return errors.Wrapf(err, "mesos create error: %s", err)
+	}
+	return
+}
+
+// circuit mesos offer /X1234/mesos X5678 cpu=2,gpu=1
+func offerMesos(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	args := x.Args()
+	if args.Len() != 2 && args.Len() != 3 {
+		return errors.New("mesos offer needs an anchor, a server and an optional resource argument")
+	}
+	var rsc ms.Resource
+	if args.Len() == 3 {
+		if rsc, err = ms.ParseResource(args.Get(2)); err != nil {
+			return err
+		}
+	}
+	m, err := getMesos(x)
+	if err != nil {
+		return err
+	}
+	if err = m.Offer(args.Get(1), rsc); err != nil {
+		return errors.Wrapf(err, "mesos offer error: %v", err)
+	}
+	return
+}
+
+// circuit mesos ask /X1234/mesos /X5678/job cpu=2,mem=512
+func askMesos(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	args := x.Args()
+	if args.Len() != 3 {
+		return errors.New("mesos ask needs an anchor, a framework and a resource argument")
+	}
+	rsc, err := ms.ParseResource(args.Get(2))
+	if err != nil {
+		return err
+	}
+	m, err := getMesos(x)
+	if err != nil {
+		return err
+	}
+	g, err := m.Ask(args.Get(1), rsc)
+	if err != nil {
+		return errors.Wrapf(err, "mesos ask error: %v", err)
+	}
+	buf, _ := json.MarshalIndent(g, "", "\t")
+	fmt.Println(string(buf))
+	return
+}
+
+func releaseMesos(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	if x.Args().Len() != 2 {
+		return errors.New("mesos release needs an anchor and a grant argument")
+	}
+	m, err := getMesos(x)
+	if err != nil {
+		return err
+	}
+	if err = m.Release(x.Args().Get(1)); err != nil {
+		return errors.Wrapf(err, "mesos release error: %v", err)
+	}
+	return
+}
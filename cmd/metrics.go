@@ -0,0 +1,148 @@
+// Copyright 2013 The Go Circuit Project
+// Use of this source code is governed by the license for
+// The Go Circuit Project, found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gocircuit/circuit/sys/lang"
+	"github.com/gocircuit/circuit/sys/lang/prof"
+	"github.com/gocircuit/circuit/tissue"
+	"github.com/gocircuit/circuit/tissue/locus"
+)
+
+// metrics is a snapshot of the metrics of a circuit server
+type metrics struct {
+	Profile   *prof.WorkerStat
+	Runtime   *lang.Stat
+	Elements  map[string]int
+	Neighbors int
+	Peers     int
+}
+
+// serveMetrics serves the metrics of the circuit server at /metrics on addr,
+// in the Prometheus text exposition format
+func serveMetrics(addr string, rt *lang.Runtime, kin *tissue.Kin, l *locus.Locus) (net.Addr, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, req *http.Request) {
+		m := &metrics{
+			Profile:   rt.Profile().Stat(),
+			Runtime:   rt.Stat(),
+			Elements:  l.Elements(),
+			Neighbors: kin.NumNeighbors(),
+			Peers:     len(l.GetPeers()),
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.write(w)
+	})
+	go http.Serve(ln, mux)
+	return ln.Addr(), nil
+}
+
+func (m *metrics) write(w io.Writer) {
+	p := promWriter{w}
+
+	p.help("circuit_runtime_calls_total", "counter", "Cross-runtime calls begun, by side (call or reply) and procedure.")
+	p.procs("circuit_runtime_calls_total", m.Profile, func(s *prof.Stat) float64 { return float64(s.Begin) })
+	p.help("circuit_runtime_call_aborts_total", "counter", "Cross-runtime calls made that failed, by procedure.")
+	for _, proc := range sortedProcs(m.Profile.CallProc) {
+		p.sample("circuit_runtime_call_aborts_total", float64(m.Profile.CallProc[proc].Abort), "proc", proc)
+	}
+	p.help("circuit_runtime_call_duration_seconds", "summary", "Duration of completed cross-runtime calls, by side and procedure.")
+	p.procs("circuit_runtime_call_duration_seconds_sum", m.Profile, func(s *prof.Stat) float64 { return s.DurAvg * float64(s.End) / 1e9 })
+	p.procs("circuit_runtime_call_duration_seconds_count", m.Profile, func(s *prof.Stat) float64 { return float64(s.End) })
+	p.help("circuit_runtime_calls_in_flight", "gauge", "Cross-runtime calls in progress, by side.")
+	p.sample("circuit_runtime_calls_in_flight", inFlight(m.Profile.CallTotal), "side", "call")
+	p.sample("circuit_runtime_calls_in_flight", inFlight(m.Profile.ReplyTotal), "side", "reply")
+
+	p.help("circuit_runtime_exported_handles", "gauge", "Handles to local values held by other runtimes, by permanence.")
+	p.sample("circuit_runtime_exported_handles", float64(m.Runtime.ExpPerm), "perm", "true")
+	p.sample("circuit_runtime_exported_handles", float64(m.Runtime.ExpNonPerm), "perm", "false")
+	p.help("circuit_runtime_importers", "gauge", "Runtimes holding non-permanent handles to local values.")
+	p.sample("circuit_runtime_importers", float64(m.Runtime.ExpImporters))
+	p.help("circuit_runtime_imported_handles", "gauge", "Handles to values of other runtimes held locally, by permanence.")
+	p.sample("circuit_runtime_imported_handles", float64(m.Runtime.ImpPerm), "perm", "true")
+	p.sample("circuit_runtime_imported_handles", float64(m.Runtime.ImpNonPerm), "perm", "false")
+	p.help("circuit_runtime_exporters", "gauge", "Runtimes whose values are held locally.")
+	p.sample("circuit_runtime_exporters", float64(m.Runtime.ImpExporters))
+	p.help("circuit_runtime_open_connections", "gauge", "Open connections of the runtime, dialed or accepted.")
+	p.sample("circuit_runtime_open_connections", float64(m.Runtime.Conns))
+
+	p.help("circuit_elements", "gauge", "Elements on the anchors of this server, by kind.")
+	kinds := make([]string, 0, len(m.Elements))
+	for kind := range m.Elements {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		p.sample("circuit_elements", float64(m.Elements[kind]), "kind", kind)
+	}
+	p.help("circuit_tissue_neighborhood_size", "gauge", "Peers in the tissue neighborhood of this server.")
+	p.sample("circuit_tissue_neighborhood_size", float64(m.Neighbors))
+	p.help("circuit_locus_peers", "gauge", "Live circuit servers known to the locus of this server.")
+	p.sample("circuit_locus_peers", float64(m.Peers))
+}
+
+func inFlight(s *prof.Stat) float64 {
+	return float64(s.Begin - s.End - s.Abort)
+}
+
+func sortedProcs(stat map[string]*prof.Stat) []string {
+	procs := make([]string, 0, len(stat))
+	for proc := range stat {
+		procs = append(procs, proc)
+	}
+	sort.Strings(procs)
+	return procs
+}
+
+// promWriter writes metrics in the Prometheus text exposition format
+type promWriter struct {
+	w io.Writer
+}
+
+func (p promWriter) help(name, typ, help string) {
+	fmt.Fprintf(p.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// procs writes a sample of the named metric for each side and procedure in stat
+func (p promWriter) procs(name string, stat *prof.WorkerStat, value func(*prof.Stat) float64) {
+	for _, proc := range sortedProcs(stat.CallProc) {
+		p.sample(name, value(stat.CallProc[proc]), "side", "call", "proc", proc)
+	}
+	for _, proc := range sortedProcs(stat.ReplyProc) {
+		p.sample(name, value(stat.ReplyProc[proc]), "side", "reply", "proc", proc)
+	}
+}
+
+// sample writes a sample of the named metric, labeled by the key-value pairs in label
+func (p promWriter) sample(name string, value float64, label ...string) {
+	var w strings.Builder
+	w.WriteString(name)
+	for i := 0; i+1 < len(label); i += 2 {
+		if i == 0 {
+			w.WriteByte('{')
+		} else {
+			w.WriteByte(',')
+		}
+		fmt.Fprintf(&w, "%s=\"%s\"", label[i], labelEscaper.Replace(label[i+1]))
+	}
+	if len(label) > 0 {
+		w.WriteByte('}')
+	}
+	fmt.Fprintf(&w, " %g\n", value)
+	io.WriteString(p.w, w.String())
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gocircuit/circuit/sys/lang"
+	"github.com/gocircuit/circuit/sys/lang/prof"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	m := &metrics{
+		Profile: &prof.WorkerStat{
+			CallTotal:  &prof.Stat{Begin: 5, End: 3, Abort: 1},
+			CallProc:   map[string]*prof.Stat{"proc.XProc.Wait": {Begin: 5, End: 3, Abort: 1, DurAvg: 2e9}},
+			ReplyTotal: &prof.Stat{Begin: 2, End: 2},
+			ReplyProc:  map[string]*prof.Stat{`dial "odd"`: {Begin: 2, End: 2, DurAvg: 1e6}},
+		},
+		Runtime:   &lang.Stat{ExpPerm: 3, ExpNonPerm: 4, ImpNonPerm: 2, ImpExporters: 1, Conns: 7},
+		Elements:  map[string]int{"proc": 2, "chan": 1},
+		Neighbors: 4,
+		Peers:     5,
+	}
+	var b bytes.Buffer
+	m.write(&b)
+	for _, s := range []string{
+		"# TYPE circuit_runtime_calls_total counter\n",
+		`circuit_runtime_calls_total{side="call",proc="proc.XProc.Wait"} 5` + "\n",
+		`circuit_runtime_call_aborts_total{proc="proc.XProc.Wait"} 1` + "\n",
+		`circuit_runtime_call_duration_seconds_sum{side="call",proc="proc.XProc.Wait"} 6` + "\n",
+		`circuit_runtime_call_duration_seconds_count{side="reply",proc="dial \"odd\""} 2` + "\n",
+		`circuit_runtime_calls_in_flight{side="call"} 1` + "\n",
+		`circuit_runtime_exported_handles{perm="false"} 4` + "\n",
+		"circuit_runtime_open_connections 7\n",
+		`circuit_elements{kind="chan"} 1` + "\n",
+		"circuit_tissue_neighborhood_size 4\n",
+		"circuit_locus_peers 5\n",
+	} {
+		if !strings.Contains(b.String(), s) {
+			t.Fatalf("metrics miss %q:\n%s", s, b.String())
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gocircuit/circuit/client"
+	"github.com/gocircuit/circuit/client/makers"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	cmds := []*cli.Command{
+		{
+			Name:      "run",
+			Usage:     "Create a process element on any server meeting the constraints",
+			Args:      true,
+			ArgsUsage: "cmd.json",
+			Action:    run,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+				&cli.StringFlag{Name: "where", Aliases: []string{"w"}, Value: "", Usage: "comma-separated constraints on the server, such as cpu>=4,mem>=8G,podman,zone=east"},
+				&cli.StringFlag{Name: "anchor", Aliases: []string{"a"}, Value: "", Usage: "anchor path of the process under the chosen server"},
+				&cli.BoolFlag{Name: "scrub", Usage: "scrub the process anchor automatically on exit"},
+			},
+		},
+	}
+
+	RegisterCommand(cmds...)
+}
+
+// circuit run --where 'cpu>=4,podman' --anchor /job/1 cmd.json
+// Reads the command from standard input when the file is - or missing,
+// and prints the anchor the process was placed at.
+func run(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	args := x.Args()
+	if args.Len() > 1 {
+		return errors.New("run needs at most one command file argument")
+	}
+	if x.String("anchor") == "" {
+		return errors.New("run needs an --anchor path for the process")
+	}
+	var buf []byte
+	if f := args.First(); f == "" || f == "-" {
+		buf, err = io.ReadAll(os.Stdin)
+	} else {
+		buf, err = os.ReadFile(f)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "cannot read command: %v", err)
+	}
+	var cmd client.Cmd
+	if err = json.Unmarshal(buf, &cmd); err != nil {
+		return errors.Wrapf(err, "command json not parsing: %v", err)
+	}
+	if x.Bool("scrub") {
+		cmd.Scrub = true
+	}
+
+	c := dial(x)
+	a, p, err := c.Schedule(x.String("where"), x.String("anchor"), makers.ProcType, cmd)
+	if err != nil {
+		return errors.Wrapf(err, "run error: %s", err)
+	}
+	fmt.Println(a.Path())
+	if proc, ok := p.(client.Proc); ok {
+		if ps := proc.Peek(); ps.Exit != nil {
+			return errors.Errorf("%v", ps.Exit)
+		}
+	}
+	return
+}
@@ -12,8 +12,13 @@ import (
 	"github.com/pkg/errors"
 
 	// "bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/gocircuit/circuit/client"
 
@@ -25,14 +30,72 @@ func init() {
 		// server-specific
 		{
 			Name:      "stk",
-			Usage:     "Print the runtime stack trace of a server element",
+			Usage:     "Print the runtime stack trace of a server element, or save those of all servers",
 			Args:      true,
 			ArgsUsage: "anchor",
 			Action:    stack,
 			Flags: []cli.Flag{
 				&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
 				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
-				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing HMAC credentials. Use RC4 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+				&cli.BoolFlag{Name: "all", Usage: "save the stack trace of every server to a file named after the server"},
+				&cli.StringFlag{Name: "dir", Value: ".", Usage: "directory of the files saved with --all"},
+			},
+		},
+		{
+			Name:      "reload-keys",
+			Usage:     "Make a server element reread its keyring file or revocation list, or all servers with --all",
+			Args:      true,
+			ArgsUsage: "anchor",
+			Action:    reloadkeys,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+				&cli.BoolFlag{Name: "all", Usage: "reload the keys of every server"},
+			},
+		},
+		{
+			Name:      "audit",
+			Usage:     "Print the audit log of a server element as JSON lines, or of all servers with --all",
+			Args:      true,
+			ArgsUsage: "anchor",
+			Action:    audit,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+				&cli.StringFlag{Name: "since", Value: "24h", Usage: "print operations since this long ago, or since this RFC 3339 time"},
+				&cli.BoolFlag{Name: "all", Usage: "print the audit logs of every server"},
+			},
+		},
+		{
+			Name:      "runtime-stat",
+			Usage:     "Print the handles to values of a server element held by other workers as JSON, or of all servers with --all",
+			Args:      true,
+			ArgsUsage: "anchor",
+			Action:    runtimeStat,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+				&cli.BoolFlag{Name: "all", Usage: "print the handles of every server"},
 			},
 		},
 		{
@@ -44,7 +107,11 @@ func init() {
 			Flags: []cli.Flag{
 				&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
 				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
-				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing HMAC credentials. Use RC4 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
 			},
 		},
 		{
@@ -56,7 +123,11 @@ func init() {
 			Flags: []cli.Flag{
 				&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
 				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
-				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing HMAC credentials. Use RC4 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
 			},
 		},
 	}
@@ -71,9 +142,12 @@ func stack(x *cli.Context) (err error) {
 	}()
 
 	c := dial(x)
+	if x.Bool("all") {
+		return stackAll(c, x.String("dir"))
+	}
 	args := x.Args()
 	if args.Len() != 1 {
-		return errors.New("recv needs one anchor argument")
+		return errors.New("stk needs one anchor argument")
 	}
 
 	w, _ := parseGlob(args.First())
@@ -90,6 +164,229 @@ func stack(x *cli.Context) (err error) {
 	return
 }
 
+// stackAll saves the stack trace of each server in the circuit to dir/ID.stk
+func stackAll(c *client.Client, dir string) error {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		failed int
+	)
+	for id, a := range c.View() {
+		wg.Add(1)
+		go func(id string, a client.Anchor) {
+			defer wg.Done()
+			file := filepath.Join(dir, id+".stk")
+			err := saveStack(a, file)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "%s: %v\n", id, err)
+				return
+			}
+			fmt.Println(file)
+		}(id, a)
+	}
+	wg.Wait()
+	if failed > 0 {
+		return errors.Errorf("stack traces of %d servers not saved", failed)
+	}
+	return nil
+}
+
+func saveStack(a client.Anchor, file string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("server unreachable: %v", r)
+		}
+	}()
+	u, ok := a.Get().(client.Server)
+	if !ok {
+		return errors.New("not a server")
+	}
+	r, err := u.Profile("goroutine")
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// circuit reload-keys --all
+func reloadkeys(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+
+	c := dial(x)
+	if x.Bool("all") {
+		var failed int
+		for id, a := range c.View() {
+			if err := reloadServerKeys(a); err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "%s: %v\n", id, err)
+			}
+		}
+		if failed > 0 {
+			return errors.Errorf("keys of %d servers not reloaded", failed)
+		}
+		return nil
+	}
+	args := x.Args()
+	if args.Len() != 1 {
+		return errors.New("reload-keys needs one server anchor argument")
+	}
+	w, _ := parseGlob(args.First())
+	return reloadServerKeys(c.Walk(w))
+}
+
+func reloadServerKeys(a client.Anchor) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("server unreachable: %v", r)
+		}
+	}()
+	u, ok := a.Get().(client.Server)
+	if !ok {
+		return errors.New("not a server")
+	}
+	return u.ReloadKeys()
+}
+
+// circuit audit --since 1h --all
+func audit(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+
+	since, err := parseSince(x.String("since"))
+	if err != nil {
+		return err
+	}
+	c := dial(x)
+	enc := json.NewEncoder(os.Stdout)
+	if x.Bool("all") {
+		var failed int
+		for id, a := range c.View() {
+			if err := printAudit(enc, a, since); err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "%s: %v\n", id, err)
+			}
+		}
+		if failed > 0 {
+			return errors.Errorf("audit logs of %d servers not read", failed)
+		}
+		return nil
+	}
+	args := x.Args()
+	if args.Len() != 1 {
+		return errors.New("audit needs one server anchor argument")
+	}
+	w, _ := parseGlob(args.First())
+	return printAudit(enc, c.Walk(w), since)
+}
+
+// parseSince parses a duration before now, or an RFC 3339 time
+func parseSince(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.Errorf("since %q is neither a duration nor an RFC 3339 time", s)
+	}
+	return t, nil
+}
+
+func printAudit(enc *json.Encoder, a client.Anchor, since time.Time) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("server unreachable: %v", r)
+		}
+	}()
+	u, ok := a.Get().(client.Server)
+	if !ok {
+		return errors.New("not a server")
+	}
+	recs, err := u.Audit(since)
+	if err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		if err = enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// circuit runtime-stat --all
+func runtimeStat(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+
+	c := dial(x)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	if x.Bool("all") {
+		var failed int
+		for id, a := range c.View() {
+			if err := printRuntimeStat(enc, a); err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "%s: %v\n", id, err)
+			}
+		}
+		if failed > 0 {
+			return errors.Errorf("handles of %d servers not read", failed)
+		}
+		return nil
+	}
+	args := x.Args()
+	if args.Len() != 1 {
+		return errors.New("runtime-stat needs one server anchor argument")
+	}
+	w, _ := parseGlob(args.First())
+	return printRuntimeStat(enc, c.Walk(w))
+}
+
+func printRuntimeStat(enc *json.Encoder, a client.Anchor) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("server unreachable: %v", r)
+		}
+	}()
+	u, ok := a.Get().(client.Server)
+	if !ok {
+		return errors.New("not a server")
+	}
+	stat, err := u.RuntimeStat()
+	if err != nil {
+		return err
+	}
+	for _, imp := range stat.Importers {
+		if imp.Orphan {
+			fmt.Fprintf(os.Stderr, "%s: %d handles held by %s, which is no longer watched for liveness\n",
+				stat.Addr, len(imp.Handles), imp.Addr)
+		}
+	}
+	return enc.Encode(stat)
+}
+
 func suicide(x *cli.Context) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
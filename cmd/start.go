@@ -13,11 +13,20 @@ import (
 	"log"
 	"net"
 	"os"
+	"os/signal"
 	"path"
+	"strings"
+	"syscall"
 
+	"github.com/gocircuit/circuit/anchor"
 	"github.com/gocircuit/circuit/element/docker"
 	p "github.com/gocircuit/circuit/element/podman"
+	srv "github.com/gocircuit/circuit/element/server"
+	"github.com/gocircuit/circuit/element/wasm"
 	"github.com/gocircuit/circuit/kit/assemble"
+	"github.com/gocircuit/circuit/kit/tele/aead"
+	teletls "github.com/gocircuit/circuit/kit/tele/tls"
+	"github.com/gocircuit/circuit/sys/lang/prof"
 	"github.com/gocircuit/circuit/tissue"
 	"github.com/gocircuit/circuit/tissue/locus"
 	"github.com/gocircuit/circuit/use/circuit"
@@ -34,11 +43,23 @@ func init() {
 			Name:  "start",
 			Usage: "Run a circuit worker on this machine",
 			Before: func(c *cli.Context) error {
-				if cmd, err := p.ResolvePodman(); err != nil {
+				if c.IsSet("podman-socket") {
+					sock := c.String("podman-socket")
+					if sock == "" {
+						sock = p.DefaultSocket()
+					}
+					be, err := p.NewLibpod(sock)
+					if err != nil {
+						return errors.Wrapf(err, "cannot use podman socket %s: %v", sock, err)
+					}
+					p.Use(be)
+					log.Printf("Enabling podman elements, using libpod API at %s", sock)
+				} else if cmd, err := p.ResolvePodman(); err != nil {
 					return errors.Wrapf(err, "cannot use podman: %v", err)
 				} else {
 					log.Printf("Enabling podman elements, using %s", cmd)
 				}
+				srv.Advertise("podman")
 
 				if c.Bool("docker") {
 					cmd, e := docker.Init()
@@ -46,6 +67,24 @@ func init() {
 						return errors.Wrapf(e, "cannot use docker: %v", e)
 					}
 					log.Printf("Enabling docker elements, using %s", cmd)
+					srv.Advertise("docker")
+				}
+
+				if c.IsSet("wasm-runtime") {
+					if err := wasm.UseRuntime(c.String("wasm-runtime")); err != nil {
+						return errors.Wrapf(err, "cannot use wasm runtime: %v", err)
+					}
+				}
+				srv.Advertise("wasm")
+
+				srv.UseVersion(c.App.Version)
+
+				for _, l := range c.StringSlice("label") {
+					k, v, ok := strings.Cut(l, "=")
+					if !ok || k == "" {
+						return errors.Errorf("label %q is not of the form key=value", l)
+					}
+					srv.Label(k, v)
 				}
 
 				return nil
@@ -56,9 +95,20 @@ func init() {
 				&cli.StringFlag{Name: "if", Value: "", Usage: "Bind any available port on the specified interface."},
 				&cli.StringFlag{Name: "var", Value: "", Usage: "Lock and log directory for the circuit server."},
 				&cli.StringFlag{Name: "join", Aliases: []string{"j"}, Value: "", Usage: "Join a circuit through a current member by address."},
-				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File with HMAC credentials for HMAC/RC4 transport security.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File with the shared key for AES-GCM or ChaCha20-Poly1305 transport security.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "tls-revoked", Value: "", Usage: "File listing the serial numbers of revoked certificates, reread on SIGHUP", EnvVars: []string{"CIRCUIT_TLS_REVOKED"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this server to peers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+				&cli.StringFlag{Name: "policy", Value: "", Usage: "File containing the access policy of remote callers to anchors", EnvVars: []string{"CIRCUIT_POLICY"}},
+				&cli.StringFlag{Name: "trace", Value: "", Usage: "File or OTLP collector URL, e.g. http://localhost:4318/v1/traces, to export traces of cross-server calls to", EnvVars: []string{"CIRCUIT_TRACE"}},
+				&cli.StringFlag{Name: "metrics", Value: "", Usage: "Address, e.g. :9100, to serve Prometheus metrics of this server on", EnvVars: []string{"CIRCUIT_METRICS"}},
 				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
 				&cli.BoolFlag{Name: "docker", Usage: "Enable docker elements; docker command must be executable"},
+				&cli.StringFlag{Name: "podman-socket", Value: "", Usage: "Drive podman elements through the libpod REST API on this unix socket instead of the podman command", EnvVars: []string{"CIRCUIT_PODMAN_SOCKET"}},
+				&cli.StringFlag{Name: "wasm-runtime", Value: "wazero", Usage: "Default runtime of wasm elements, wazero or wasmedge when built with the wasmedge tag", EnvVars: []string{"CIRCUIT_WASM_RUNTIME"}},
+				&cli.StringSliceFlag{Name: "label", Aliases: []string{"l"}, Usage: "Label the server with key=value, for placement by circuit run --where"},
 			},
 		},
 	}
@@ -91,12 +141,59 @@ func server(c *cli.Context) (err error) {
 	}
 
 	// start circuit runtime
-	addr := load(tcpaddr, varDir, readkey(c))
+	sec := readsec(c)
+	var revoked *teletls.Revoked
+	if c.IsSet("tls-revoked") {
+		if sec.TLS == nil {
+			return errors.New("a revocation list needs mutual tls")
+		}
+		if revoked, err = teletls.LoadRevoked(c.String("tls-revoked")); err != nil {
+			return errors.Wrapf(err, "revocation list does not load (%s)", err)
+		}
+		sec.TLS = teletls.WithRevoked(sec.TLS, revoked)
+		log.Printf("Refusing %d revoked certificates listed in %s", revoked.Len(), c.String("tls-revoked"))
+	}
+	rt := load(tcpaddr, varDir, sec)
+	addr := rt.ServerAddr()
+	switch {
+	case sec.Keys != nil:
+		reloadKeys(c.String("hmac"), sec.Keys)
+	case revoked != nil:
+		reloadRevoked(c.String("tls-revoked"), revoked)
+	}
+	if c.IsSet("policy") {
+		policy, err := anchor.LoadPolicy(c.String("policy"))
+		if err != nil {
+			return errors.Wrapf(err, "access policy does not load (%s)", err)
+		}
+		anchor.UsePolicy(policy)
+		log.Printf("Enforcing access policy %s on remote callers", c.String("policy"))
+	}
 
 	// tissue + locus
 	kin, xkin, rip := tissue.NewKin()
 	xlocus := locus.NewLocus(kin, rip)
 
+	if c.IsSet("trace") {
+		x, err := prof.OpenTraceExporter(map[string]string{
+			"service.name":        "circuit",
+			"service.instance.id": kin.Avatar().ID.String(),
+			"circuit.addr":        addr.String(),
+		}, c.String("trace"))
+		if err != nil {
+			return errors.Wrapf(err, "trace exporter does not open (%s)", err)
+		}
+		rt.SetTracer(prof.NewTracer(x))
+		log.Printf("Exporting traces of cross-server calls to %s", c.String("trace"))
+	}
+	if c.IsSet("metrics") {
+		maddr, err := serveMetrics(c.String("metrics"), rt, kin, locus.LocusOf(xlocus))
+		if err != nil {
+			return errors.Wrapf(err, "metrics listener does not start (%s)", err)
+		}
+		log.Printf("Serving metrics on http://%s/metrics", maddr)
+	}
+
 	// joining
 	switch {
 	case join != nil:
@@ -119,6 +216,44 @@ func server(c *cli.Context) (err error) {
 	//return nil
 }
 
+// reloadKeys reloads the keyring from file on SIGHUP or on request of the server element
+func reloadKeys(file string, keys *aead.Keyring) {
+	srv.UseKeyReload(func() error {
+		k, err := loadKeys(file)
+		if err != nil {
+			return err
+		}
+		keys.Set(k...)
+		log.Printf("Reloaded %d keys from %s", len(k), file)
+		return nil
+	})
+	reloadOnHangup()
+}
+
+// reloadRevoked rereads the revocation list from file on SIGHUP or on request of the server element
+func reloadRevoked(file string, revoked *teletls.Revoked) {
+	srv.UseKeyReload(func() error {
+		if err := revoked.Reload(); err != nil {
+			return err
+		}
+		log.Printf("Reloaded %d revoked certificates from %s", revoked.Len(), file)
+		return nil
+	})
+	reloadOnHangup()
+}
+
+func reloadOnHangup() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	go func() {
+		for range ch {
+			if err := srv.ReloadKeys(); err != nil {
+				log.Printf("Keys not reloaded: %v", err)
+			}
+		}
+	}()
+}
+
 func parseDiscover(c *cli.Context) *net.UDPAddr {
 	src := c.String("discover")
 	if src == "" {
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gocircuit/circuit/client"
+	srv "github.com/gocircuit/circuit/client/server"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	cmds := []*cli.Command{
+		{
+			Name:   "top",
+			Usage:  "Show a live table of the resource usage of every circuit server",
+			Action: top,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+				&cli.DurationFlag{Name: "interval", Aliases: []string{"n"}, Value: 2 * time.Second, Usage: "time between refreshes"},
+				&cli.IntFlag{Name: "count", Aliases: []string{"c"}, Usage: "exit after this many refreshes, 0 refreshes until interrupted"},
+			},
+		},
+	}
+
+	RegisterCommand(cmds...)
+}
+
+// serverTop is the sample of a server, or the reason there is none
+type serverTop struct {
+	id  string
+	top srv.ServerTop
+	err error
+}
+
+// circuit top -n 5s
+func top(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	c := dial(x)
+	prev := make(map[string]srv.ServerTop)
+	for i := 1; ; i++ {
+		tops := sampleTops(c)
+		// clear the terminal
+		fmt.Print("\033[H\033[2J")
+		printTops(os.Stdout, tops, prev)
+		prev = make(map[string]srv.ServerTop)
+		for _, t := range tops {
+			if t.err == nil {
+				prev[t.id] = t.top
+			}
+		}
+		if n := x.Int("count"); n > 0 && i >= n {
+			return
+		}
+		time.Sleep(x.Duration("interval"))
+	}
+}
+
+// sampleTops samples every server in the circuit, in the order of their IDs
+func sampleTops(c *client.Client) []serverTop {
+	var (
+		view = c.View()
+		tops = make([]serverTop, 0, len(view))
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+	)
+	for id, a := range view {
+		wg.Add(1)
+		go func(id string, a client.Anchor) {
+			defer wg.Done()
+			t := serverTop{id: id}
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.err = fmt.Errorf("unreachable: %v", r)
+					}
+				}()
+				s, ok := a.Get().(client.Server)
+				if !ok {
+					t.err = errors.New("not a server")
+					return
+				}
+				t.top = s.Top()
+			}()
+			mu.Lock()
+			tops = append(tops, t)
+			mu.Unlock()
+		}(id, a)
+	}
+	wg.Wait()
+	sort.Slice(tops, func(i, j int) bool { return tops[i].id < tops[j].id })
+	return tops
+}
+
+// printTops writes the table of samples. CPU usage is computed against the
+// previous samples, and is blank on the first refresh.
+func printTops(w io.Writer, tops []serverTop, prev map[string]srv.ServerTop) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVER\tADDR\tCPU%\tMEM\tLOAD\tGOROUTINES\tELEMENTS")
+	for _, t := range tops {
+		if t.err != nil {
+			fmt.Fprintf(tw, "%s\t%v\t\t\t\t\t\n", t.id, t.err)
+			continue
+		}
+		cpu := "-"
+		if p, ok := prev[t.id]; ok {
+			if dt := t.top.Time.Sub(p.Time); dt > 0 {
+				cpu = fmt.Sprintf("%.1f", 100*float64(t.top.CPUTime-p.CPUTime)/float64(dt))
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.2f %.2f %.2f\t%d\t%s\n",
+			t.id, t.top.Addr, cpu, formatBytes(t.top.Mem),
+			t.top.Load[0], t.top.Load[1], t.top.Load[2],
+			t.top.Goroutines, formatElements(t.top.Elements))
+	}
+	tw.Flush()
+}
+
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	d, e := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		d *= unit
+		e++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(b)/float64(d), "KMGTPE"[e])
+}
+
+// formatElements lists element counts as kind=count, by kind
+func formatElements(m map[string]int) string {
+	kinds := make([]string, 0, len(m))
+	for k := range m {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	for i, k := range kinds {
+		kinds[i] = fmt.Sprintf("%s=%d", k, m[k])
+	}
+	return strings.Join(kinds, ",")
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	srv "github.com/gocircuit/circuit/client/server"
+)
+
+func TestPrintTops(t *testing.T) {
+	now := time.Now()
+	prev := map[string]srv.ServerTop{
+		"X1": {Time: now.Add(-2 * time.Second), CPUTime: time.Second},
+	}
+	tops := []serverTop{
+		{id: "X1", top: srv.ServerTop{
+			Addr:       "circuit://127.0.0.1:1/1/X1",
+			Time:       now,
+			CPUTime:    2 * time.Second,
+			Mem:        3 << 20,
+			Load:       [3]float64{0.5, 0.25, 0.125},
+			Goroutines: 12,
+			Elements:   map[string]int{"proc": 2, "chan": 1},
+		}},
+		{id: "X2", err: errors.New("unreachable")},
+	}
+	var b bytes.Buffer
+	printTops(&b, tops, prev)
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("table %q", b.String())
+	}
+	for _, s := range []string{"50.0", "3.0MiB", "0.50 0.25 0.12", "12", "chan=1,proc=2"} {
+		if !strings.Contains(lines[1], s) {
+			t.Fatalf("row %q misses %q", lines[1], s)
+		}
+	}
+	if !strings.Contains(lines[2], "unreachable") {
+		t.Fatalf("row %q", lines[2])
+	}
+}
@@ -8,7 +8,7 @@
 package cmd
 
 import (
-	"encoding/base64"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -17,6 +17,9 @@ import (
 	"strings"
 
 	"github.com/gocircuit/circuit/client"
+	"github.com/gocircuit/circuit/kit/tele/aead"
+	teletls "github.com/gocircuit/circuit/kit/tele/tls"
+	"github.com/gocircuit/circuit/use/n"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 )
@@ -26,20 +29,62 @@ func fatalf(format string, arg ...interface{}) {
 	os.Exit(1)
 }
 
-func readkey(x *cli.Context) (key []byte) {
-	var hmac string
-	if hmac = x.String("hmac"); !x.IsSet("hmac") {
+// readkeys returns the keys in the keyring file given by the hmac flag, or nil if it is not set
+func readkeys(x *cli.Context) [][]byte {
+	if !x.IsSet("hmac") {
 		return nil
 	}
+	keys, err := loadKeys(x.String("hmac"))
+	if err != nil {
+		fatalf("%v", err)
+	}
+	return keys
+}
 
-	b64, err := os.ReadFile(hmac)
+// loadKeys reads a keyring file, holding base64 keys one per line, the current key first
+func loadKeys(file string) ([][]byte, error) {
+	text, err := os.ReadFile(file)
 	if err != nil {
-		fatalf("problem reading private key file (%s): %v", hmac, err)
+		return nil, fmt.Errorf("problem reading private key file (%s): %v", file, err)
 	}
-	if key, err = base64.StdEncoding.DecodeString(string(b64)); err != nil {
-		fatalf("problem decoding base64 private key: %v", err)
+	keys, err := aead.ParseKeyring(text)
+	if err != nil {
+		return nil, fmt.Errorf("problem decoding base64 private keys in %s: %v", file, err)
 	}
-	return
+	return keys, nil
+}
+
+// readtls returns the mutual TLS configuration given by the tls flags, or nil if none are set
+func readtls(x *cli.Context) *tls.Config {
+	cert, key, ca := x.String("tls-cert"), x.String("tls-key"), x.String("tls-ca")
+	if cert == "" && key == "" && ca == "" {
+		return nil
+	}
+	if cert == "" || key == "" || ca == "" {
+		fatalf("mutual tls needs all of -tls-cert, -tls-key and -tls-ca")
+	}
+	config, err := teletls.LoadConfig(cert, key, ca)
+	if err != nil {
+		fatalf("problem loading tls certificates: %v", err)
+	}
+	return config
+}
+
+// readsec returns the transport security given by the hmac or tls flags,
+// and the access token given by the token flag
+func readsec(x *cli.Context) n.Security {
+	sec := n.Security{TLS: readtls(x), Token: x.String("token")}
+	if keys := readkeys(x); keys != nil {
+		if sec.TLS != nil {
+			fatalf("use either hmac or tls security, not both")
+		}
+		sec.Keys = aead.NewKeyring(keys...)
+	}
+	return sec
+}
+
+func dialAddr(x *cli.Context, addr string) *client.Client {
+	return client.DialSecure(addr, readsec(x))
 }
 
 func dial(x *cli.Context) *client.Client {
@@ -50,7 +95,7 @@ func dial(x *cli.Context) *client.Client {
 				fatalf("addressed server is gone or authentication failed")
 			}
 		}()
-		return client.Dial(x.String("dial"), readkey(x))
+		return dialAddr(x, x.String("dial"))
 
 	case os.Getenv("CIRCUIT") != "":
 		//buf, err := ioutil.ReadFile(os.Getenv("CIRCUIT"))
@@ -64,7 +109,7 @@ func dial(x *cli.Context) *client.Client {
 			}
 		}()
 		log.Printf("dial string from env: %s", string(buf))
-		return client.Dial(strings.TrimSpace(string(buf)), readkey(x))
+		return dialAddr(x, strings.TrimSpace(string(buf)))
 
 	case x.String("discover") != "":
 		// try multicast as a last
@@ -73,7 +118,7 @@ func dial(x *cli.Context) *client.Client {
 				fatalf("multicast address is unresponsive or authentication failed")
 			}
 		}()
-		return client.DialDiscover(x.String("discover"), readkey(x))
+		return client.DialDiscoverSecure(x.String("discover"), readsec(x))
 
 	}
 	fatalf("no dial or discovery addresses available; use -dial or -discover")
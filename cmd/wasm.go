@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gocircuit/circuit/client/makers"
+	ws "github.com/gocircuit/circuit/client/wasm"
+	"github.com/gocircuit/circuit/element/wasm"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	cmds := []*cli.Command{
+		{
+			Name:      "mkwasm",
+			Usage:     "Create a wasm element running a WebAssembly module",
+			Args:      true,
+			ArgsUsage: "anchor",
+			Action:    mkwasm,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+				&cli.StringFlag{Name: "module", Aliases: []string{"m"}, Usage: "local module file to upload with the request"},
+				&cli.BoolFlag{Name: "scrub", Usage: "scrub the wasm anchor automatically on exit"},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+			},
+		},
+		{
+			Name:      "invoke",
+			Usage:     "Call an exported function of a wasm element in function mode",
+			Args:      true,
+			ArgsUsage: "anchor function [args...]",
+			Action:    invoke,
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "dial", Aliases: []string{"d"}, Value: "", Usage: "circuit member to dial into"},
+				&cli.StringFlag{Name: "discover", Value: "228.8.8.8:8822", Usage: "Multicast address for peer server discovery", EnvVars: []string{"CIRCUIT_DISCOVER"}},
+				&cli.StringFlag{Name: "hmac", Value: "", Usage: "File containing the shared key. Use AES-GCM or ChaCha20-Poly1305 encryption.", EnvVars: []string{"CIRCUIT_HMAC"}},
+				&cli.StringFlag{Name: "tls-cert", Value: "", Usage: "File containing the TLS certificate of this endpoint. Use mutual TLS.", EnvVars: []string{"CIRCUIT_TLS_CERT"}},
+				&cli.StringFlag{Name: "tls-key", Value: "", Usage: "File containing the TLS private key of this endpoint", EnvVars: []string{"CIRCUIT_TLS_KEY"}},
+				&cli.StringFlag{Name: "tls-ca", Value: "", Usage: "File containing the certificate of the authority issuing circuit certificates", EnvVars: []string{"CIRCUIT_TLS_CA"}},
+				&cli.StringFlag{Name: "token", Value: "", Usage: "Access token identifying this client to servers enforcing an access policy", EnvVars: []string{"CIRCUIT_TOKEN"}},
+			},
+		},
+		{
+			// run by the wasm element in a child process of the server
+			Name:   wasm.ExecCommand,
+			Hidden: true,
+			Action: func(*cli.Context) error {
+				os.Exit(wasm.Exec())
+				return nil
+			},
+		},
+	}
+
+	RegisterCommand(cmds...)
+}
+
+// circuit mkwasm /X1234/hola/charlie << EOF
+// { "Path": "/opt/hello.wasm", "WasiConf": { "Enable": true, "Args": ["world"] } }
+// EOF
+func mkwasm(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	c := dial(x)
+	args := x.Args()
+	if args.Len() != 1 {
+		return errors.New("mkwasm needs an anchor argument")
+	}
+	w, _ := parseGlob(args.First())
+	buf, _ := io.ReadAll(os.Stdin)
+	var exe ws.Execute
+	if err = json.Unmarshal(buf, &exe); err != nil {
+		return errors.Wrapf(err, "execute json not parsing: %v", err)
+	}
+	if m := x.String("module"); m != "" {
+		if exe.Module, err = os.ReadFile(m); err != nil {
+			return errors.Wrapf(err, "reading module: %v", err)
+		}
+	}
+	if x.Bool("scrub") {
+		exe.Scrub = true
+	}
+	if _, err = c.Walk(w).Make(makers.WasmType, exe); err != nil {
+		return errors.Wrapf(err, "mkwasm error: %s", err)
+	}
+	return
+}
+
+// circuit invoke /X1234/hola/add add 1 2
+func invoke(x *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Wrapf(r.(error), "error, likely due to missing server or misspelled anchor: %v", r)
+		}
+	}()
+	c := dial(x)
+	args := x.Args()
+	if args.Len() < 2 {
+		return errors.New("invoke needs an anchor and a function name arguments")
+	}
+	w, _ := parseGlob(args.First())
+	u, ok := c.Walk(w).Get().(ws.Wasm)
+	if !ok {
+		return errors.New("anchor is not a wasm element")
+	}
+	var a []any
+	for _, s := range args.Slice()[2:] {
+		a = append(a, s)
+	}
+	r, err := u.Invoke(args.Get(1), a...)
+	if err != nil {
+		return errors.Wrapf(err, "invoke error: %v", err)
+	}
+	buf, _ := json.Marshal(r)
+	fmt.Println(string(buf))
+	return
+}
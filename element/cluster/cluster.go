@@ -1,149 +1,535 @@
 package cluster
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gocircuit/circuit/anchor"
+	"github.com/gocircuit/circuit/client"
 	cl "github.com/gocircuit/circuit/client/cluster"
+	srv "github.com/gocircuit/circuit/client/server"
+	"github.com/gocircuit/circuit/element/server"
+	"github.com/gocircuit/circuit/tissue/locus"
 	"github.com/gocircuit/circuit/use/circuit"
+	"github.com/gocircuit/circuit/use/n"
 )
 
+// LaunchTimeout bounds the wait for a launched server to report its address
+var LaunchTimeout = 30 * time.Second
+
+// StopTimeout bounds the wait for a terminated server to exit, before it is killed
+var StopTimeout = 10 * time.Second
+
 type Cluster interface {
 	cl.Cluster
+	PeekBytes() []byte
 	X() circuit.X
 }
 
 type cluster struct {
-	config *cl.ClusterConfig
-
-	// circuit host address
-	circuits []string
+	sync.Mutex
+	name  string
+	join  string
+	hosts map[string]*host
+	// host names in the order they were added
+	order []string
+}
 
-	//
+// host tracks the server of a host. A new proc and exit channel are made on
+// every start.
+type host struct {
+	info   cl.HostInfo
+	proc   client.Proc
+	exit   chan struct{}
+	stop   bool   // stop was requested
+	stderr string // last line of standard error
 }
 
 func init() {
-	anchor.RegisterElement("cluster", ef, yf)
+	anchor.RegisterElement(anchor.Cluster, ef, yf)
 }
 
 func createCluster(config *cl.ClusterConfig) (_ Cluster, err error) {
 	c := &cluster{
-		config:   config,
-		circuits: []string{},
+		name:  config.Name,
+		join:  config.Join,
+		hosts: make(map[string]*host),
+	}
+	if err = c.CreateCluster(config); err != nil {
+		c.Shutdown()
+		return nil, err
 	}
-	// create and start circuit daemons on each of the host
-	var wg sync.WaitGroup
-	wg.Add(len(config.Hosts))
-	// for _, h := range config.Hosts {
-	// 	// config circuit process and start it
-	// 	// first locate ciricuit
-	// 	exe, err := exec.LookPath("circuit")
-	// 	if err != nil {
-	// 		return nil, errors.Wrapf(err, "circuit daemon not found")
-	// 	}
-
-	// 	cmd := proc.Cmd{
-	// 		Path: exe,
-	// 		Args: "",
-	// 	}
-
-	// }
-
 	return c, nil
 }
 
-// AddHost adds a new host to the cluster
+// AddHost adds a new host to the cluster and starts its server
 func (c *cluster) AddHost(hc *cl.HostConfig) error {
+	if err := c.add(hc); err != nil {
+		return err
+	}
+	return c.Start(hc.Name)
+}
+
+func (c *cluster) add(hc *cl.HostConfig) error {
+	if hc.Name == "" {
+		return errors.New("cluster host name missing")
+	}
+	c.Lock()
+	defer c.Unlock()
+	if _, ok := c.hosts[hc.Name]; ok {
+		return fmt.Errorf("cluster host %s exists", hc.Name)
+	}
+	c.hosts[hc.Name] = &host{info: cl.HostInfo{Config: *hc, State: cl.HostStopped}}
+	c.order = append(c.order, hc.Name)
 	return nil
 }
 
 // Clone clones the cluster
 func (c *cluster) Clone() error {
-	return nil
+	return errors.New("cluster cloning not supported")
 }
 
-func (c *cluster) CreateCluster(*cl.ClusterConfig) error {
-	return nil
+// CreateCluster adds the hosts of config to the cluster and starts them. The
+// first host is started alone, so that the others can join the circuit
+// through it.
+func (c *cluster) CreateCluster(config *cl.ClusterConfig) error {
+	for i := range config.Hosts {
+		if err := c.add(&config.Hosts[i]); err != nil {
+			return err
+		}
+	}
+	if len(config.Hosts) == 0 {
+		return nil
+	}
+	if err := c.Start(config.Hosts[0].Name); err != nil {
+		return err
+	}
+	errs := make([]error, len(config.Hosts))
+	var wg sync.WaitGroup
+	for i := 1; i < len(config.Hosts); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = c.Start(config.Hosts[i].Name)
+		}(i)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
 }
 
 // Exists checks if host exist in cluster
 func (c *cluster) Exists(hostName string) bool {
-	return false
+	c.Lock()
+	defer c.Unlock()
+	_, ok := c.hosts[hostName]
+	return ok
 }
 
 // List the hosts in the cluster
 func (c *cluster) Hosts() []string {
-	return nil
+	c.Lock()
+	defer c.Unlock()
+	return append([]string(nil), c.order...)
 }
 
 // Inspect the cluster configuration
 func (c *cluster) Inspect() (*cl.InspectClusterConfig, error) {
-	return nil, nil
+	c.Lock()
+	defer c.Unlock()
+	r := &cl.InspectClusterConfig{Name: c.name, Join: c.join}
+	for _, name := range c.order {
+		r.Hosts = append(r.Hosts, c.hosts[name].info)
+	}
+	return r, nil
+}
+
+func (c *cluster) PeekBytes() []byte {
+	r, _ := c.Inspect()
+	b, _ := json.MarshalIndent(r, "", "\t")
+	return b
 }
 
 func (c *cluster) InspectHost(hostName string) (*cl.HostInfo, error) {
-	return nil, nil
+	c.Lock()
+	defer c.Unlock()
+	h, ok := c.hosts[hostName]
+	if !ok {
+		return nil, fmt.Errorf("cluster host %s not found", hostName)
+	}
+	info := h.info
+	return &info, nil
 }
 
-// Join merges the networks of this cluster with argument cluster
+// Join merges the circuit of this cluster with the circuit of the member at
+// address cluster. Hosts started later join through it, when no host runs.
 func (c *cluster) Join(cluster string) error {
-	return nil
+	if _, err := n.ParseAddr(cluster); err != nil {
+		return err
+	}
+	c.Lock()
+	c.join = cluster
+	var addrs []string
+	for _, name := range c.order {
+		if h := c.hosts[name]; h.info.State == cl.HostRunning {
+			addrs = append(addrs, h.info.Addr)
+		}
+	}
+	c.Unlock()
+	if len(addrs) == 0 {
+		return nil
+	}
+	// Joining one member merges the circuits
+	return rejoin(addrs[0], cluster)
+}
+
+// rejoin asks the server at addr to join the circuit through join
+func rejoin(addr, join string) error {
+	a, err := n.ParseAddr(addr)
+	if err != nil {
+		return err
+	}
+	// Servers print their address before they listen, so a server which has
+	// just started may not answer yet
+	var srv server.YServer
+	for i := 0; ; i++ {
+		if srv, err = dialServer(a); err == nil || i == 4 {
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	if err != nil {
+		return err
+	}
+	return srv.Rejoin(join)
+}
+
+// dialServer returns the server element of the server at addr
+func dialServer(addr n.Addr) (_ server.YServer, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("server %s unreachable: %v", addr, r)
+		}
+	}()
+	self := locus.YLocus{X: circuit.Dial(addr, "locus")}.Self()
+	_, y := anchor.YTerminal{X: self.Term}.Get()
+	srv, ok := y.(server.YServer)
+	if !ok {
+		return server.YServer{}, fmt.Errorf("server %s has no server element", addr)
+	}
+	return srv, nil
 }
 
 // RemoveHost terminates and removes the specified host in the cluster
 func (c *cluster) RemoveHost(hostName string) error {
+	if err := c.Stop(hostName); err != nil && !errors.Is(err, errNotRunning) {
+		return err
+	}
+	c.Lock()
+	defer c.Unlock()
+	delete(c.hosts, hostName)
+	for i, name := range c.order {
+		if name == hostName {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
 	return nil
 }
 
 // ResolveHost
 func (c *cluster) ResolveHost(hostName string) (*cl.HostConfig, error) {
-	return nil, nil
+	c.Lock()
+	defer c.Unlock()
+	h, ok := c.hosts[hostName]
+	if !ok {
+		return nil, fmt.Errorf("cluster host %s not found", hostName)
+	}
+	hc := h.info.Config
+	return &hc, nil
 }
 
 // Scrub shutdown and remove the cluster element
 func (c *cluster) Scrub() {
-
+	c.Shutdown()
 }
 
 // Shutdown the entire cluster, stops all hosts
 func (c *cluster) Shutdown() error {
-	return nil
+	names := c.Hosts()
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			if err := c.Stop(name); !errors.Is(err, errNotRunning) {
+				errs[i] = err
+			}
+		}(i, name)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
 }
 
 // Signal all the hosts in the cluster
 func (c *cluster) Signal(sig string) error {
-	return nil
+	c.Lock()
+	var procs []client.Proc
+	for _, h := range c.hosts {
+		if h.proc != nil && !closed(h.exit) {
+			procs = append(procs, h.proc)
+		}
+	}
+	c.Unlock()
+	var errs []error
+	for _, p := range procs {
+		errs = append(errs, p.Signal(sig))
+	}
+	return errors.Join(errs...)
 }
 
-// Stack provides the runtime statck trace for the requested server at host
-func (c *cluster) Stack(hostName string) {
+// Stack returns the runtime stack trace of the server at host
+func (c *cluster) Stack(hostName string) (_ string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cluster host %s unreachable: %v", hostName, r)
+		}
+	}()
+	srv, err := c.hostServer(hostName)
+	if err != nil {
+		return "", err
+	}
+	r, err := srv.Profile("goroutine")
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	return string(b), err
+}
 
+// hostServer returns the server element of a running host
+func (c *cluster) hostServer(hostName string) (server.YServer, error) {
+	c.Lock()
+	h, ok := c.hosts[hostName]
+	if !ok {
+		c.Unlock()
+		return server.YServer{}, fmt.Errorf("cluster host %s not found", hostName)
+	}
+	state, addr := h.info.State, h.info.Addr
+	c.Unlock()
+	if state != cl.HostRunning {
+		return server.YServer{}, fmt.Errorf("cluster host %s is %s", hostName, state)
+	}
+	a, err := n.ParseAddr(addr)
+	if err != nil {
+		return server.YServer{}, err
+	}
+	return dialServer(a)
 }
 
-// Start a stopped host in the cluster
+var errNotRunning = errors.New("cluster host not running")
+
+// Start a stopped host in the cluster. Start returns once the server has
+// reported its address.
 func (c *cluster) Start(hostName string) error {
-	return nil
+	c.Lock()
+	h, ok := c.hosts[hostName]
+	if !ok {
+		c.Unlock()
+		return fmt.Errorf("cluster host %s not found", hostName)
+	}
+	if h.proc != nil && !closed(h.exit) {
+		c.Unlock()
+		return fmt.Errorf("cluster host %s is %s", hostName, h.info.State)
+	}
+	hc, join := h.info.Config, c.joinAddr()
+	l, err := lookupLauncher(hc.Launcher)
+	if err != nil {
+		c.Unlock()
+		return err
+	}
+	p, err := l.Launch(&hc, join)
+	if err != nil {
+		h.info = cl.HostInfo{Config: hc, State: cl.HostFailed, Err: err.Error()}
+		c.Unlock()
+		return err
+	}
+	// Servers read nothing, and the process is not reaped while its standard
+	// input is open
+	p.Stdin().Close()
+	exit, addr := make(chan struct{}), make(chan string, 1)
+	h.proc, h.exit, h.stop, h.stderr = p, exit, false, ""
+	h.info = cl.HostInfo{Config: hc, State: cl.HostStarting, Started: time.Now()}
+	c.Unlock()
+
+	go readAddr(p.Stdout(), addr)
+	go c.drainStderr(h, p)
+	go c.wait(h, p, exit)
+
+	select {
+	case a := <-addr:
+		c.Lock()
+		defer c.Unlock()
+		if h.proc == p && h.info.State == cl.HostStarting {
+			h.info.State, h.info.Addr = cl.HostRunning, a
+		}
+		return nil
+	case <-exit:
+		info, _ := c.InspectHost(hostName)
+		if info == nil || info.Err == "" {
+			return fmt.Errorf("cluster host %s exited", hostName)
+		}
+		return fmt.Errorf("cluster host %s exited: %s", hostName, info.Err)
+	case <-time.After(LaunchTimeout):
+		p.Signal("KILL")
+		<-exit
+		return fmt.Errorf("cluster host %s did not report its address", hostName)
+	}
+}
+
+// joinAddr returns the address of a running host, or the join address of the
+// cluster when none runs
+func (c *cluster) joinAddr() string {
+	for _, name := range c.order {
+		if h := c.hosts[name]; h.info.State == cl.HostRunning {
+			return h.info.Addr
+		}
+	}
+	return c.join
+}
+
+// readAddr sends the first circuit address printed by a server on addr, and
+// consumes the rest of its output
+func readAddr(stdout io.Reader, addr chan<- string) {
+	s := bufio.NewScanner(stdout)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if addr == nil {
+			continue
+		}
+		if _, err := n.ParseAddr(line); err == nil {
+			addr <- line
+			addr = nil
+		}
+	}
 }
 
-// Stop stops the request server host in the cluster
+func (c *cluster) drainStderr(h *host, p client.Proc) {
+	s := bufio.NewScanner(p.Stderr())
+	for s.Scan() {
+		if line := strings.TrimSpace(s.Text()); line != "" {
+			c.Lock()
+			if h.proc == p {
+				h.stderr = line
+			}
+			c.Unlock()
+		}
+	}
+}
+
+// wait records the exit of the server process p of h
+func (c *cluster) wait(h *host, p client.Proc, exit chan struct{}) {
+	stat, err := p.Wait()
+	c.Lock()
+	defer c.Unlock()
+	defer close(exit)
+	if h.proc != p {
+		return
+	}
+	h.info.Stopped = time.Now()
+	if h.stop {
+		h.info.State = cl.HostStopped
+		return
+	}
+	h.info.State = cl.HostFailed
+	switch {
+	case err != nil:
+		h.info.Err = err.Error()
+	case stat.Exit != nil:
+		h.info.Err = stat.Exit.Error()
+	default:
+		h.info.Err = "server exited"
+	}
+	if h.stderr != "" {
+		h.info.Err += ": " + h.stderr
+	}
+}
+
+// Stop stops the request server host in the cluster. The server is
+// terminated, and killed if it does not exit within StopTimeout.
 func (c *cluster) Stop(hostName string) error {
+	c.Lock()
+	h, ok := c.hosts[hostName]
+	if !ok {
+		c.Unlock()
+		return fmt.Errorf("cluster host %s not found", hostName)
+	}
+	if h.proc == nil || closed(h.exit) {
+		c.Unlock()
+		return errNotRunning
+	}
+	h.stop = true
+	p, exit := h.proc, h.exit
+	c.Unlock()
+
+	if err := p.Signal("TERM"); err != nil {
+		return err
+	}
+	select {
+	case <-exit:
+		return nil
+	case <-time.After(StopTimeout):
+	}
+	if err := p.Signal("KILL"); err != nil {
+		return err
+	}
+	<-exit
 	return nil
 }
 
-// Top displays the running processes
-func (c *cluster) Top(hostName string) {
-
+// Top samples the resource usage of the server at host
+func (c *cluster) Top(hostName string) (_ *srv.ServerTop, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("cluster host %s unreachable: %v", hostName, r)
+		}
+	}()
+	y, err := c.hostServer(hostName)
+	if err != nil {
+		return nil, err
+	}
+	t := y.Top()
+	return &t, nil
 }
 
 func (c *cluster) X() circuit.X {
-	return circuit.Ref(XCluster{c})
+	return circuit.Ref(XCluster{c: c})
+}
+
+func (c *cluster) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XCluster{c: c, guard: g})
+}
+
+func closed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
 }
 
 func ef(t *anchor.Terminal, arg any) (anchor.Element, error) {
-	opts, ok := arg.(c.ClusterConfig)
+	opts, ok := arg.(cl.ClusterConfig)
 	if !ok {
-		return nil, fmt.Errorf("invalid argument to network element factory, arg=%T", arg)
+		return nil, fmt.Errorf("invalid argument to cluster element factory, arg=%T", arg)
 	}
 
 	n, err := createCluster(&opts)
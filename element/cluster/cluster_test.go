@@ -0,0 +1,218 @@
+package cluster
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocircuit/circuit/client"
+	cl "github.com/gocircuit/circuit/client/cluster"
+	"github.com/gocircuit/circuit/sys/lang"
+	_ "github.com/gocircuit/circuit/sys/tele"
+	"github.com/gocircuit/circuit/tissue"
+	"github.com/gocircuit/circuit/tissue/locus"
+	"github.com/gocircuit/circuit/use/circuit"
+	"github.com/gocircuit/circuit/use/n"
+)
+
+// The test binary stands in for the circuit executable of local hosts
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == "start" {
+		serve(os.Args[2:])
+	}
+	os.Exit(m.Run())
+}
+
+// serve runs a minimal circuit server, as circuit start does
+func serve(args []string) {
+	fs := flag.NewFlagSet("start", flag.ExitOnError)
+	addr := fs.String("addr", "", "")
+	join := fs.String("join", "", "")
+	fs.Parse(args)
+
+	a, err := net.ResolveTCPAddr("tcp", *addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	t := n.NewTransport(n.ChooseWorkerID(), a, n.Security{})
+	fmt.Println(t.Addr().String())
+	circuit.Bind(lang.New(t))
+
+	kin, xkin, rip := tissue.NewKin()
+	xlocus := locus.NewLocus(kin, rip)
+	if *join != "" {
+		j, err := n.ParseAddr(*join)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		kin.ReJoin(j)
+	}
+	circuit.Listen(tissue.ServiceName, xkin)
+	circuit.Listen("locus", xlocus)
+	select {}
+}
+
+func localHost(name string) cl.HostConfig {
+	return cl.HostConfig{Name: name, Circuit: os.Args[0]}
+}
+
+// waitPeers waits until the circuit of the member at addr has k servers
+func waitPeers(t *testing.T, addr string, k int) {
+	c := client.Dial(addr, nil)
+	var m int
+	for i := 0; i < 50; i++ {
+		if m = len(c.View()); m == k {
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	t.Fatalf("circuit at %s has %d servers, expected %d", addr, m, k)
+}
+
+func TestCluster(t *testing.T) {
+	c, err := createCluster(&cl.ClusterConfig{
+		Name:  "test",
+		Hosts: []cl.HostConfig{localHost("a"), localHost("b"), localHost("c")},
+	})
+	if err != nil {
+		t.Fatalf("create (%v)", err)
+	}
+	defer c.Shutdown()
+
+	if h := c.Hosts(); len(h) != 3 || h[0] != "a" || h[1] != "b" || h[2] != "c" {
+		t.Fatalf("hosts %v", h)
+	}
+	r, err := c.Inspect()
+	if err != nil {
+		t.Fatalf("inspect (%v)", err)
+	}
+	for _, h := range r.Hosts {
+		if h.State != cl.HostRunning || h.Addr == "" {
+			t.Fatalf("host %s is %s at %q", h.Config.Name, h.State, h.Addr)
+		}
+	}
+	waitPeers(t, r.Hosts[0].Addr, 3)
+
+	top, err := c.Top("a")
+	if err != nil || top.Goroutines == 0 || top.Addr != r.Hosts[0].Addr {
+		t.Fatalf("top %+v (%v)", top, err)
+	}
+	stk, err := c.Stack("a")
+	if err != nil || !strings.Contains(stk, "goroutine") {
+		t.Fatalf("stack %q (%v)", stk, err)
+	}
+
+	// Stop and restart
+	if err = c.Stop("b"); err != nil {
+		t.Fatalf("stop (%v)", err)
+	}
+	if h, _ := c.InspectHost("b"); h.State != cl.HostStopped || h.Stopped.IsZero() {
+		t.Fatalf("stopped host is %s", h.State)
+	}
+	if err = c.Stop("b"); err == nil {
+		t.Fatalf("stopping a stopped host succeeded")
+	}
+	if _, err = c.Top("b"); err == nil {
+		t.Fatalf("top of a stopped host succeeded")
+	}
+	if err = c.Start("b"); err != nil {
+		t.Fatalf("start (%v)", err)
+	}
+	if h, _ := c.InspectHost("b"); h.State != cl.HostRunning {
+		t.Fatalf("restarted host is %s", h.State)
+	}
+	if err = c.Start("b"); err == nil {
+		t.Fatalf("starting a running host succeeded")
+	}
+
+	// Remove and add
+	if err = c.RemoveHost("c"); err != nil {
+		t.Fatalf("remove (%v)", err)
+	}
+	if c.Exists("c") {
+		t.Fatalf("removed host exists")
+	}
+	if _, err = c.InspectHost("c"); err == nil {
+		t.Fatalf("inspecting a removed host succeeded")
+	}
+	if err = c.AddHost(&cl.HostConfig{Name: "d", Circuit: os.Args[0]}); err != nil {
+		t.Fatalf("add (%v)", err)
+	}
+	if err = c.AddHost(&cl.HostConfig{Name: "d"}); err == nil {
+		t.Fatalf("adding a host twice succeeded")
+	}
+	hc, err := c.ResolveHost("d")
+	if err != nil || hc.Circuit != os.Args[0] {
+		t.Fatalf("resolve %v (%v)", hc, err)
+	}
+
+	// A server which cannot start
+	if err = c.AddHost(&cl.HostConfig{Name: "e", Circuit: "/nonexistent/circuit"}); err == nil {
+		t.Fatalf("starting a missing executable succeeded")
+	}
+	if h, _ := c.InspectHost("e"); h.State != cl.HostFailed || h.Err == "" {
+		t.Fatalf("failed host is %s, err %q", h.State, h.Err)
+	}
+	if err = c.AddHost(&cl.HostConfig{Name: "f", Launcher: "telepathy"}); err == nil {
+		t.Fatalf("unknown launcher succeeded")
+	}
+
+	// Merge with another cluster
+	other, err := createCluster(&cl.ClusterConfig{Name: "other", Hosts: []cl.HostConfig{localHost("x")}})
+	if err != nil {
+		t.Fatalf("create other (%v)", err)
+	}
+	defer other.Shutdown()
+	a, _ := c.InspectHost("a")
+	if err = other.Join(a.Addr); err != nil {
+		t.Fatalf("join (%v)", err)
+	}
+	waitPeers(t, a.Addr, 4)
+
+	if err = c.Shutdown(); err != nil {
+		t.Fatalf("shutdown (%v)", err)
+	}
+	r, _ = c.Inspect()
+	for _, h := range r.Hosts {
+		if h.State == cl.HostRunning || h.State == cl.HostStarting {
+			t.Fatalf("host %s is %s after shutdown", h.Config.Name, h.State)
+		}
+	}
+}
+
+func TestStartArgs(t *testing.T) {
+	hc := &cl.HostConfig{
+		If:      "eth0",
+		Var:     "/var/circuit",
+		Hmac:    "/etc/circuit/hmac",
+		TLSCert: "/etc/circuit/host.crt",
+		TLSKey:  "/etc/circuit/host.key",
+		TLSCA:   "/etc/circuit/ca.crt",
+		Token:   "c0ffee",
+		Policy:  "/etc/circuit/policy.json",
+		Trace:   "http://localhost:4318/v1/traces",
+	}
+	got := strings.Join(startArgs(hc, "circuit://10.0.0.1:11022/1/R0"), " ")
+	want := "start --addr= --if eth0 --var /var/circuit --hmac /etc/circuit/hmac" +
+		" --tls-cert /etc/circuit/host.crt --tls-key /etc/circuit/host.key --tls-ca /etc/circuit/ca.crt" +
+		" --policy /etc/circuit/policy.json" +
+		" --trace http://localhost:4318/v1/traces --join circuit://10.0.0.1:11022/1/R0"
+	if got != want {
+		t.Fatalf("start args\n%s\nexpected\n%s", got, want)
+	}
+	if got = strings.Join(startEnv(hc, []string{"HOME=/root"}), " "); got != "HOME=/root CIRCUIT_TOKEN=c0ffee" {
+		t.Fatalf("start env %s", got)
+	}
+	if got = strings.Join(startArgs(&cl.HostConfig{Addr: ":0"}, ""), " "); got != "start --addr :0" {
+		t.Fatalf("start args %s", got)
+	}
+	if env := startEnv(&cl.HostConfig{}, []string{"HOME=/root"}); env != nil {
+		t.Fatalf("start env %v without token", env)
+	}
+}
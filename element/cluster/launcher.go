@@ -0,0 +1,153 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/gocircuit/circuit/client"
+	cl "github.com/gocircuit/circuit/client/cluster"
+	"github.com/gocircuit/circuit/element/proc"
+)
+
+// Launcher starts the circuit server of a host. The server joins the circuit
+// through the member at join, or forms a new circuit when join is empty, and
+// prints its circuit address on its standard output, as circuit start does.
+type Launcher interface {
+	Launch(hc *cl.HostConfig, join string) (client.Proc, error)
+}
+
+var launchers = struct {
+	sync.Mutex
+	m map[string]Launcher
+}{m: map[string]Launcher{
+	cl.LauncherLocal: localLauncher{},
+	cl.LauncherSSH:   sshLauncher{},
+}}
+
+// RegisterLauncher makes a launcher available to hosts under name
+func RegisterLauncher(name string, l Launcher) {
+	launchers.Lock()
+	defer launchers.Unlock()
+	launchers.m[name] = l
+}
+
+func lookupLauncher(name string) (Launcher, error) {
+	if name == "" {
+		name = cl.LauncherLocal
+	}
+	launchers.Lock()
+	defer launchers.Unlock()
+	l, ok := launchers.m[name]
+	if !ok {
+		return nil, fmt.Errorf("cluster launcher %s not available", name)
+	}
+	return l, nil
+}
+
+// startArgs returns the arguments to circuit for starting the server of hc.
+// The files of hc are named as they are, at the host of the server: launchers
+// do not copy them. The access token is not among the arguments, which other
+// users of the host can list, but in the environment of startEnv.
+func startArgs(hc *cl.HostConfig, join string) []string {
+	args := []string{"start"}
+	switch {
+	case hc.Addr != "":
+		args = append(args, "--addr", hc.Addr)
+	case hc.If != "":
+		// start prefers its default address over the interface
+		args = append(args, "--addr=", "--if", hc.If)
+	}
+	if hc.Var != "" {
+		args = append(args, "--var", hc.Var)
+	}
+	for _, f := range []struct{ flag, value string }{
+		{"--hmac", hc.Hmac},
+		{"--tls-cert", hc.TLSCert},
+		{"--tls-key", hc.TLSKey},
+		{"--tls-ca", hc.TLSCA},
+		{"--tls-revoked", hc.TLSRevoked},
+		{"--policy", hc.Policy},
+		{"--trace", hc.Trace},
+	} {
+		if f.value != "" {
+			args = append(args, f.flag, f.value)
+		}
+	}
+	if join != "" {
+		args = append(args, "--join", join)
+	}
+	return args
+}
+
+// tokenEnv is the environment variable passing the access token to circuit start
+const tokenEnv = "CIRCUIT_TOKEN"
+
+// startEnv returns env with the access token of hc added, or nil if hc has no token
+func startEnv(hc *cl.HostConfig, env []string) []string {
+	if hc.Token == "" {
+		return nil
+	}
+	return append(env[:len(env):len(env)], tokenEnv+"="+hc.Token)
+}
+
+// localLauncher starts servers as child processes of this server. Unless the
+// host says otherwise, they run this circuit executable on the loopback
+// interface.
+type localLauncher struct{}
+
+func (localLauncher) Launch(hc *cl.HostConfig, join string) (client.Proc, error) {
+	exe := hc.Circuit
+	if exe == "" {
+		var err error
+		if exe, err = os.Executable(); err != nil {
+			return nil, err
+		}
+	}
+	h := *hc
+	if h.Addr == "" && h.If == "" {
+		h.Addr = "127.0.0.1:0"
+	}
+	return proc.MakeProc(client.Cmd{
+		Path: exe,
+		Args: startArgs(&h, join),
+		Env:  startEnv(&h, os.Environ()),
+	}), nil
+}
+
+// sshLauncher starts servers with the ssh command. A terminal is forced, so
+// that the server is hung up when the ssh session is terminated. The access
+// token is sent in the environment, which the ssh server of the host must
+// accept with AcceptEnv CIRCUIT_TOKEN.
+type sshLauncher struct{}
+
+func (sshLauncher) Launch(hc *cl.HostConfig, join string) (client.Proc, error) {
+	if hc.Host == "" {
+		return nil, fmt.Errorf("ssh launcher needs the host of %s", hc.Name)
+	}
+	ssh, err := exec.LookPath("ssh")
+	if err != nil {
+		return nil, err
+	}
+	exe := hc.Circuit
+	if exe == "" {
+		exe = "circuit"
+	}
+	// ssh hands the command to the remote shell
+	cmd := []string{shellQuote(exe)}
+	for _, a := range startArgs(hc, join) {
+		cmd = append(cmd, shellQuote(a))
+	}
+	args := []string{"-tt", "-o", "BatchMode=yes"}
+	if hc.Token != "" {
+		args = append(args, "-o", "SendEnv="+tokenEnv)
+	}
+	args = append(args, hc.Host, strings.Join(cmd, " "))
+	return proc.MakeProc(client.Cmd{Path: ssh, Args: args, Env: startEnv(hc, os.Environ())}), nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
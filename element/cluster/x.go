@@ -1,15 +1,237 @@
 package cluster
 
-import "github.com/gocircuit/circuit/use/circuit"
+import (
+	"github.com/gocircuit/circuit/anchor"
+	cl "github.com/gocircuit/circuit/client/cluster"
+	srv "github.com/gocircuit/circuit/client/server"
+	"github.com/gocircuit/circuit/use/circuit"
+	"github.com/gocircuit/circuit/use/errors"
+)
 
 type XCluster struct {
-	Cluster
+	c     Cluster
+	guard anchor.Guard
+}
+
+// clusterVerbs are the verbs of the methods of XCluster. The cluster calls
+// the servers it launched as this server, so callers are checked against
+// the anchor of the cluster: launching servers makes them, stopping them
+// signals them, and removing them scrubs them.
+var clusterVerbs = anchor.Verbs{
+	"AddHost":       anchor.Make,
+	"Clone":         anchor.Make,
+	"CreateCluster": anchor.Make,
+	"Exists":        anchor.Get,
+	"Hosts":         anchor.Get,
+	"Inspect":       anchor.Get,
+	"InspectHost":   anchor.Get,
+	"Join":          anchor.Make,
+	"PeekBytes":     anchor.Get,
+	"RemoveHost":    anchor.Scrub,
+	"ResolveHost":   anchor.Get,
+	"Scrub":         anchor.Scrub,
+	"Shutdown":      anchor.Scrub,
+	"Signal":        anchor.Signal,
+	"Stack":         anchor.Get,
+	"Start":         anchor.Make,
+	"Stop":          anchor.Signal,
+	"Top":           anchor.Get,
+}
+
+func (x XCluster) Authorize(method string) error {
+	return x.guard.Authorize(clusterVerbs, method)
 }
 
 func init() {
 	circuit.RegisterValue(XCluster{})
 }
 
+func (x XCluster) AddHost(hc *cl.HostConfig) error {
+	err := x.c.AddHost(hc)
+	x.guard.Audit(anchor.Make, hc, err)
+	return errors.Pack(err)
+}
+
+func (x XCluster) Clone() error {
+	return errors.Pack(x.c.Clone())
+}
+
+func (x XCluster) CreateCluster(config *cl.ClusterConfig) error {
+	err := x.c.CreateCluster(config)
+	x.guard.Audit(anchor.Make, config, err)
+	return errors.Pack(err)
+}
+
+func (x XCluster) Exists(hostName string) bool {
+	return x.c.Exists(hostName)
+}
+
+func (x XCluster) Hosts() []string {
+	return x.c.Hosts()
+}
+
+func (x XCluster) Inspect() (*cl.InspectClusterConfig, error) {
+	r, err := x.c.Inspect()
+	return r, errors.Pack(err)
+}
+
+func (x XCluster) InspectHost(hostName string) (*cl.HostInfo, error) {
+	r, err := x.c.InspectHost(hostName)
+	return r, errors.Pack(err)
+}
+
+func (x XCluster) Join(cluster string) error {
+	err := x.c.Join(cluster)
+	x.guard.Audit(anchor.Make, cluster, err)
+	return errors.Pack(err)
+}
+
+func (x XCluster) RemoveHost(hostName string) error {
+	err := x.c.RemoveHost(hostName)
+	x.guard.Audit(anchor.Scrub, hostName, err)
+	return errors.Pack(err)
+}
+
+func (x XCluster) PeekBytes() []byte {
+	return x.c.PeekBytes()
+}
+
+func (x XCluster) ResolveHost(hostName string) (*cl.HostConfig, error) {
+	r, err := x.c.ResolveHost(hostName)
+	return r, errors.Pack(err)
+}
+
+func (x XCluster) Scrub() {
+	x.c.Scrub()
+	x.guard.Audit(anchor.Scrub, nil, nil)
+}
+
+func (x XCluster) Shutdown() error {
+	err := x.c.Shutdown()
+	x.guard.Audit(anchor.Scrub, nil, err)
+	return errors.Pack(err)
+}
+
+func (x XCluster) Signal(sig string) error {
+	err := x.c.Signal(sig)
+	x.guard.Audit(anchor.Signal, sig, err)
+	return errors.Pack(err)
+}
+
+func (x XCluster) Stack(hostName string) (string, error) {
+	r, err := x.c.Stack(hostName)
+	return r, errors.Pack(err)
+}
+
+func (x XCluster) Top(hostName string) (*srv.ServerTop, error) {
+	r, err := x.c.Top(hostName)
+	return r, errors.Pack(err)
+}
+
+func (x XCluster) Start(hostName string) error {
+	err := x.c.Start(hostName)
+	x.guard.Audit(anchor.Make, hostName, err)
+	return errors.Pack(err)
+}
+
+func (x XCluster) Stop(hostName string) error {
+	err := x.c.Stop(hostName)
+	x.guard.Audit(anchor.Signal, hostName, err)
+	return errors.Pack(err)
+}
+
 type YCluster struct {
 	X circuit.X
 }
+
+func (y YCluster) AddHost(hc *cl.HostConfig) error {
+	return errors.Unpack(y.X.Call("AddHost", hc)[0])
+}
+
+func (y YCluster) Clone() error {
+	return errors.Unpack(y.X.Call("Clone")[0])
+}
+
+func (y YCluster) CreateCluster(config *cl.ClusterConfig) error {
+	return errors.Unpack(y.X.Call("CreateCluster", config)[0])
+}
+
+func (y YCluster) Exists(hostName string) bool {
+	return y.X.Call("Exists", hostName)[0].(bool)
+}
+
+func (y YCluster) Hosts() []string {
+	return y.X.Call("Hosts")[0].([]string)
+}
+
+func (y YCluster) Inspect() (*cl.InspectClusterConfig, error) {
+	r := y.X.Call("Inspect")
+	if err := errors.Unpack(r[1]); err != nil {
+		return nil, err
+	}
+	return r[0].(*cl.InspectClusterConfig), nil
+}
+
+func (y YCluster) InspectHost(hostName string) (*cl.HostInfo, error) {
+	r := y.X.Call("InspectHost", hostName)
+	if err := errors.Unpack(r[1]); err != nil {
+		return nil, err
+	}
+	return r[0].(*cl.HostInfo), nil
+}
+
+func (y YCluster) Join(cluster string) error {
+	return errors.Unpack(y.X.Call("Join", cluster)[0])
+}
+
+func (y YCluster) PeekBytes() []byte {
+	return y.X.Call("PeekBytes")[0].([]byte)
+}
+
+func (y YCluster) RemoveHost(hostName string) error {
+	return errors.Unpack(y.X.Call("RemoveHost", hostName)[0])
+}
+
+func (y YCluster) ResolveHost(hostName string) (*cl.HostConfig, error) {
+	r := y.X.Call("ResolveHost", hostName)
+	if err := errors.Unpack(r[1]); err != nil {
+		return nil, err
+	}
+	return r[0].(*cl.HostConfig), nil
+}
+
+func (y YCluster) Scrub() {
+	y.X.Call("Scrub")
+}
+
+func (y YCluster) Shutdown() error {
+	return errors.Unpack(y.X.Call("Shutdown")[0])
+}
+
+func (y YCluster) Signal(sig string) error {
+	return errors.Unpack(y.X.Call("Signal", sig)[0])
+}
+
+func (y YCluster) Stack(hostName string) (string, error) {
+	r := y.X.Call("Stack", hostName)
+	if err := errors.Unpack(r[1]); err != nil {
+		return "", err
+	}
+	return r[0].(string), nil
+}
+
+func (y YCluster) Start(hostName string) error {
+	return errors.Unpack(y.X.Call("Start", hostName)[0])
+}
+
+func (y YCluster) Stop(hostName string) error {
+	return errors.Unpack(y.X.Call("Stop", hostName)[0])
+}
+
+func (y YCluster) Top(hostName string) (*srv.ServerTop, error) {
+	r := y.X.Call("Top", hostName)
+	if err := errors.Unpack(r[1]); err != nil {
+		return nil, err
+	}
+	return r[0].(*srv.ServerTop), nil
+}
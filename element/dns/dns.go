@@ -94,7 +94,11 @@ func (ns *nameserver) Scrub() {
 }
 
 func (ns *nameserver) X() circuit.X {
-	return circuit.Ref(XNameserver{ns})
+	return circuit.Ref(XNameserver{ns: ns})
+}
+
+func (ns *nameserver) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XNameserver{ns: ns, guard: g})
 }
 
 func (ns *nameserver) Set(rr string) error {
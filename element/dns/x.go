@@ -8,6 +8,7 @@
 package dns
 
 import (
+	"github.com/gocircuit/circuit/anchor"
 	"github.com/gocircuit/circuit/client"
 	"github.com/gocircuit/circuit/use/circuit"
 	"github.com/gocircuit/circuit/use/errors"
@@ -19,16 +20,44 @@ func init() {
 
 // X
 type XNameserver struct {
-	Nameserver
+	ns    Nameserver
+	guard anchor.Guard
+}
+
+// nameserverVerbs are the verbs of the methods of XNameserver. Setting and
+// unsetting records makes them.
+var nameserverVerbs = anchor.Verbs{
+	"Set":       anchor.Make,
+	"Unset":     anchor.Make,
+	"Peek":      anchor.Get,
+	"PeekBytes": anchor.Get,
+	"Scrub":     anchor.Scrub,
+}
+
+func (x XNameserver) Authorize(method string) error {
+	return x.guard.Authorize(nameserverVerbs, method)
 }
 
 func (x XNameserver) Set(rr string) error {
-	err := x.Nameserver.Set(rr)
+	err := x.ns.Set(rr)
 	return errors.Pack(err)
 }
 
+func (x XNameserver) Unset(name string) {
+	x.ns.Unset(name)
+}
+
+func (x XNameserver) Peek() client.NameserverStat {
+	return x.ns.Peek()
+}
+
 func (x XNameserver) PeekBytes() []byte {
-	return x.Nameserver.PeekBytes()
+	return x.ns.PeekBytes()
+}
+
+func (x XNameserver) Scrub() {
+	x.ns.Scrub()
+	x.guard.Audit(anchor.Scrub, nil, nil)
 }
 
 // Y
@@ -54,5 +83,5 @@ func (y YNameserver) Peek() client.NameserverStat {
 }
 
 func (y YNameserver) PeekBytes() []byte {
-	return y.X.Call("Peekbytes")[0].([]byte)
+	return y.X.Call("PeekBytes")[0].([]byte)
 }
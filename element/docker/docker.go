@@ -134,7 +134,11 @@ func (con *container) IsDone() bool {
 }
 
 func (con *container) X() circuit.X {
-	return circuit.Ref(XContainer{con})
+	return circuit.Ref(XContainer{con: con})
+}
+
+func (con *container) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XContainer{con: con, guard: g})
 }
 
 // ef is the element factory for creating the docker element
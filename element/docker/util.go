@@ -22,4 +22,10 @@ func Init() (_ string, err error) {
 
 var dkr string
 
+// Path returns the docker binary resolved by Init, or the empty string if
+// docker elements are not enabled on this server.
+func Path() string {
+	return dkr
+}
+
 const StdBufferLen = 32e3
@@ -10,6 +10,7 @@ package docker
 import (
 	"io"
 
+	"github.com/gocircuit/circuit/anchor"
 	ds "github.com/gocircuit/circuit/client/docker"
 	xio "github.com/gocircuit/circuit/kit/x/io"
 	"github.com/gocircuit/circuit/use/circuit"
@@ -22,37 +23,67 @@ func init() {
 
 // XContainer is a circuit container that wraps a Docker container skeleton impl.
 type XContainer struct {
-	Container
+	con   Container
+	guard anchor.Guard
+}
+
+// containerVerbs are the verbs of the methods of XContainer
+var containerVerbs = anchor.Verbs{
+	"Wait":      anchor.Get,
+	"Signal":    anchor.Signal,
+	"Stdin":     anchor.Stdin,
+	"Stdout":    anchor.Get,
+	"Stderr":    anchor.Get,
+	"IsDone":    anchor.Get,
+	"Peek":      anchor.Get,
+	"PeekBytes": anchor.Get,
+	"Scrub":     anchor.Scrub,
+}
+
+func (x XContainer) Authorize(method string) error {
+	return x.guard.Authorize(containerVerbs, method)
 }
 
 func (x XContainer) Wait() (*ds.Stat, error) {
-	stat, err := x.Container.Wait()
+	stat, err := x.con.Wait()
 	return stat, errors.Pack(err)
 }
 
 func (x XContainer) Signal(sig string) error {
-	return errors.Pack(x.Container.Signal(sig))
+	err := x.con.Signal(sig)
+	x.guard.Audit(anchor.Signal, sig, err)
+	return errors.Pack(err)
 }
 
 func (x XContainer) Stdin() circuit.X {
-	return xio.NewXWriteCloser(x.Container.Stdin())
+	x.guard.Audit(anchor.Stdin, nil, nil)
+	return xio.NewXWriteCloser(x.con.Stdin())
 }
 
 func (x XContainer) Stdout() circuit.X {
-	return xio.NewXReadCloser(x.Container.Stdout())
+	return xio.NewXReadCloser(x.con.Stdout())
 }
 
 func (x XContainer) Stderr() circuit.X {
-	return xio.NewXReadCloser(x.Container.Stderr())
+	return xio.NewXReadCloser(x.con.Stderr())
 }
 
 func (x XContainer) Peek() (*ds.Stat, error) {
-	stat, err := x.Container.Peek()
+	stat, err := x.con.Peek()
 	return stat, errors.Pack(err)
 }
 
 func (x XContainer) PeekBytes() []byte {
-	return x.Container.PeekBytes()
+	return x.con.PeekBytes()
+}
+
+func (x XContainer) IsDone() bool {
+	return x.con.IsDone()
+}
+
+func (x XContainer) Scrub() {
+	x.con.Scrub()
+	x.guard.Audit(anchor.Scrub, nil, nil)
 }
 
 // YContainer is a circuit container that wraps a Docker container stub/proxy impl.
@@ -86,7 +117,7 @@ func (y YContainer) Peek() (stat *ds.Stat, err error) {
 }
 
 func (y YContainer) PeekBytes() []byte {
-	return y.X.Call("Peekbytes")[0].([]byte)
+	return y.X.Call("PeekBytes")[0].([]byte)
 }
 
 func (y YContainer) Stdin() io.WriteCloser {
@@ -0,0 +1,472 @@
+// Package job provides the job element, which runs an array of parallel
+// tasks across circuit servers, with retries and a failure policy.
+package job
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gocircuit/circuit/anchor"
+	"github.com/gocircuit/circuit/client"
+	cl "github.com/gocircuit/circuit/client/job"
+	srv "github.com/gocircuit/circuit/client/server"
+	_ "github.com/gocircuit/circuit/element/proc"
+	"github.com/gocircuit/circuit/use/circuit"
+)
+
+// Job is the element-side interface of jobs
+type Job interface {
+	cl.Job
+	X() circuit.X
+}
+
+// RetryDelay is the pause before a failed task is run again
+var RetryDelay = time.Second
+
+// placer runs the processes of tasks on circuit servers
+type placer interface {
+	// Hosts returns the hosts of the live circuit servers, by server ID
+	Hosts() map[string]srv.HostStat
+	// Make starts a process at walk under the root anchor of server
+	Make(server string, walk []string, cmd client.Cmd) (client.Proc, error)
+	// Scrub removes the element at walk under the root anchor of server
+	Scrub(server string, walk []string)
+}
+
+// termPlacer places processes through the anchor terminal of the job.
+// Processes on the server hosting the job are placed locally. Other servers
+// see this server place them, so the maker of the job must be allowed to
+// make them at their anchors here.
+type termPlacer struct {
+	t     *anchor.Terminal
+	self  string
+	maker anchor.Guard
+}
+
+func (p termPlacer) Hosts() map[string]srv.HostStat {
+	return p.t.Hosts()
+}
+
+// at returns the guard of the maker of the job at the anchor of a task
+func (p termPlacer) at(server string, walk []string) anchor.Guard {
+	return p.maker.At("/"+strings.Join(append([]string{server}, walk...), "/"), anchor.Proc)
+}
+
+// Make records the processes it places, and is denied, in the audit log as
+// made by the maker of the job
+func (p termPlacer) Make(server string, walk []string, cmd client.Cmd) (_ client.Proc, err error) {
+	g := p.at(server, walk)
+	defer func() {
+		g.Audit(anchor.Make, cmd, err)
+	}()
+	if err = g.Check(anchor.Make); err != nil {
+		return nil, err
+	}
+	if server == p.self {
+		elm, err := p.t.Root().Walk(walk).Make(anchor.Proc, cmd)
+		if err != nil {
+			return nil, err
+		}
+		return elm.(client.Proc), nil
+	}
+	y, ok := p.t.Peer(server)
+	if !ok {
+		return nil, fmt.Errorf("server %s is gone", server)
+	}
+	v, err := y.Walk(walk).Make(anchor.Proc, cmd)
+	if err != nil {
+		return nil, err
+	}
+	proc, ok := v.(client.Proc)
+	if !ok {
+		return nil, fmt.Errorf("element at %s is not a process", strings.Join(walk, "/"))
+	}
+	return proc, nil
+}
+
+func (p termPlacer) Scrub(server string, walk []string) {
+	p.at(server, walk).Audit(anchor.Scrub, nil, nil)
+	if server == p.self {
+		p.t.Root().Walk(walk).Scrub()
+		return
+	}
+	if y, ok := p.t.Peer(server); ok {
+		y.Walk(walk).Scrub()
+	}
+}
+
+type job struct {
+	spec  cl.Spec
+	where []client.Constraint
+	walk  []string // anchor path of the job below its server
+	p     placer
+	tmpl  cmdTemplate
+	done  chan struct{} // closed when no task is running or pending
+	abr   chan struct{} // closed on abort
+	sync.Mutex
+	tasks    []cl.TaskStat
+	procs    map[int]client.Proc
+	aborted  bool
+	scrubbed bool
+}
+
+func init() {
+	anchor.RegisterElement(anchor.Job, ef, yf)
+}
+
+func ef(t *anchor.Terminal, arg any) (anchor.Element, error) {
+	spec, ok := arg.(cl.Spec)
+	if !ok {
+		return nil, fmt.Errorf("invalid argument to job element factory, arg=%T", arg)
+	}
+	// the path of the job below the server, /X1234/a/b => a/b
+	walk := client.Split(t.Path())
+	if len(walk) == 0 {
+		return nil, errors.New("job cannot be placed at the root anchor")
+	}
+	return makeJob(spec, walk[1:], termPlacer{t: t, self: walk[0], maker: t.Maker()})
+}
+
+func yf(x circuit.X) (any, error) {
+	return YJob{x}, nil
+}
+
+func makeJob(spec cl.Spec, walk []string, p placer) (*job, error) {
+	switch {
+	case spec.Tasks <= 0:
+		return nil, errors.New("job needs at least one task")
+	case spec.Parallel < 0, spec.Retries < 0:
+		return nil, errors.New("job parallelism and retries cannot be negative")
+	case spec.Policy != "" && spec.Policy != cl.Continue && spec.Policy != cl.Abort:
+		return nil, fmt.Errorf("job failure policy %q not known", spec.Policy)
+	}
+	where, err := client.ParseConstraints(spec.Where)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := parseCmd(spec.Cmd)
+	if err != nil {
+		return nil, err
+	}
+	j := &job{
+		spec:  spec,
+		where: where,
+		walk:  walk,
+		p:     p,
+		tmpl:  tmpl,
+		done:  make(chan struct{}),
+		abr:   make(chan struct{}),
+		tasks: make([]cl.TaskStat, spec.Tasks),
+		procs: make(map[int]client.Proc),
+	}
+	for i := range j.tasks {
+		j.tasks[i] = cl.TaskStat{Index: i, State: cl.Pending}
+	}
+	go j.loop()
+	return j, nil
+}
+
+// loop runs the tasks, at most spec.Parallel at once
+func (j *job) loop() {
+	n := j.spec.Parallel
+	if n == 0 {
+		n = j.spec.Tasks
+	}
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+start:
+	for i := 0; i < j.spec.Tasks; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-j.abr:
+			// Tasks not started by the abort are not started at all
+			for ; i < j.spec.Tasks; i++ {
+				j.setState(i, cl.Aborted)
+			}
+			break start
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			j.run(i)
+		}(i)
+	}
+	wg.Wait()
+	close(j.done)
+}
+
+// run runs task i until it is done, or it fails and has no retries left.
+// A task whose command does not render fails without retries.
+func (j *job) run(i int) {
+	cmd, err := j.tmpl.exec(i, j.spec.Tasks)
+	if err != nil {
+		j.Lock()
+		j.tasks[i].Exit = err.Error()
+		j.Unlock()
+		j.fail(i)
+		return
+	}
+	for attempt := 0; ; attempt++ {
+		if j.isAborted() {
+			j.setState(i, cl.Aborted)
+			return
+		}
+		err := j.attempt(i, attempt, cmd)
+		if err == nil {
+			j.setState(i, cl.Done)
+			return
+		}
+		j.Lock()
+		j.tasks[i].Exit = err.Error()
+		j.Unlock()
+		if j.isAborted() {
+			j.setState(i, cl.Aborted)
+			return
+		}
+		if attempt >= j.spec.Retries {
+			j.fail(i)
+			return
+		}
+		select {
+		case <-time.After(RetryDelay):
+		case <-j.abr:
+		}
+	}
+}
+
+// fail marks task i failed, and aborts the job if its policy says so
+func (j *job) fail(i int) {
+	j.setState(i, cl.Failed)
+	if j.spec.Policy == cl.Abort {
+		j.abort()
+	}
+}
+
+// attempt places the process of task i on a server and waits for it to exit.
+// Attempts of a task are spread over the servers meeting the constraints.
+func (j *job) attempt(i, attempt int, cmd client.Cmd) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("server unreachable: %v", r)
+		}
+	}()
+	servers := client.Candidates(j.p.Hosts(), j.where)
+	if len(servers) == 0 {
+		return client.ErrNoServer
+	}
+	server := servers[(i+attempt)%len(servers)]
+	walk := append(append([]string{}, j.walk...), strconv.Itoa(i))
+
+	j.Lock()
+	t := &j.tasks[i]
+	t.State, t.Attempts, t.Server = cl.Running, attempt+1, server
+	t.Anchor = "/" + strings.Join(append([]string{server}, walk...), "/")
+	j.Unlock()
+
+	proc, err := j.p.Make(server, walk, cmd)
+	if err != nil {
+		return err
+	}
+	defer j.p.Scrub(server, walk)
+	j.Lock()
+	j.procs[i] = proc
+	aborted := j.aborted
+	j.Unlock()
+	defer func() {
+		j.Lock()
+		delete(j.procs, i)
+		j.Unlock()
+	}()
+	if aborted {
+		proc.Signal("KILL")
+	}
+
+	proc.Stdin().Close()
+	go io.Copy(io.Discard, proc.Stdout())
+	last := make(chan string, 1)
+	go func() {
+		last <- lastLine(proc.Stderr())
+	}()
+	stat, err := proc.Wait()
+	if err != nil {
+		return err
+	}
+	if stat.Exit != nil {
+		select {
+		case l := <-last:
+			if l != "" {
+				return fmt.Errorf("%v: %s", stat.Exit, l)
+			}
+		case <-time.After(time.Second):
+		}
+		return stat.Exit
+	}
+	return nil
+}
+
+// lastLine reads r to the end, and returns its last non-empty line
+func lastLine(r io.Reader) (l string) {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if t := strings.TrimSpace(s.Text()); t != "" {
+			l = t
+		}
+	}
+	return l
+}
+
+func (j *job) setState(i int, state string) {
+	j.Lock()
+	defer j.Unlock()
+	j.tasks[i].State = state
+}
+
+func (j *job) isAborted() bool {
+	j.Lock()
+	defer j.Unlock()
+	return j.aborted
+}
+
+// abort kills the running tasks and starts no more
+func (j *job) abort() {
+	j.Lock()
+	defer j.Unlock()
+	if j.aborted {
+		return
+	}
+	j.aborted = true
+	close(j.abr)
+	for _, p := range j.procs {
+		go func(p client.Proc) {
+			defer func() { recover() }()
+			p.Signal("KILL")
+		}(p)
+	}
+}
+
+func (j *job) Wait() (cl.Stat, error) {
+	<-j.done
+	j.Lock()
+	scrubbed := j.scrubbed
+	j.Unlock()
+	if scrubbed {
+		return cl.Stat{}, errors.New("aborted")
+	}
+	return j.Peek(), nil
+}
+
+func (j *job) Peek() cl.Stat {
+	j.Lock()
+	defer j.Unlock()
+	r := cl.Stat{Tasks: make([]cl.TaskStat, len(j.tasks))}
+	copy(r.Tasks, j.tasks)
+	for _, t := range j.tasks {
+		switch t.State {
+		case cl.Pending:
+			r.Pending++
+		case cl.Running:
+			r.Running++
+		case cl.Done:
+			r.Done++
+		case cl.Failed:
+			r.Failed++
+		case cl.Aborted:
+			r.Aborted++
+		}
+	}
+	switch {
+	case r.Pending+r.Running > 0:
+		r.State = cl.Running
+	case r.Done == len(j.tasks):
+		r.State = cl.Done
+	default:
+		r.State = cl.Failed
+	}
+	return r
+}
+
+func (j *job) PeekBytes() []byte {
+	b, _ := json.MarshalIndent(j.Peek(), "", "\t")
+	return b
+}
+
+func (j *job) Scrub() {
+	j.Lock()
+	j.scrubbed = true
+	j.Unlock()
+	j.abort()
+}
+
+func (j *job) X() circuit.X {
+	return circuit.Ref(XJob{j: j})
+}
+
+func (j *job) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XJob{j: j, guard: g})
+}
+
+// cmdTemplate holds the templates of the fields of a task command
+type cmdTemplate struct {
+	env, args []*template.Template
+	dir, path *template.Template
+}
+
+func parseCmd(cmd client.Cmd) (c cmdTemplate, err error) {
+	parse := func(s string) *template.Template {
+		if err != nil {
+			return nil
+		}
+		var t *template.Template
+		t, err = template.New("").Option("missingkey=error").Parse(s)
+		return t
+	}
+	if strings.TrimSpace(cmd.Path) == "" {
+		return c, errors.New("job command has no path")
+	}
+	c.path = parse(cmd.Path)
+	c.dir = parse(cmd.Dir)
+	for _, s := range cmd.Env {
+		c.env = append(c.env, parse(s))
+	}
+	for _, s := range cmd.Args {
+		c.args = append(c.args, parse(s))
+	}
+	if err != nil {
+		return c, fmt.Errorf("job command template does not parse (%v)", err)
+	}
+	return c, nil
+}
+
+// exec returns the command of task i of n
+func (c cmdTemplate) exec(i, n int) (cmd client.Cmd, err error) {
+	data := struct{ Index, Tasks int }{i, n}
+	exec := func(t *template.Template) string {
+		if err != nil {
+			return ""
+		}
+		var b strings.Builder
+		err = t.Execute(&b, data)
+		return b.String()
+	}
+	cmd.Path = exec(c.path)
+	cmd.Dir = exec(c.dir)
+	for _, t := range c.env {
+		cmd.Env = append(cmd.Env, exec(t))
+	}
+	for _, t := range c.args {
+		cmd.Args = append(cmd.Args, exec(t))
+	}
+	if err != nil {
+		return cmd, fmt.Errorf("job command template (%v)", err)
+	}
+	return cmd, nil
+}
@@ -0,0 +1,163 @@
+package job
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocircuit/circuit/client"
+	cl "github.com/gocircuit/circuit/client/job"
+	srv "github.com/gocircuit/circuit/client/server"
+	"github.com/gocircuit/circuit/element/proc"
+)
+
+// localPlacer runs the processes of every server locally
+type localPlacer struct {
+	sync.Mutex
+	hosts map[string]srv.HostStat
+	made  map[string]int // anchor => processes made
+}
+
+func newLocalPlacer(servers ...string) *localPlacer {
+	p := &localPlacer{hosts: make(map[string]srv.HostStat), made: make(map[string]int)}
+	for _, s := range servers {
+		p.hosts[s] = srv.HostStat{NumCPU: 1}
+	}
+	return p
+}
+
+func (p *localPlacer) Hosts() map[string]srv.HostStat {
+	return p.hosts
+}
+
+func (p *localPlacer) Make(server string, walk []string, cmd client.Cmd) (client.Proc, error) {
+	p.Lock()
+	p.made["/"+server+"/"+strings.Join(walk, "/")]++
+	p.Unlock()
+	return proc.MakeProc(cmd), nil
+}
+
+func (p *localPlacer) Scrub(server string, walk []string) {}
+
+func sh(script string) client.Cmd {
+	return client.Cmd{Path: "/bin/sh", Args: []string{"-c", script}}
+}
+
+func TestJob(t *testing.T) {
+	RetryDelay = 10 * time.Millisecond
+	p := newLocalPlacer("X1", "X2")
+	j, err := makeJob(cl.Spec{Tasks: 4, Parallel: 2, Cmd: sh("exit {{.Index}}")}, []string{"batch"}, p)
+	if err != nil {
+		t.Fatalf("make (%v)", err)
+	}
+	s, err := j.Wait()
+	if err != nil {
+		t.Fatalf("wait (%v)", err)
+	}
+	if s.State != cl.Failed || s.Done != 1 || s.Failed != 3 {
+		t.Fatalf("stat %+v", s)
+	}
+	if s.Tasks[0].Anchor != "/X1/batch/0" || s.Tasks[1].Anchor != "/X2/batch/1" {
+		t.Fatalf("tasks are not spread over servers %+v", s.Tasks)
+	}
+
+	// Every task fails until its second retry
+	p = newLocalPlacer("X1", "X2")
+	spec := cl.Spec{Tasks: 3, Retries: 2, Cmd: sh(`test -e "$0" || { touch "$0"; exit 1; }; test -e "$0.2" || { touch "$0.2"; echo flaky >&2; exit 1; }`)}
+	dir := t.TempDir()
+	spec.Cmd.Args = append(spec.Cmd.Args, dir+"/{{.Index}}")
+	if j, err = makeJob(spec, []string{"retry"}, p); err != nil {
+		t.Fatalf("make (%v)", err)
+	}
+	if s, _ = j.Wait(); s.State != cl.Done || s.Done != 3 {
+		t.Fatalf("stat %+v", s)
+	}
+	for _, task := range s.Tasks {
+		if task.Attempts != 3 || !strings.Contains(task.Exit, "flaky") {
+			t.Fatalf("task %+v", task)
+		}
+	}
+	if p.made["/X1/retry/0"] != 2 || p.made["/X2/retry/0"] != 1 {
+		t.Fatalf("retries are not moved to other servers %v", p.made)
+	}
+
+	// The abort policy kills running tasks
+	spec = cl.Spec{Tasks: 3, Policy: cl.Abort, Cmd: sh("test {{.Index}} = 0 && exit 1; exec sleep 10")}
+	if j, err = makeJob(spec, nil, newLocalPlacer("X1")); err != nil {
+		t.Fatalf("make (%v)", err)
+	}
+	if s, _ = j.Wait(); s.State != cl.Failed || s.Failed != 1 || s.Aborted != 2 {
+		t.Fatalf("stat %+v", s)
+	}
+
+	// The abort policy does not start the tasks waiting for a slot
+	spec = cl.Spec{Tasks: 5, Parallel: 1, Policy: cl.Abort, Cmd: sh("exit 1")}
+	if j, err = makeJob(spec, nil, newLocalPlacer("X1")); err != nil {
+		t.Fatalf("make (%v)", err)
+	}
+	if s = waitJob(t, j); s.State != cl.Failed || s.Failed != 1 || s.Aborted != 4 {
+		t.Fatalf("stat %+v", s)
+	}
+
+	// A command which does not render fails its task without retries, and
+	// the abort policy applies
+	p = newLocalPlacer("X1")
+	spec = cl.Spec{Tasks: 2, Parallel: 1, Retries: 2, Policy: cl.Abort, Cmd: sh("true")}
+	spec.Cmd.Args = append(spec.Cmd.Args, "{{if eq .Index 0}}{{.Missing}}{{end}}")
+	if j, err = makeJob(spec, nil, p); err != nil {
+		t.Fatalf("make (%v)", err)
+	}
+	if s = waitJob(t, j); s.State != cl.Failed || s.Failed != 1 || s.Aborted != 1 {
+		t.Fatalf("stat %+v", s)
+	}
+	if task := s.Tasks[0]; task.Attempts != 0 || !strings.Contains(task.Exit, "template") || len(p.made) != 0 {
+		t.Fatalf("task %+v, made %v", task, p.made)
+	}
+
+	// Scrub aborts a running job
+	if j, err = makeJob(cl.Spec{Tasks: 2, Cmd: sh("exec sleep 10")}, nil, newLocalPlacer("X1")); err != nil {
+		t.Fatalf("make (%v)", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if s = j.Peek(); s.State != cl.Running || s.Running != 2 {
+		t.Fatalf("stat %+v", s)
+	}
+	j.Scrub()
+	if _, err = j.Wait(); err == nil {
+		t.Fatalf("wait of a scrubbed job succeeded")
+	}
+
+	// Scrub aborts a job with tasks waiting for a slot
+	if j, err = makeJob(cl.Spec{Tasks: 3, Parallel: 1, Cmd: sh("exec sleep 10")}, nil, newLocalPlacer("X1")); err != nil {
+		t.Fatalf("make (%v)", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	j.Scrub()
+	if s = waitJob(t, j); s.Aborted != 3 {
+		t.Fatalf("stat %+v", s)
+	}
+
+	// Specs which do not make sense
+	for _, spec := range []cl.Spec{
+		{Tasks: 0, Cmd: sh("true")},
+		{Tasks: 1},
+		{Tasks: 1, Cmd: sh("{{.Index")},
+		{Tasks: 1, Cmd: sh("true"), Policy: "maybe"},
+		{Tasks: 1, Cmd: sh("true"), Where: "cpu>=many"},
+	} {
+		if _, err = makeJob(spec, nil, newLocalPlacer("X1")); err == nil {
+			t.Fatalf("spec %+v accepted", spec)
+		}
+	}
+}
+
+// waitJob waits for the tasks of j to end
+func waitJob(t *testing.T, j *job) cl.Stat {
+	select {
+	case <-j.done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("job does not end")
+	}
+	return j.Peek()
+}
@@ -0,0 +1,68 @@
+package job
+
+import (
+	"github.com/gocircuit/circuit/anchor"
+	cl "github.com/gocircuit/circuit/client/job"
+	"github.com/gocircuit/circuit/use/circuit"
+	"github.com/gocircuit/circuit/use/errors"
+)
+
+type XJob struct {
+	j     Job
+	guard anchor.Guard
+}
+
+// jobVerbs are the verbs of the methods of XJob
+var jobVerbs = anchor.Verbs{
+	"Wait":      anchor.Get,
+	"Peek":      anchor.Get,
+	"PeekBytes": anchor.Get,
+	"Scrub":     anchor.Scrub,
+}
+
+func (x XJob) Authorize(method string) error {
+	return x.guard.Authorize(jobVerbs, method)
+}
+
+func init() {
+	circuit.RegisterValue(XJob{})
+}
+
+func (x XJob) Wait() (cl.Stat, error) {
+	r, err := x.j.Wait()
+	return r, errors.Pack(err)
+}
+
+func (x XJob) Peek() cl.Stat {
+	return x.j.Peek()
+}
+
+func (x XJob) PeekBytes() []byte {
+	return x.j.PeekBytes()
+}
+
+func (x XJob) Scrub() {
+	x.j.Scrub()
+	x.guard.Audit(anchor.Scrub, nil, nil)
+}
+
+type YJob struct {
+	X circuit.X
+}
+
+func (y YJob) Wait() (cl.Stat, error) {
+	r := y.X.Call("Wait")
+	return r[0].(cl.Stat), errors.Unpack(r[1])
+}
+
+func (y YJob) Peek() cl.Stat {
+	return y.X.Call("Peek")[0].(cl.Stat)
+}
+
+func (y YJob) PeekBytes() []byte {
+	return y.X.Call("PeekBytes")[0].([]byte)
+}
+
+func (y YJob) Scrub() {
+	y.X.Call("Scrub")
+}
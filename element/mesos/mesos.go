@@ -0,0 +1,341 @@
+// Copyright 2013 The Go Circuit Project
+// Use of this source code is governed by the license for
+// The Go Circuit Project, found in the LICENSE file.
+//
+// Authors:
+//   2014 Petar Maymounkov <p@gocircuit.org>
+
+// Package mesos provides the mesos element which serves as a real-time resource megotiation exchange inspired by Apache Mesos.
+package mesos
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocircuit/circuit/anchor"
+	cl "github.com/gocircuit/circuit/client/mesos"
+	srv "github.com/gocircuit/circuit/client/server"
+	"github.com/gocircuit/circuit/use/circuit"
+	"github.com/gocircuit/circuit/use/n"
+)
+
+// Mesos is the element-side interface of the exchange
+type Mesos interface {
+	cl.Mesos
+	X() circuit.X
+
+	// Addr returns the address of the worker of a live circuit server
+	Addr(worker string) (n.Addr, bool)
+}
+
+var (
+	errScrubbed = errors.New("mesos element scrubbed")
+	errNoOffer  = errors.New("no worker can cover the resource")
+)
+
+// mesos keeps offers and grants against the hosts of the live circuit
+// servers. Liveness is observed whenever the exchange is used, so grants of
+// departed workers and holders, and of holders whose element was scrubbed,
+// are released before any answer is given.
+type mesos struct {
+	hosts func() map[string]srv.HostStat
+	addr  func(worker string) (n.Addr, bool)
+	held  func(path string) bool // reports whether the anchor at path holds an element
+	sync.Mutex
+	offers   map[string]cl.Resource // worker => offered resource
+	pinned   map[string]cl.Resource // worker => resource set by Offer
+	grants   map[string]*cl.Grant
+	anchored map[string]bool // grants whose holder anchor held an element when granted
+	n        int64
+	scrubbed bool
+}
+
+func init() {
+	anchor.RegisterElement(anchor.Mesos, ef, yf)
+}
+
+// ef accepts no argument, nil or "", as the exchange has no configuration
+func ef(t *anchor.Terminal, arg any) (anchor.Element, error) {
+	if arg != nil && arg != "" {
+		return nil, fmt.Errorf("invalid argument to mesos element factory, arg=%T", arg)
+	}
+	m := newMesos(t.Hosts)
+	m.addr, m.held = workerAddr(t), holds(t)
+	return m, nil
+}
+
+// workerAddr returns the addresses of the workers of live circuit servers
+func workerAddr(t *anchor.Terminal) func(string) (n.Addr, bool) {
+	return func(worker string) (n.Addr, bool) {
+		y, ok := t.Peer(worker)
+		if !ok {
+			return nil, false
+		}
+		return y.X.Addr(), true
+	}
+}
+
+// holds reports whether anchors, given by path, hold an element. Anchors of
+// servers that cannot be asked hold none.
+func holds(t *anchor.Terminal) func(string) bool {
+	return func(path string) (ok bool) {
+		walk := strings.Split(strings.Trim(path, "/"), "/")
+		y, found := t.Peer(walk[0])
+		if !found {
+			return false
+		}
+		defer func() {
+			if recover() != nil {
+				ok = false
+			}
+		}()
+		kind, _ := y.Walk(walk[1:]).Get()
+		return kind != ""
+	}
+}
+
+func yf(x circuit.X) (any, error) {
+	return YMesos{x}, nil
+}
+
+func newMesos(hosts func() map[string]srv.HostStat) *mesos {
+	return &mesos{
+		hosts:    hosts,
+		offers:   make(map[string]cl.Resource),
+		pinned:   make(map[string]cl.Resource),
+		grants:   make(map[string]*cl.Grant),
+		anchored: make(map[string]bool),
+	}
+}
+
+func (m *mesos) Addr(worker string) (n.Addr, bool) {
+	if m.addr == nil {
+		return nil, false
+	}
+	return m.addr(worker)
+}
+
+// offerOf returns the resource a host offers: its CPUs, its free memory and
+// disk, and its labels with integer values. Grants are taken off the offer
+// alike for every resource, by spare.
+func offerOf(h srv.HostStat) cl.Resource {
+	r := cl.Resource{
+		cl.CPU:  h.NumCPU,
+		cl.Mem:  int(h.MemFree >> 20),
+		cl.Disk: int(h.DiskFree >> 20),
+	}
+	for k, v := range h.Labels {
+		if u, err := strconv.Atoi(v); err == nil && u >= 0 {
+			if _, ok := r[k]; !ok {
+				r[k] = u
+			}
+		}
+	}
+	return r
+}
+
+// refresh updates the offers of live workers, and drops the offers and
+// grants of departed workers, and the grants of departed holders and of
+// holders whose element was scrubbed
+func (m *mesos) refresh() {
+	hosts := m.hosts()
+	m.offers = make(map[string]cl.Resource, len(hosts))
+	for id, h := range hosts {
+		if r, ok := m.pinned[id]; ok {
+			m.offers[id] = r
+		} else {
+			m.offers[id] = offerOf(h)
+		}
+	}
+	for id := range m.pinned {
+		if _, ok := hosts[id]; !ok {
+			delete(m.pinned, id)
+		}
+	}
+	for id, g := range m.grants {
+		if _, ok := hosts[g.Worker]; !ok {
+			m.release(id)
+			continue
+		}
+		if holder, ok := holderOf(g.Framework); ok {
+			if _, ok := hosts[holder]; !ok {
+				m.release(id)
+				continue
+			}
+		}
+		if m.anchored[id] && !m.held(g.Framework) {
+			m.release(id)
+		}
+	}
+}
+
+func (m *mesos) release(grant string) {
+	delete(m.grants, grant)
+	delete(m.anchored, grant)
+}
+
+// holderOf returns the server ID of a framework given as an anchor path
+func holderOf(framework string) (string, bool) {
+	if !strings.HasPrefix(framework, "/") {
+		return "", false
+	}
+	server, _, _ := strings.Cut(strings.TrimLeft(framework, "/"), "/")
+	return server, server != ""
+}
+
+// spare returns the resource of worker that is not granted
+func (m *mesos) spare(worker string) cl.Resource {
+	r := make(cl.Resource)
+	for k, v := range m.offers[worker] {
+		r[k] = v
+	}
+	for _, g := range m.grants {
+		if g.Worker != worker {
+			continue
+		}
+		for k, v := range g.Resource {
+			if r[k] -= v; r[k] < 0 {
+				r[k] = 0
+			}
+		}
+	}
+	return r
+}
+
+func (m *mesos) Offer(worker string, rsc cl.Resource) error {
+	m.Lock()
+	defer m.Unlock()
+	if m.scrubbed {
+		return errScrubbed
+	}
+	m.refresh()
+	if _, ok := m.offers[worker]; !ok {
+		return fmt.Errorf("worker %s is not a live circuit server", worker)
+	}
+	if rsc == nil {
+		delete(m.pinned, worker)
+	} else {
+		for k, v := range rsc {
+			if v < 0 {
+				return fmt.Errorf("offer of %d units of %s", v, k)
+			}
+		}
+		m.pinned[worker] = rsc
+	}
+	m.refresh()
+	return nil
+}
+
+func (m *mesos) Ask(framework string, rsc cl.Resource) (*cl.Grant, error) {
+	m.Lock()
+	defer m.Unlock()
+	if m.scrubbed {
+		return nil, errScrubbed
+	}
+	for k, v := range rsc {
+		if v < 0 {
+			return nil, fmt.Errorf("ask for %d units of %s", v, k)
+		}
+	}
+	m.refresh()
+	var (
+		worker string
+		best   = -1
+	)
+	for id := range m.offers {
+		s := m.spare(id)
+		if !s.Covers(rsc) {
+			continue
+		}
+		if c := s[cl.CPU]; c > best || (c == best && id < worker) {
+			worker, best = id, c
+		}
+	}
+	if worker == "" {
+		return nil, fmt.Errorf("%w %s", errNoOffer, rsc)
+	}
+	m.n++
+	g := &cl.Grant{
+		ID:        fmt.Sprintf("G%d", m.n),
+		Framework: framework,
+		Worker:    worker,
+		Resource:  make(cl.Resource, len(rsc)),
+		Time:      time.Now(),
+	}
+	for k, v := range rsc {
+		g.Resource[k] = v
+	}
+	m.grants[g.ID] = g
+	if _, ok := holderOf(framework); ok && m.held != nil && m.held(framework) {
+		m.anchored[g.ID] = true
+	}
+	r := *g
+	return &r, nil
+}
+
+func (m *mesos) Release(grant string) error {
+	m.Lock()
+	defer m.Unlock()
+	if _, ok := m.grants[grant]; !ok {
+		return fmt.Errorf("no grant %s", grant)
+	}
+	m.release(grant)
+	return nil
+}
+
+func (m *mesos) Peek() cl.Stat {
+	m.Lock()
+	defer m.Unlock()
+	if !m.scrubbed {
+		m.refresh()
+	}
+	r := cl.Stat{
+		Offers: make([]cl.Offer, 0, len(m.offers)),
+		Grants: make([]cl.Grant, 0, len(m.grants)),
+	}
+	for id, o := range m.offers {
+		_, pinned := m.pinned[id]
+		r.Offers = append(r.Offers, cl.Offer{Worker: id, Offered: o, Spare: m.spare(id), Pinned: pinned})
+	}
+	sort.Slice(r.Offers, func(i, j int) bool { return r.Offers[i].Worker < r.Offers[j].Worker })
+	for _, g := range m.grants {
+		r.Grants = append(r.Grants, *g)
+	}
+	sort.Slice(r.Grants, func(i, j int) bool {
+		a, b := r.Grants[i], r.Grants[j]
+		if len(a.ID) != len(b.ID) {
+			return len(a.ID) < len(b.ID)
+		}
+		return a.ID < b.ID
+	})
+	return r
+}
+
+func (m *mesos) PeekBytes() []byte {
+	buf, _ := json.MarshalIndent(m.Peek(), "", "\t")
+	return buf
+}
+
+func (m *mesos) Scrub() {
+	m.Lock()
+	defer m.Unlock()
+	m.scrubbed = true
+	m.offers = make(map[string]cl.Resource)
+	m.pinned = make(map[string]cl.Resource)
+	m.grants = make(map[string]*cl.Grant)
+	m.anchored = make(map[string]bool)
+}
+
+func (m *mesos) X() circuit.X {
+	return circuit.Ref(XMesos{m: m})
+}
+
+func (m *mesos) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XMesos{m: m, guard: g})
+}
@@ -0,0 +1,101 @@
+package mesos
+
+import (
+	"testing"
+
+	cl "github.com/gocircuit/circuit/client/mesos"
+	srv "github.com/gocircuit/circuit/client/server"
+)
+
+func TestMesos(t *testing.T) {
+	hosts := map[string]srv.HostStat{
+		"X1": {NumCPU: 4, MemTotal: 8 << 30, MemFree: 1 << 30},
+		"X2": {NumCPU: 8, MemTotal: 2 << 30, MemFree: 2 << 30, Labels: map[string]string{"gpu": "1", "zone": "east"}},
+	}
+	m := newMesos(func() map[string]srv.HostStat { return hosts })
+
+	s := m.Peek()
+	if len(s.Offers) != 2 || s.Offers[1].Offered["gpu"] != 1 || s.Offers[0].Offered[cl.Mem] != 1<<10 {
+		t.Fatalf("offers %+v", s.Offers)
+	}
+
+	// The worker with the most spare CPU is chosen
+	g1, err := m.Ask("fw", cl.Resource{cl.CPU: 6})
+	if err != nil || g1.Worker != "X2" {
+		t.Fatalf("ask %+v (%v)", g1, err)
+	}
+	g2, err := m.Ask("fw", cl.Resource{cl.CPU: 3})
+	if err != nil || g2.Worker != "X1" {
+		t.Fatalf("ask %+v (%v)", g2, err)
+	}
+	if _, err = m.Ask("fw", cl.Resource{cl.CPU: 3}); err == nil {
+		t.Fatalf("ask beyond the offers succeeded")
+	}
+	if _, err = m.Ask("fw", cl.Resource{"gpu": 1, cl.Mem: 4 << 10}); err == nil {
+		t.Fatalf("ask for gpu and memory of different workers succeeded")
+	}
+	if err = m.Release(g1.ID); err != nil {
+		t.Fatalf("release (%v)", err)
+	}
+	if err = m.Release(g1.ID); err == nil {
+		t.Fatalf("releasing twice succeeded")
+	}
+
+	// Pinned offers
+	if err = m.Offer("X1", cl.Resource{cl.CPU: 16}); err != nil {
+		t.Fatalf("offer (%v)", err)
+	}
+	if err = m.Offer("X9", cl.Resource{cl.CPU: 1}); err == nil {
+		t.Fatalf("offer of a missing worker succeeded")
+	}
+	g3, err := m.Ask("/X2/job", cl.Resource{cl.CPU: 12})
+	if err != nil || g3.Worker != "X1" {
+		t.Fatalf("ask pinned %+v (%v)", g3, err)
+	}
+	if err = m.Offer("X1", nil); err != nil {
+		t.Fatalf("unpin (%v)", err)
+	}
+	if s = m.Peek(); s.Offers[0].Pinned || s.Offers[0].Spare[cl.CPU] != 0 {
+		t.Fatalf("unpinned offer %+v", s.Offers[0])
+	}
+
+	// Grants are released when their holder leaves
+	delete(hosts, "X2")
+	if s = m.Peek(); len(s.Offers) != 1 || len(s.Grants) != 1 || s.Grants[0].ID != g2.ID {
+		t.Fatalf("after holder departure %+v", s)
+	}
+	// and when their worker leaves
+	delete(hosts, "X1")
+	if s = m.Peek(); len(s.Offers) != 0 || len(s.Grants) != 0 {
+		t.Fatalf("after worker departure %+v", s)
+	}
+
+	m.Scrub()
+	if _, err = m.Ask("fw", nil); err == nil {
+		t.Fatalf("ask of a scrubbed exchange succeeded")
+	}
+}
+
+// Grants to a holder whose anchor held an element are released once it is scrubbed
+func TestScrubbedHolder(t *testing.T) {
+	hosts := map[string]srv.HostStat{"X1": {NumCPU: 4}}
+	held := map[string]bool{"/X1/job": true}
+	m := newMesos(func() map[string]srv.HostStat { return hosts })
+	m.held = func(path string) bool { return held[path] }
+
+	g1, err := m.Ask("/X1/job", cl.Resource{cl.CPU: 1})
+	if err != nil {
+		t.Fatalf("ask (%v)", err)
+	}
+	g2, err := m.Ask("/X1/framework", cl.Resource{cl.CPU: 1})
+	if err != nil {
+		t.Fatalf("ask (%v)", err)
+	}
+	if s := m.Peek(); len(s.Grants) != 2 {
+		t.Fatalf("grants %+v", s.Grants)
+	}
+	held["/X1/job"] = false
+	if s := m.Peek(); len(s.Grants) != 1 || s.Grants[0].ID != g2.ID {
+		t.Fatalf("grants after scrub of %s: %+v", g1.Framework, s.Grants)
+	}
+}
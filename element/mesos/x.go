@@ -0,0 +1,107 @@
+package mesos
+
+import (
+	"github.com/gocircuit/circuit/anchor"
+	cl "github.com/gocircuit/circuit/client/mesos"
+	"github.com/gocircuit/circuit/use/circuit"
+	"github.com/gocircuit/circuit/use/errors"
+)
+
+type XMesos struct {
+	m     Mesos
+	guard anchor.Guard
+}
+
+// mesosVerbs are the verbs of the methods of XMesos. Methods changing the
+// offers and grants of the exchange make them. Offer needs admin as well,
+// unless its caller is the worker whose offer it pins.
+var mesosVerbs = anchor.Verbs{
+	"Offer":     anchor.Make,
+	"Ask":       anchor.Make,
+	"Release":   anchor.Make,
+	"Peek":      anchor.Get,
+	"PeekBytes": anchor.Get,
+	"Scrub":     anchor.Scrub,
+}
+
+func (x XMesos) Authorize(method string) error {
+	return x.guard.Authorize(mesosVerbs, method)
+}
+
+func init() {
+	circuit.RegisterValue(XMesos{})
+}
+
+func (x XMesos) Offer(worker string, rsc cl.Resource) error {
+	err := x.offerer(worker)
+	if err == nil {
+		err = x.m.Offer(worker, rsc)
+	}
+	x.guard.Audit(anchor.Make, []any{worker, rsc}, err)
+	return errors.Pack(err)
+}
+
+// offerer returns an error if the caller may not pin the offer of worker
+func (x XMesos) offerer(worker string) error {
+	if addr, ok := x.m.Addr(worker); ok && x.guard.From(addr) {
+		return nil
+	}
+	return x.guard.Check(anchor.Admin)
+}
+
+func (x XMesos) Ask(framework string, rsc cl.Resource) (*cl.Grant, error) {
+	r, err := x.m.Ask(framework, rsc)
+	x.guard.Audit(anchor.Make, []any{framework, rsc}, err)
+	return r, errors.Pack(err)
+}
+
+func (x XMesos) Release(grant string) error {
+	err := x.m.Release(grant)
+	x.guard.Audit(anchor.Make, grant, err)
+	return errors.Pack(err)
+}
+
+func (x XMesos) Peek() cl.Stat {
+	return x.m.Peek()
+}
+
+func (x XMesos) PeekBytes() []byte {
+	return x.m.PeekBytes()
+}
+
+func (x XMesos) Scrub() {
+	x.m.Scrub()
+	x.guard.Audit(anchor.Scrub, nil, nil)
+}
+
+type YMesos struct {
+	X circuit.X
+}
+
+func (y YMesos) Offer(worker string, rsc cl.Resource) error {
+	return errors.Unpack(y.X.Call("Offer", worker, rsc)[0])
+}
+
+func (y YMesos) Ask(framework string, rsc cl.Resource) (*cl.Grant, error) {
+	r := y.X.Call("Ask", framework, rsc)
+	if err := errors.Unpack(r[1]); err != nil {
+		return nil, err
+	}
+	return r[0].(*cl.Grant), nil
+}
+
+func (y YMesos) Release(grant string) error {
+	return errors.Unpack(y.X.Call("Release", grant)[0])
+}
+
+func (y YMesos) Peek() cl.Stat {
+	return y.X.Call("Peek")[0].(cl.Stat)
+}
+
+func (y YMesos) PeekBytes() []byte {
+	return y.X.Call("PeekBytes")[0].([]byte)
+}
+
+func (y YMesos) Scrub() {
+	y.X.Call("Scrub")
+}
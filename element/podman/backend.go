@@ -0,0 +1,143 @@
+package podman
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	c "github.com/gocircuit/circuit/client/podman"
+)
+
+// ErrNotEnabled is returned when podman elements are created on a server
+// that has neither a podman binary nor a libpod socket configured.
+var ErrNotEnabled = errors.New("podman not enabled on this server")
+
+// ErrNotSupported is returned by a backend for operations it cannot perform.
+var ErrNotSupported = errors.New("operation not supported by podman backend")
+
+// Stdio holds the standard streams to connect to a started container.
+type Stdio struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Backend performs podman operations on behalf of the container, pod, network,
+// volume and image elements. Operations address objects by name.
+type Backend interface {
+	ContainerBackend
+	PodBackend
+	NetworkBackend
+	VolumeBackend
+	ImageBackend
+}
+
+type ContainerBackend interface {
+	ContainerCreate(opts *c.ContainerCreateOptions) (id string, err error)
+	ContainerCheckpoint(name string, opts *c.ContainerCheckpointOptions) error
+	ContainerExec(name string, opts *c.ContainerExecOptions) ([]byte, error)
+	ContainerInspect(name string) (*c.InspectContainerData, error)
+	ContainerKill(name string, sig string) error
+	ContainerPause(name string) error
+	ContainerPorts(name string) ([]string, error)
+	ContainerRemove(name string, opts *c.ContainerRemoveOptions) error
+	ContainerRename(name, newName string) error
+	ContainerRestore(name string, opts *c.ContainerRestoreOptions) error
+
+	// ContainerStart starts the container and connects its standard streams to stdio.
+	// The returned function blocks until the container exits.
+	ContainerStart(name string, stdio Stdio) (wait func() error, err error)
+
+	// ContainerStats streams resource usage samples of the container to fn,
+	// until stop is closed or the container stops running.
+	ContainerStats(name string, stop <-chan struct{}, fn func(*c.ContainerStats)) error
+
+	// ContainerEvents streams the lifecycle events of the container that occurred
+	// since the given time to fn, until stop is closed.
+	ContainerEvents(name string, since time.Time, stop <-chan struct{}, fn func(*c.ContainerEvent)) error
+
+	ContainerStop(name string, opts *c.ContainerStopOpts) error
+	ContainerUnpause(name string) error
+
+	// ContainerWait blocks until the container exits.
+	// A non-zero exit code is reported as an error.
+	ContainerWait(name string) error
+}
+
+type PodBackend interface {
+	PodCreate(opts *c.PodCreateOptions) (id string, err error)
+	PodClone(name string, opts *c.PodCloneOptions) (id string, err error)
+	PodExists(name string) error
+
+	// PodGenerate returns the Kubernetes YAML of the pod and its containers.
+	PodGenerate(name string) ([]byte, error)
+	PodInspect(name string) (*c.InspectPodData, error)
+	PodKill(name string, sig string) error
+	PodPause(name string) error
+	PodRemove(name string) error
+	PodRestart(name string) error
+	PodStart(name string, opts *c.PodStartOptions) error
+	PodStop(name string, opts *c.PodStopOptions) error
+	PodUnpause(name string) error
+}
+
+type NetworkBackend interface {
+	NetworkCreate(opts *c.NetworkCreateOptions) (id string, err error)
+	NetworkConnect(name string, opts *c.NetworkConnectOptions) error
+	NetworkDisconnect(name string, opts *c.NetworkDisconnectOptions) error
+	NetworkExists(name string) error
+	NetworkInspect(name string) (*c.InspectNetworkSettings, error)
+	NetworkReload(name string) error
+	NetworkRemove(name string, opts *c.NetworkRemoveOptions) error
+	NetworkUpdate(name string, opts *c.NetworkUpdateOptions) error
+}
+
+type VolumeBackend interface {
+	VolumeCreate(opts *c.VolumeCreateOptions) (id string, err error)
+	VolumeExists(name string) error
+	VolumeExport(name string, opts c.VolumeExportOptions) error
+	VolumeImport(name string, source string) error
+	VolumeInspect(name string) (*c.InspectVolumeData, error)
+	VolumeMount(name string) error
+	VolumeReload(name string) error
+	VolumeRemove(name string, opts *c.VolumeRemoveOptions) error
+	VolumeUnmount(name string) error
+}
+
+// ImageBackend operations are also implemented for docker by the command line backend.
+type ImageBackend interface {
+	// ImageBuild builds the image tagged tag, passing each line of build output to progress.
+	ImageBuild(tag string, opts *c.ImageBuildOptions, progress func(string)) error
+	ImageExists(name string) error
+	ImageInspect(name string) (*c.InspectImageData, error)
+	ImageLoad(file string) error
+
+	// ImagePull pulls the image, passing each line of pull output to progress.
+	ImagePull(name string, opts *c.ImagePullOptions, progress func(string)) error
+	ImageRemove(name string) error
+	ImageSave(name string, file string) error
+	ImageTag(name string, tag string) error
+}
+
+var engine struct {
+	sync.Mutex
+	be Backend
+}
+
+// Use installs the backend used by podman elements created from now on.
+func Use(be Backend) {
+	engine.Lock()
+	defer engine.Unlock()
+	engine.be = be
+}
+
+// Engine returns the backend installed on this server, or ErrNotEnabled.
+func Engine() (Backend, error) {
+	engine.Lock()
+	defer engine.Unlock()
+	if engine.be == nil {
+		return nil, ErrNotEnabled
+	}
+	return engine.be, nil
+}
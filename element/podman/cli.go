@@ -0,0 +1,413 @@
+package podman
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	c "github.com/gocircuit/circuit/client/podman"
+	"github.com/gocircuit/circuit/element"
+)
+
+// cli is the backend which forks the podman binary for every operation.
+type cli struct {
+	path string
+}
+
+// NewCLI returns a backend that runs the podman binary at path.
+func NewCLI(path string) Backend {
+	return &cli{path: path}
+}
+
+func (b *cli) run(args ...string) error {
+	_, err := b.output(args...)
+	return err
+}
+
+// output runs podman with args and returns its trimmed standard output.
+// On failure the standard error of podman is folded into the returned error.
+func (b *cli) output(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(b.path, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s %s: %s", b.path, args[0], msg)
+		}
+		return nil, err
+	}
+	return bytes.TrimSpace(out), nil
+}
+
+// stream runs a long-lived podman command, passing each line of its output to fn.
+// The command is killed when stop is closed.
+func (b *cli) stream(stop <-chan struct{}, fn func([]byte), args ...string) error {
+	cmd := exec.Command(b.path, args...)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stop:
+			cmd.Process.Kill()
+		case <-done:
+		}
+	}()
+	scanner := bufio.NewScanner(out)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		fn(scanner.Bytes())
+	}
+	err = cmd.Wait()
+	select {
+	case <-stop:
+		return nil
+	default:
+	}
+	return err
+}
+
+// container
+
+func (b *cli) ContainerCreate(opts *c.ContainerCreateOptions) (string, error) {
+	r, err := b.output(opts.CmdLine(opts.Name)...)
+	return string(r), err
+}
+
+// NOTE: this requires root to run and also that criu is installed on the host.
+func (b *cli) ContainerCheckpoint(name string, opts *c.ContainerCheckpointOptions) error {
+	return b.run(opts.CmdLine(name)...)
+}
+
+func (b *cli) ContainerExec(name string, opts *c.ContainerExecOptions) ([]byte, error) {
+	return b.output(opts.CmdLine(name)...)
+}
+
+func (b *cli) ContainerInspect(name string) (*c.InspectContainerData, error) {
+	r, err := b.output("container", "inspect", name)
+	if err != nil {
+		return nil, err
+	}
+	return element.ParseJSONArrayFirst[*c.InspectContainerData](r)
+}
+
+func (b *cli) ContainerKill(name string, sig string) error {
+	args := []string{"container", "kill"}
+	if sig != "" {
+		args = append(args, "--signal", sig)
+	}
+	return b.run(append(args, name)...)
+}
+
+func (b *cli) ContainerPause(name string) error {
+	return b.run("container", "pause", name)
+}
+
+func (b *cli) ContainerPorts(name string) ([]string, error) {
+	r, err := b.output("container", "port", name)
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(r), nil
+}
+
+func (b *cli) ContainerRemove(name string, opts *c.ContainerRemoveOptions) error {
+	return b.run(opts.CmdLine(name)...)
+}
+
+func (b *cli) ContainerRename(name, newName string) error {
+	return b.run("container", "rename", name, newName)
+}
+
+func (b *cli) ContainerRestore(name string, opts *c.ContainerRestoreOptions) error {
+	return b.run(opts.CmdLine(name)...)
+}
+
+func (b *cli) ContainerStart(name string, stdio Stdio) (func() error, error) {
+	cmd := exec.Command(b.path, "start", name)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdio.Stdin, stdio.Stdout, stdio.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return cmd.Wait, nil
+}
+
+func (b *cli) ContainerStats(name string, stop <-chan struct{}, fn func(*c.ContainerStats)) error {
+	return b.stream(stop,
+		func(line []byte) {
+			if s, ok := parseStats(line); ok {
+				fn(s)
+			}
+		},
+		"stats", "--no-reset", "--interval", "1", "--format", "{{json .ContainerStats}}", name,
+	)
+}
+
+func (b *cli) ContainerEvents(name string, since time.Time, stop <-chan struct{}, fn func(*c.ContainerEvent)) error {
+	return b.stream(stop,
+		func(line []byte) {
+			if e, ok := parseEvent(line); ok {
+				fn(e)
+			}
+		},
+		"events", "--format", "json", "--since", strconv.FormatInt(since.Unix(), 10),
+		"--filter", "type=container", "--filter", "container="+name,
+	)
+}
+
+func (b *cli) ContainerStop(name string, opts *c.ContainerStopOpts) error {
+	return b.run(opts.CmdLine(name)...)
+}
+
+func (b *cli) ContainerUnpause(name string) error {
+	return b.run("container", "unpause", name)
+}
+
+func (b *cli) ContainerWait(name string) error {
+	r, err := b.output("wait", name)
+	if err != nil {
+		return err
+	}
+	code, err := strconv.Atoi(string(r))
+	if err != nil {
+		return fmt.Errorf("podman wait: unexpected output %q", r)
+	}
+	if code != 0 {
+		return fmt.Errorf("container exited with code %d", code)
+	}
+	return nil
+}
+
+// pod
+
+func (b *cli) PodCreate(opts *c.PodCreateOptions) (string, error) {
+	r, err := b.output(opts.CmdLine()...)
+	return string(r), err
+}
+
+func (b *cli) PodClone(name string, opts *c.PodCloneOptions) (string, error) {
+	r, err := b.output(append(opts.CmdLine(), name)...)
+	return string(r), err
+}
+
+func (b *cli) PodExists(name string) error {
+	return b.run("pod", "exists", name)
+}
+
+func (b *cli) PodGenerate(name string) ([]byte, error) {
+	return b.output("kube", "generate", name)
+}
+
+func (b *cli) PodInspect(name string) (*c.InspectPodData, error) {
+	r, err := b.output("pod", "inspect", name)
+	if err != nil {
+		return nil, err
+	}
+	return element.ParseJSONArrayFirst[*c.InspectPodData](r)
+}
+
+func (b *cli) PodKill(name string, sig string) error {
+	args := []string{"pod", "kill"}
+	if sig != "" {
+		args = append(args, "--signal", sig)
+	}
+	return b.run(append(args, name)...)
+}
+
+func (b *cli) PodPause(name string) error {
+	return b.run("pod", "pause", name)
+}
+
+func (b *cli) PodRemove(name string) error {
+	return b.run("pod", "rm", "--force", "--ignore", name)
+}
+
+func (b *cli) PodRestart(name string) error {
+	return b.run("pod", "restart", name)
+}
+
+func (b *cli) PodStart(name string, opts *c.PodStartOptions) error {
+	return b.run(opts.CmdLine(name)...)
+}
+
+func (b *cli) PodStop(name string, opts *c.PodStopOptions) error {
+	return b.run(opts.CmdLine(name)...)
+}
+
+func (b *cli) PodUnpause(name string) error {
+	return b.run("pod", "unpause", name)
+}
+
+// network
+
+func (b *cli) NetworkCreate(opts *c.NetworkCreateOptions) (string, error) {
+	r, err := b.output(opts.CmdLine()...)
+	return string(r), err
+}
+
+func (b *cli) NetworkConnect(name string, opts *c.NetworkConnectOptions) error {
+	return b.run(opts.CmdLine(name)...)
+}
+
+func (b *cli) NetworkDisconnect(name string, opts *c.NetworkDisconnectOptions) error {
+	return b.run(opts.CmdLine(name)...)
+}
+
+func (b *cli) NetworkExists(name string) error {
+	return b.run("network", "exists", name)
+}
+
+func (b *cli) NetworkInspect(name string) (*c.InspectNetworkSettings, error) {
+	r, err := b.output("network", "inspect", name)
+	if err != nil {
+		return nil, err
+	}
+	return element.ParseJSONArrayFirst[*c.InspectNetworkSettings](r)
+}
+
+func (b *cli) NetworkReload(name string) error {
+	return b.run("network", "reload", name)
+}
+
+func (b *cli) NetworkRemove(name string, opts *c.NetworkRemoveOptions) error {
+	return b.run(opts.CmdLine(name)...)
+}
+
+func (b *cli) NetworkUpdate(name string, opts *c.NetworkUpdateOptions) error {
+	return b.run(opts.CmdLine(name)...)
+}
+
+// volume
+
+func (b *cli) VolumeCreate(opts *c.VolumeCreateOptions) (string, error) {
+	r, err := b.output(opts.CmdLine()...)
+	return string(r), err
+}
+
+func (b *cli) VolumeExists(name string) error {
+	return b.run("volume", "exists", name)
+}
+
+func (b *cli) VolumeExport(name string, opts c.VolumeExportOptions) error {
+	return b.run(opts.CmdLine(name)...)
+}
+
+func (b *cli) VolumeImport(name string, source string) error {
+	args := []string{"volume", "import", name}
+	if source != "" {
+		args = append(args, source)
+	}
+	return b.run(args...)
+}
+
+func (b *cli) VolumeInspect(name string) (*c.InspectVolumeData, error) {
+	r, err := b.output("volume", "inspect", name)
+	if err != nil {
+		return nil, err
+	}
+	return element.ParseJSONArrayFirst[*c.InspectVolumeData](r)
+}
+
+func (b *cli) VolumeMount(name string) error {
+	return b.run("volume", "mount", name)
+}
+
+func (b *cli) VolumeReload(name string) error {
+	return b.run("volume", "reload", name)
+}
+
+func (b *cli) VolumeRemove(name string, opts *c.VolumeRemoveOptions) error {
+	return b.run(opts.CmdLine(name)...)
+}
+
+func (b *cli) VolumeUnmount(name string) error {
+	return b.run("volume", "unmount", name)
+}
+
+// image
+
+// progress runs podman with args, passing each line of its combined output to fn.
+// On failure the last line of output is folded into the returned error.
+func (b *cli) progress(fn func(string), args ...string) error {
+	r, w := io.Pipe()
+	cmd := exec.Command(b.path, args...)
+	cmd.Stdout, cmd.Stderr = w, w
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() {
+		w.CloseWithError(cmd.Wait())
+	}()
+	var last string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		if l := strings.TrimSpace(scanner.Text()); l != "" {
+			last = l
+			fn(l)
+		}
+	}
+	err := scanner.Err()
+	if err != nil && last != "" {
+		return fmt.Errorf("%s %s: %s", b.path, args[0], last)
+	}
+	return err
+}
+
+func (b *cli) ImageBuild(tag string, opts *c.ImageBuildOptions, progress func(string)) error {
+	return b.progress(progress, opts.CmdLine(tag)...)
+}
+
+func (b *cli) ImageExists(name string) error {
+	return b.run("image", "inspect", "--format", "{{.Id}}", name)
+}
+
+func (b *cli) ImageInspect(name string) (*c.InspectImageData, error) {
+	r, err := b.output("image", "inspect", name)
+	if err != nil {
+		return nil, err
+	}
+	return element.ParseJSONArrayFirst[*c.InspectImageData](r)
+}
+
+func (b *cli) ImageLoad(file string) error {
+	return b.run("load", "--input", file)
+}
+
+func (b *cli) ImagePull(name string, opts *c.ImagePullOptions, progress func(string)) error {
+	return b.progress(progress, opts.CmdLine(name)...)
+}
+
+func (b *cli) ImageRemove(name string) error {
+	return b.run("image", "rm", name)
+}
+
+func (b *cli) ImageSave(name string, file string) error {
+	return b.run("save", "--output", file, name)
+}
+
+func (b *cli) ImageTag(name string, tag string) error {
+	return b.run("tag", name, tag)
+}
+
+func splitLines(b []byte) []string {
+	var r []string
+	for _, l := range strings.Split(string(b), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			r = append(r, l)
+		}
+	}
+	return r
+}
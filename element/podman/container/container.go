@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"os/exec"
 	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gocircuit/circuit/anchor"
 	c "github.com/gocircuit/circuit/client/podman"
@@ -23,13 +25,25 @@ type Container interface {
 }
 
 type container struct {
+	term   *anchor.Terminal
+	be     podman.Backend
 	name   string
 	id     string
-	cmd    *exec.Cmd
 	stdin  io.WriteCloser
 	stdout io.ReadCloser
 	stderr io.ReadCloser
+	scrub  bool // scrub the anchor on exit
 	exit   chan error
+	exited sync.Once
+	stop   chan struct{} // closed on scrub, ends the event and stats streams
+	once   sync.Once
+	events *podman.Feed
+	since  *atomic.Pointer[time.Time] // events before since have been streamed
+	stats  *podman.Feed
+	run    struct {
+		sync.Mutex
+		gen int // incremented when the container is checkpointed for migration
+	}
 }
 
 func init() {
@@ -37,73 +51,70 @@ func init() {
 }
 
 // MakeContainer creates a new container element
-func makeContainer(opts *c.ContainerCreateOptions) (Container, error) {
+func makeContainer(t *anchor.Terminal, opts *c.ContainerCreateOptions) (*container, error) {
 	// Check if podman enabled on this server
 	// TODO make this a capability of the server when it joins the cluster.
-	if podman.Path == "" {
-		return nil, errors.New("podman not installed on this server")
+	be, err := podman.Engine()
+	if err != nil {
+		return nil, err
 	}
 
 	// determine name
 	opts.Name = element.ElementName(opts.Name)
 
-	//log.Printf("cmd line: %s %v", podman.Path, args)
-
-	// Create a new container
-	con := &container{
-		name: opts.Name,
-		exit: make(chan error, 1),
-	}
-
-	// Create a new exec.Cmd
-	args := opts.CmdLine(opts.Name)
-
-	cmd := exec.Command(podman.Path, args...)
-	_, con.stdin = interruptible.BufferPipe(element.StdBufferLen)
-	r, err := cmd.Output()
+	since := time.Now()
+	id, err := be.ContainerCreate(opts)
 	if err != nil {
-		log.Printf("error running command: %s %v - error:%v", podman.Path, args, err)
+		log.Printf("error creating container %s: %v", opts.Name, err)
 		return nil, err
 	}
+	log.Printf("container-id: %s", id)
 
-	con.id = string(r)
-	fmt.Printf("container-id: %s", con.id)
+	return newContainer(t, be, opts.Name, id, since), nil
+}
+
+// newContainer returns the element for an existing podman container,
+// streaming its events from the given time on.
+func newContainer(t *anchor.Terminal, be podman.Backend, name, id string, since time.Time) *container {
+	con := &container{
+		term:   t,
+		be:     be,
+		name:   name,
+		id:     id,
+		exit:   make(chan error, 1),
+		stop:   make(chan struct{}),
+		events: podman.NewFeed("events", false),
+		since:  &atomic.Pointer[time.Time]{},
+		stats:  podman.NewFeed("stats", true),
+	}
+	_, con.stdin = interruptible.BufferPipe(element.StdBufferLen)
+	con.since.Store(&since)
+	con.startEvents()
 
 	// GC...
 	runtime.SetFinalizer(con,
-		func(c *container) {
-			exec.Command(podman.Path, "rm", c.name).Run()
+		func(con *container) {
+			con.close()
+			con.be.ContainerRemove(con.name, &c.ContainerRemoveOptions{Force: true, Ignore: true})
 		},
 	)
 
-	return con, nil
+	return con
 }
 
 // NOTE: this requires root to run and also that criu is installed on the host.
 // TODO check user and if criu is installed from host config
 func (con *container) CheckPoint(opts *c.ContainerCheckpointOptions) error {
-	return exec.Command(podman.Path, opts.CmdLine(con.name)...).Run()
+	return con.be.ContainerCheckpoint(con.name, opts)
 }
 
 // Exec runs a command in a running container.
 func (con *container) Exec(opts *c.ContainerExecOptions) ([]byte, error) {
-	return exec.Command(podman.Path, opts.CmdLine(con.name)...).Output()
+	return con.be.ContainerExec(con.name, opts)
 }
 
 func (con *container) Inspect() (*c.InspectContainerData, error) {
-	args := append([]string{}, "container", "inspect", con.name)
-
-	b, err := exec.Command(podman.Path, args...).Output()
-	if err != nil {
-		return nil, err
-	}
-
-	data, err := element.ParseJSONArrayFirst[*c.InspectContainerData](b)
-	if err != nil {
-		return nil, err
-	}
-
-	return data, nil
+	return con.be.ContainerInspect(con.name)
 }
 
 func (con *container) IsDone() bool {
@@ -116,11 +127,58 @@ func (con *container) IsDone() bool {
 }
 
 func (con *container) Pause() error {
-	if err := exec.Command(podman.Path, "container", "pause", con.name).Run(); err != nil {
-		return err
+	return con.be.ContainerPause(con.name)
+}
+
+// stream feeds f from a backend stream until the stream ends.
+func stream(f *podman.Feed, name string, run func(func(interface{})) error) {
+	defer f.Done()
+	if err := run(f.Publish); err != nil {
+		log.Printf("container %s %s stream ended: %v", name, f.Source(), err)
 	}
+}
 
-	return nil
+func (con *container) close() {
+	con.once.Do(func() {
+		close(con.stop)
+		con.events.Close()
+		con.stats.Close()
+	})
+}
+
+// Events restarts streaming lifecycle events, if the stream has ended, and
+// subscribes to them.
+func (con *container) Events() c.Subscription {
+	con.startEvents()
+	return con.events.Subscribe()
+}
+
+// startEvents streams lifecycle events, unless already streaming, from
+// where the previous stream ended.
+func (con *container) startEvents() {
+	if !con.events.Start() {
+		return
+	}
+	// The streams must not refer to con, so that it can be collected
+	be, name, stop, since := con.be, con.name, con.stop, con.since
+	go stream(con.events, name, func(fn func(interface{})) error {
+		err := be.ContainerEvents(name, *since.Load(), stop, func(e *c.ContainerEvent) { fn(e) })
+		end := time.Now()
+		since.Store(&end)
+		return err
+	})
+}
+
+// Stats starts streaming resource usage samples, unless already streaming,
+// and subscribes to them.
+func (con *container) Stats() c.Subscription {
+	if con.stats.Start() {
+		be, name, stop := con.be, con.name, con.stop
+		go stream(con.stats, name, func(fn func(interface{})) error {
+			return be.ContainerStats(name, stop, func(s *c.ContainerStats) { fn(s) })
+		})
+	}
+	return con.stats.Subscribe()
 }
 
 func (con *container) Peek() (*c.InspectContainerData, error) {
@@ -138,24 +196,15 @@ func (con *container) PeekBytes() []byte {
 }
 
 func (con *container) Ports() []string {
-	b, err := exec.Command(podman.Path, "container", "port", con.name).Output()
-	if err != nil {
-		return []string{}
-	}
-
-	// convert to []string
-	data, err := element.ParseJSONArray[string](b)
+	ports, err := con.be.ContainerPorts(con.name)
 	if err != nil {
 		return []string{}
 	}
-
-	// TODO what does b look like with multiple ports.
-
-	return data
+	return ports
 }
 
 func (con *container) Restore(opts *c.ContainerRestoreOptions) error {
-	return exec.Command(podman.Path, opts.CmdLine(con.name)...).Run()
+	return con.be.ContainerRestore(con.name, opts)
 }
 
 func (con *container) RunLabel() error {
@@ -170,12 +219,15 @@ func (con *container) Scrub() {
 		Ignore: true,
 		Volume: true,
 	}
-	exec.Command(podman.Path, opts.CmdLine(con.name)...).Run()
+	con.close()
+	con.be.ContainerRemove(con.name, &opts)
+	con.done(errors.New("container scrubbed"))
 }
 
+// Signal sends sig to the main process of the container.
+// The signal can be an integer string or a signal name.
 func (con *container) Signal(sig string) error {
-	// signal can be integer string or string name
-	return nil
+	return con.be.ContainerKill(con.name, sig)
 }
 
 func (con *container) Start() error {
@@ -183,24 +235,46 @@ func (con *container) Start() error {
 		return errors.New("container not created")
 	}
 
-	con.cmd = exec.Command(podman.Path, "start", con.name)
-	con.cmd.Stdin, con.stdin = interruptible.BufferPipe(element.StdBufferLen)
-	con.stdout, con.cmd.Stdout = interruptible.BufferPipe(element.StdBufferLen)
-	con.stderr, con.cmd.Stderr = interruptible.BufferPipe(element.StdBufferLen)
-	if err := con.cmd.Start(); err != nil {
+	var stdio podman.Stdio
+	var stdout, stderr io.WriteCloser
+	stdio.Stdin, con.stdin = interruptible.BufferPipe(element.StdBufferLen)
+	con.stdout, stdout = interruptible.BufferPipe(element.StdBufferLen)
+	con.stderr, stderr = interruptible.BufferPipe(element.StdBufferLen)
+	stdio.Stdout, stdio.Stderr = stdout, stderr
+	wait, err := con.be.ContainerStart(con.name, stdio)
+	if err != nil {
 		return err
 	}
 
+	gen := con.generation()
 	go func() {
-		con.exit <- con.cmd.Wait()
-		close(con.exit)
-		con.cmd.Stdout.(io.Closer).Close()
-		con.cmd.Stderr.(io.Closer).Close()
+		err := wait()
+		stdout.Close()
+		stderr.Close()
+		// A run ended by a checkpoint for migration continues elsewhere,
+		// or here again if the migration is rolled back.
+		if gen == con.generation() {
+			con.done(err)
+		}
 	}()
 
 	return nil
 }
 
+func (con *container) generation() int {
+	con.run.Lock()
+	defer con.run.Unlock()
+	return con.run.gen
+}
+
+// done records the exit of the container.
+func (con *container) done(err error) {
+	con.exited.Do(func() {
+		con.exit <- err
+		close(con.exit)
+	})
+}
+
 func (con *container) Stderr() io.ReadCloser {
 	return con.stderr
 }
@@ -214,19 +288,11 @@ func (con *container) Stdout() io.ReadCloser {
 }
 
 func (con *container) Stop(opts *c.ContainerStopOpts) error {
-	if err := exec.Command(podman.Path, opts.CmdLine(con.name)...).Run(); err != nil {
-		return err
-	}
-
-	return nil
+	return con.be.ContainerStop(con.name, opts)
 }
 
 func (con *container) Unpause() error {
-	if err := exec.Command(podman.Path, "container", "unpause", con.name).Run(); err != nil {
-		return err
-	}
-
-	return nil
+	return con.be.ContainerUnpause(con.name)
 }
 
 func (con *container) Wait() (*c.InspectContainerData, error) {
@@ -235,17 +301,27 @@ func (con *container) Wait() (*c.InspectContainerData, error) {
 }
 
 func (con *container) X() circuit.X {
-	return circuit.Ref(XContainer{con})
+	return circuit.Ref(XContainer{con: con})
+}
+
+func (con *container) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XContainer{con: con, guard: g})
 }
 
 // ef is the element factory for the container element
 func ef(t *anchor.Terminal, arg any) (anchor.Element, error) {
-	opts, ok := arg.(c.ContainerCreateOptions)
-	if !ok {
+	var x *container
+	var err error
+	switch opts := arg.(type) {
+	case c.ContainerCreateOptions:
+		if x, err = makeContainer(t, &opts); err == nil {
+			x.scrub = opts.Scrub
+		}
+	case *Import:
+		x, err = importContainer(t, opts)
+	default:
 		return nil, fmt.Errorf("invalid argument to container element factory, arg=%T", arg)
 	}
-
-	x, err := makeContainer(&opts)
 	if err != nil {
 		return nil, err
 	}
@@ -253,7 +329,7 @@ func ef(t *anchor.Terminal, arg any) (anchor.Element, error) {
 		defer func() {
 			recover()
 		}()
-		if opts.Scrub {
+		if x.scrub {
 			defer t.Scrub()
 		}
 		x.Wait()
@@ -0,0 +1,139 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	c "github.com/gocircuit/circuit/client/podman"
+	"github.com/gocircuit/circuit/element/podman"
+)
+
+// fakeBackend records the calls made by a container element
+type fakeBackend struct {
+	podman.Backend
+	sync.Mutex
+	since []time.Time // since of each event stream
+	calls []string    // other calls, with their arguments
+	wait  chan error
+	fail  error // error of restores
+}
+
+func (b *fakeBackend) call(format string, arg ...interface{}) {
+	b.Lock()
+	defer b.Unlock()
+	b.calls = append(b.calls, fmt.Sprintf(format, arg...))
+}
+
+// ContainerEvents ends the first stream after one event
+func (b *fakeBackend) ContainerEvents(name string, since time.Time, stop <-chan struct{}, fn func(*c.ContainerEvent)) error {
+	b.Lock()
+	b.since = append(b.since, since)
+	n := len(b.since)
+	b.Unlock()
+	fn(&c.ContainerEvent{Status: c.EventStart, Time: time.Now()})
+	if n == 1 {
+		return errors.New("stream ended")
+	}
+	<-stop
+	return nil
+}
+
+func (b *fakeBackend) streams() int {
+	b.Lock()
+	defer b.Unlock()
+	return len(b.since)
+}
+
+func (b *fakeBackend) ContainerRemove(name string, opts *c.ContainerRemoveOptions) error {
+	b.call("remove %s", name)
+	return nil
+}
+
+func (b *fakeBackend) ContainerRename(name, newName string) error {
+	b.call("rename %s %s", name, newName)
+	return nil
+}
+
+func (b *fakeBackend) ContainerRestore(name string, opts *c.ContainerRestoreOptions) error {
+	b.call("restore %q import=%s name=%s", name, opts.Import, opts.Name)
+	return b.fail
+}
+
+func (b *fakeBackend) ContainerInspect(name string) (*c.InspectContainerData, error) {
+	return &c.InspectContainerData{ID: "restored"}, nil
+}
+
+func (b *fakeBackend) ContainerWait(name string) error {
+	return <-b.wait
+}
+
+func TestEventsRestart(t *testing.T) {
+	be := &fakeBackend{}
+	start := time.Now()
+	con := newContainer(nil, be, "test", "id", start)
+	defer con.close()
+
+	// The first stream ends, and a later subscription restarts it from
+	// where it ended
+	for i := 0; be.streams() < 2; i++ {
+		if i == 100 {
+			t.Fatalf("event stream not restarted")
+		}
+		con.Events()
+		time.Sleep(10 * time.Millisecond)
+	}
+	be.Lock()
+	defer be.Unlock()
+	if !be.since[0].Equal(start) || !be.since[1].After(start) {
+		t.Fatalf("event streams since %v", be.since)
+	}
+}
+
+// A failed migration restores the container from its exported checkpoint,
+// before it replaces the checkpointed one
+func TestRollback(t *testing.T) {
+	be := &fakeBackend{wait: make(chan error, 1)}
+	con := newContainer(nil, be, "test", "id", time.Now())
+	defer con.close()
+
+	if restored, err := con.rollback("/tmp/test.tar.gz", errors.New("target down")); !restored || err == nil {
+		t.Fatalf("rollback of a failed migration: restored %v, error %v", restored, err)
+	}
+	be.Lock()
+	calls := be.calls
+	be.Unlock()
+	want := []string{`restore "" import=/tmp/test.tar.gz name=test-rollback`, "remove test", "rename test-rollback test"}
+	if !reflect.DeepEqual(calls, want) || con.id != "restored" {
+		t.Fatalf("rollback calls %q, id %s", calls, con.id)
+	}
+
+	// The exit of the restored container is awaited
+	be.wait <- nil
+	select {
+	case <-con.exit:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("exit of restored container not awaited")
+	}
+}
+
+// A failed local restore keeps the checkpointed container, and names the archive
+func TestRollbackFailed(t *testing.T) {
+	be := &fakeBackend{fail: errors.New("criu failed")}
+	con := newContainer(nil, be, "test", "id", time.Now())
+	defer con.close()
+
+	restored, err := con.rollback("/tmp/test.tar.gz", errors.New("target down"))
+	if restored || err == nil || !strings.Contains(err.Error(), "/tmp/test.tar.gz") {
+		t.Fatalf("failed rollback: restored %v, error %v", restored, err)
+	}
+	be.Lock()
+	defer be.Unlock()
+	if want := []string{`restore "" import=/tmp/test.tar.gz name=test-rollback`}; !reflect.DeepEqual(be.calls, want) {
+		t.Fatalf("failed rollback calls %q", be.calls)
+	}
+}
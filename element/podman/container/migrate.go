@@ -0,0 +1,218 @@
+package container
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gocircuit/circuit/anchor"
+	c "github.com/gocircuit/circuit/client/podman"
+	"github.com/gocircuit/circuit/element"
+	"github.com/gocircuit/circuit/element/podman"
+	"github.com/gocircuit/circuit/kit/interruptible"
+	xio "github.com/gocircuit/circuit/kit/x/io"
+	"github.com/gocircuit/circuit/use/circuit"
+)
+
+// Import is the argument to the container element factory which restores
+// a container from a checkpoint archive, read from a remote cross-reader.
+// It is used by Migrate to recreate a container on the target server.
+type Import struct {
+	Name    string    // Name of the container
+	Archive circuit.X // Cross-interface to an io.ReadCloser of the exported checkpoint
+	Scrub   bool      // Scrub the anchor when the container exits
+}
+
+func init() {
+	gob.Register(&Import{})
+}
+
+// Migrate checkpoints the container, restores it on the server with the given ID
+// under the same anchor path and scrubs this element. If the container cannot be
+// restored on the target, it is restored here. Migrate returns the path of the new anchor.
+//
+// NOTE: like CheckPoint, this requires root and criu on both hosts.
+func (con *container) Migrate(server string) (string, error) {
+	if con.term == nil {
+		return "", errors.New("container is not attached to an anchor")
+	}
+	server = strings.Trim(server, "/")
+	walk := strings.Split(strings.TrimPrefix(con.term.Path(), "/"), "/")
+	if walk[0] == server {
+		return "", fmt.Errorf("container already hosted on server %s", server)
+	}
+	target, ok := con.term.Peer(server)
+	if !ok {
+		return "", fmt.Errorf("server %s not found", server)
+	}
+	moved := "/" + path.Join(append([]string{server}, walk[1:]...)...)
+
+	dir, err := os.MkdirTemp("", "circuit-migrate-")
+	if err != nil {
+		return "", err
+	}
+	// The archive outlives a migration which leaves the container nowhere.
+	var keep bool
+	defer func() {
+		if !keep {
+			os.RemoveAll(dir)
+		}
+	}()
+	archive := filepath.Join(dir, con.name+".tar.gz")
+
+	con.run.Lock()
+	con.run.gen++
+	con.run.Unlock()
+	if err = con.be.ContainerCheckpoint(con.name, &c.ContainerCheckpointOptions{Export: archive}); err != nil {
+		con.resume()
+		return "", fmt.Errorf("checkpoint of %s failed: %v", con.name, err)
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		keep, err = con.rollback(archive, err)
+		return "", err
+	}
+	imp := &Import{
+		Name:    con.name,
+		Archive: xio.NewXReadCloser(f),
+		Scrub:   con.scrub,
+	}
+	err = importAt(target.Walk(walk[1:]), imp)
+	f.Close()
+	if err != nil && !errors.Is(err, anchor.ErrBusy) {
+		// The target may have restored the container before the call failed
+		switch hosted, perr := con.hostedAt(target.Walk(walk[1:])); {
+		case perr != nil:
+			keep = true
+			return "", fmt.Errorf("migration of %s to server %s failed (%v), and whether the target restored it is unknown (%v); the checkpoint is kept at %s",
+				con.name, server, err, perr, archive)
+		case hosted:
+			log.Printf("container %s migrated to server %s, despite %v", con.name, server, err)
+			err = nil
+		}
+	}
+	if err != nil {
+		keep, err = con.rollback(archive, err)
+		return "", err
+	}
+
+	log.Printf("container %s migrated to server %s", con.name, server)
+	con.term.Scrub()
+	return moved, nil
+}
+
+// importAt makes the container of imp at anchor t, reporting a failed call as an error.
+func importAt(t anchor.YTerminal, imp *Import) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	_, err = t.Make(anchor.Container, imp)
+	return err
+}
+
+// hostedAt reports whether anchor t holds a container named like this one,
+// or an error if t cannot be asked.
+func (con *container) hostedAt(t anchor.YTerminal) (hosted bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			hosted, err = false, fmt.Errorf("%v", r)
+		}
+	}()
+	kind, y := t.Get()
+	other, ok := y.(c.Container)
+	if kind != anchor.Container || !ok {
+		return false, nil
+	}
+	data, err := other.Peek()
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimPrefix(data.Name, "/") == con.name, nil
+}
+
+// rollback restores the container locally from its exported checkpoint
+// archive after a failed migration, and reports whether it did. Podman keeps
+// no checkpoint of its own after an export, and the checkpointed container
+// holds the name that the restored one needs. So the container is restored
+// under a temporary name, and replaces the checkpointed one only then. If
+// the restore fails, the checkpointed container is kept, and the error
+// names the archive, which the caller must keep too.
+func (con *container) rollback(archive string, cause error) (bool, error) {
+	tmp := con.name + "-rollback"
+	if err := con.be.ContainerRestore("", &c.ContainerRestoreOptions{Import: archive, Name: tmp}); err != nil {
+		return false, fmt.Errorf("migration of %s failed (%v), restoring it locally failed too (%v); the checkpoint is kept at %s",
+			con.name, cause, err, archive)
+	}
+	con.be.ContainerRemove(con.name, &c.ContainerRemoveOptions{Force: true, Ignore: true})
+	if err := con.be.ContainerRename(tmp, con.name); err != nil {
+		log.Printf("container %s restored as %s: %v", con.name, tmp, err)
+		con.name = tmp
+	}
+	if data, err := con.be.ContainerInspect(con.name); err == nil {
+		con.id = data.ID
+	}
+	con.resume()
+	return true, fmt.Errorf("migration of %s failed, container restored locally: %v", con.name, cause)
+}
+
+// resume waits for the exit of a container whose run outlived a checkpoint.
+func (con *container) resume() {
+	gen := con.generation()
+	go func() {
+		err := con.be.ContainerWait(con.name)
+		if gen == con.generation() {
+			con.done(err)
+		}
+	}()
+}
+
+// importContainer restores a container from a migrated checkpoint archive.
+func importContainer(t *anchor.Terminal, imp *Import) (*container, error) {
+	be, err := podman.Engine()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "circuit-migrate-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	archive := filepath.Join(dir, imp.Name+".tar.gz")
+	if err = podman.ReceiveFile(archive, xio.NewYReadCloser(imp.Archive)); err != nil {
+		return nil, fmt.Errorf("receiving checkpoint of %s: %v", imp.Name, err)
+	}
+
+	since := time.Now()
+	if err = be.ContainerRestore("", &c.ContainerRestoreOptions{Import: archive, Name: imp.Name}); err != nil {
+		return nil, err
+	}
+	data, err := be.ContainerInspect(imp.Name)
+	if err != nil {
+		be.ContainerRemove(imp.Name, &c.ContainerRemoveOptions{Force: true, Ignore: true})
+		return nil, err
+	}
+
+	con := newContainer(t, be, imp.Name, data.ID, since)
+	con.scrub = imp.Scrub
+
+	// The standard streams of a restored container are not attached.
+	var stdout, stderr io.WriteCloser
+	con.stdout, stdout = interruptible.BufferPipe(element.StdBufferLen)
+	con.stderr, stderr = interruptible.BufferPipe(element.StdBufferLen)
+	stdout.Close()
+	stderr.Close()
+
+	con.resume()
+	return con, nil
+}
@@ -3,7 +3,9 @@ package container
 import (
 	"io"
 
+	"github.com/gocircuit/circuit/anchor"
 	c "github.com/gocircuit/circuit/client/podman"
+	"github.com/gocircuit/circuit/kit/pubsub"
 	xio "github.com/gocircuit/circuit/kit/x/io"
 	"github.com/gocircuit/circuit/use/circuit"
 	"github.com/gocircuit/circuit/use/errors"
@@ -15,107 +17,164 @@ func init() {
 
 // XContainer is a circuit container that wraps a podman container skeleton impl.
 type XContainer struct {
-	Container
+	con   Container
+	guard anchor.Guard
+}
+
+// containerVerbs are the verbs of the methods of XContainer. Methods running
+// commands in the container make them, and methods changing the state of
+// its processes signal them.
+var containerVerbs = anchor.Verbs{
+	"CheckPoint": anchor.Signal,
+	"Exec":       anchor.Make,
+	"Inspect":    anchor.Get,
+	"IsDone":     anchor.Get,
+	"Pause":      anchor.Signal,
+	"Peek":       anchor.Get,
+	"PeekBytes":  anchor.Get,
+	"Stats":      anchor.Get,
+	"Events":     anchor.Get,
+	"Migrate":    anchor.Scrub,
+	"Ports":      anchor.Get,
+	"Restore":    anchor.Signal,
+	"RunLabel":   anchor.Make,
+	"Scrub":      anchor.Scrub,
+	"Start":      anchor.Signal,
+	"Stop":       anchor.Signal,
+	"Unpause":    anchor.Signal,
+	"Signal":     anchor.Signal,
+	"Wait":       anchor.Get,
+	"Stdin":      anchor.Stdin,
+	"Stdout":     anchor.Get,
+	"Stderr":     anchor.Get,
+}
+
+func (x XContainer) Authorize(method string) error {
+	return x.guard.Authorize(containerVerbs, method)
 }
 
 // Checkpoint the container
 func (x XContainer) CheckPoint(opts *c.ContainerCheckpointOptions) error {
-	return errors.Pack(x.Container.CheckPoint(opts))
+	return errors.Pack(x.con.CheckPoint(opts))
 }
 
 // Clone an existing container
 // func (x XContainer) Clone(opts *c.ContainerCloneOptions) (c.Container, error) {
-// 	c, err := x.Container.Clone(opts)
+// 	c, err := x.con.Clone(opts)
 // 	return c, errors.Pack(err)
 // }
 
 // Exec runs a process in the container
 func (x XContainer) Exec(opts *c.ContainerExecOptions) ([]byte, error) {
-	b, err := x.Container.Exec(opts)
+	b, err := x.con.Exec(opts)
 	return b, errors.Pack(err)
 }
 
 // Inspect the configuration of the container
 func (x XContainer) Inspect() (*c.InspectContainerData, error) {
-	s, err := x.Container.Inspect()
+	s, err := x.con.Inspect()
 	return s, errors.Pack(err)
 }
 
 func (x XContainer) IsDone() bool {
-	return x.Container.IsDone()
+	return x.con.IsDone()
 }
 
 // Pause the processes in the container
 func (x XContainer) Pause() error {
-	return errors.Pack(x.Container.Pause())
+	return errors.Pack(x.con.Pause())
 }
 
-// Stats()
-// Peek at the container's resource ussage statistics
+// Peek at the container's configuration and state
 func (x XContainer) Peek() (*c.InspectContainerData, error) {
-	c, err := x.Container.Peek()
+	c, err := x.con.Peek()
 	return c, errors.Pack(err)
 }
 
 func (x XContainer) PeekBytes() []byte {
-	return x.Container.PeekBytes()
+	return x.con.PeekBytes()
+}
+
+// Stats subscribes to the container's resource usage statistics
+func (x XContainer) Stats() circuit.X {
+	return circuit.Ref(x.con.Stats())
+}
+
+// Events subscribes to the container's lifecycle events
+func (x XContainer) Events() circuit.X {
+	return circuit.Ref(x.con.Events())
+}
+
+// Migrate the container to another circuit server. The target server sees
+// this server make the container, so the caller must be allowed to make it
+// at its new anchor here.
+func (x XContainer) Migrate(server string) (string, error) {
+	if err := x.guard.On(server).Check(anchor.Make); err != nil {
+		return "", errors.Pack(err)
+	}
+	p, err := x.con.Migrate(server)
+	return p, errors.Pack(err)
 }
 
 // Get port mappings
 func (x XContainer) Ports() []string {
-	return x.Container.Ports()
+	return x.con.Ports()
 }
 
 // Restore the container from a checkpoint
 func (x XContainer) Restore(opts *c.ContainerRestoreOptions) error {
-	return errors.Pack(x.Container.Restore(opts))
+	return errors.Pack(x.con.Restore(opts))
 }
 
 // RunLabel runs the command specified in the label
 func (x XContainer) RunLabel() error {
-	return errors.Pack(x.Container.RunLabel())
+	return errors.Pack(x.con.RunLabel())
 }
 
 // Scrub removes the container
 func (x XContainer) Scrub() {
-	x.Container.Scrub()
+	x.con.Scrub()
+	x.guard.Audit(anchor.Scrub, nil, nil)
 }
 
 // Start the container
 func (x XContainer) Start() error {
-	return errors.Pack(x.Container.Start())
+	return errors.Pack(x.con.Start())
 }
 
 // Stop the container
 func (x XContainer) Stop(opts *c.ContainerStopOpts) error {
-	return errors.Pack(x.Container.Stop(opts))
+	return errors.Pack(x.con.Stop(opts))
 }
 
 // Unpause the processes in the container
 func (x XContainer) Unpause() error {
-	return errors.Pack(x.Container.Unpause())
+	return errors.Pack(x.con.Unpause())
 }
 
 // Kill the container with the specified signal
 func (x XContainer) Signal(sig string) error {
-	return errors.Pack(x.Container.Signal(sig))
+	err := x.con.Signal(sig)
+	x.guard.Audit(anchor.Signal, sig, err)
+	return errors.Pack(err)
 }
 
 // Wait for the container to exit
 func (x XContainer) Wait() (*c.InspectContainerData, error) {
-	s, err := x.Container.Wait()
+	s, err := x.con.Wait()
 	return s, errors.Pack(err)
 }
 
 func (x XContainer) Stdin() circuit.X {
-	return xio.NewXWriteCloser(x.Container.Stdin())
+	x.guard.Audit(anchor.Stdin, nil, nil)
+	return xio.NewXWriteCloser(x.con.Stdin())
 }
 func (x XContainer) Stdout() circuit.X {
-	return xio.NewXReadCloser(x.Container.Stdout())
+	return xio.NewXReadCloser(x.con.Stdout())
 
 }
 func (x XContainer) Stderr() circuit.X {
-	return xio.NewXReadCloser(x.Container.Stderr())
+	return xio.NewXReadCloser(x.con.Stderr())
 }
 
 // YContainer is a circuit container that wraps a podman container stub/proxy impl.
@@ -165,12 +224,26 @@ func (y YContainer) PeekBytes() []byte {
 	return y.X.Call("PeekBytes")[0].([]byte)
 }
 
+func (y YContainer) Stats() c.Subscription {
+	return pubsub.YSubscription{X: y.X.Call("Stats")[0].(circuit.X)}
+}
+
+func (y YContainer) Events() c.Subscription {
+	return pubsub.YSubscription{X: y.X.Call("Events")[0].(circuit.X)}
+}
+
+func (y YContainer) Migrate(server string) (string, error) {
+	r := y.X.Call("Migrate", server)
+	p, _ := r[0].(string)
+	return p, errors.Unpack(r[1])
+}
+
 func (y YContainer) Ports() []string {
 	return y.X.Call("Ports")[0].([]string)
 }
 
 func (y YContainer) Restore(opts *c.ContainerRestoreOptions) error {
-	r := y.X.Call("Restore")
+	r := y.X.Call("Restore", opts)
 	return errors.Unpack(r[0])
 }
 
@@ -189,7 +262,7 @@ func (y YContainer) Start() error {
 }
 
 func (y YContainer) Stop(opts *c.ContainerStopOpts) error {
-	r := y.X.Call("Stop")
+	r := y.X.Call("Stop", opts)
 	return errors.Unpack(r[0])
 }
 
@@ -0,0 +1,88 @@
+package podman
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	c "github.com/gocircuit/circuit/client/podman"
+)
+
+// event is the union of the event encodings of the podman command
+// (podman events --format json) and of the libpod events API.
+type event struct {
+	Type              string            `json:"Type"`
+	Status            string            `json:"Status"`
+	Action            string            `json:"Action"`
+	Time              json.RawMessage   `json:"Time"`
+	TimeNano          int64             `json:"timeNano"`
+	ContainerExitCode *int              `json:"ContainerExitCode"`
+	HealthStatus      string            `json:"HealthStatus"`
+	Attributes        map[string]string `json:"Attributes"`
+	Actor             struct {
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+}
+
+// parseEvent decodes a container event. It returns false for events that
+// are not one of the statuses published by container elements.
+func parseEvent(b []byte) (*c.ContainerEvent, bool) {
+	var e event
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, false
+	}
+	if e.Type != "" && e.Type != "container" {
+		return nil, false
+	}
+	status := e.Status
+	if status == "" {
+		status = e.Action
+	}
+	switch status {
+	case "died":
+		status = c.EventDie
+	case c.EventStart, c.EventDie, c.EventOOM, c.EventHealthStatus:
+	default:
+		return nil, false
+	}
+	ev := &c.ContainerEvent{
+		Status:       status,
+		HealthStatus: e.HealthStatus,
+		Attributes:   e.Attributes,
+	}
+	if ev.Attributes == nil {
+		ev.Attributes = e.Actor.Attributes
+	}
+	switch {
+	case e.TimeNano != 0:
+		ev.Time = time.Unix(0, e.TimeNano)
+	case bytes.HasPrefix(e.Time, []byte(`"`)):
+		json.Unmarshal(e.Time, &ev.Time)
+	case len(e.Time) > 0:
+		if sec, err := strconv.ParseInt(string(e.Time), 10, 64); err == nil {
+			ev.Time = time.Unix(sec, 0)
+		}
+	}
+	if e.ContainerExitCode != nil {
+		ev.ExitCode = *e.ContainerExitCode
+	} else if code, ok := ev.Attributes["containerExitCode"]; ok {
+		ev.ExitCode, _ = strconv.Atoi(code)
+	}
+	return ev, true
+}
+
+// parseStats decodes a stats sample, skipping any terminal control
+// sequences podman may print ahead of it.
+func parseStats(b []byte) (*c.ContainerStats, bool) {
+	i := bytes.IndexByte(b, '{')
+	if i < 0 {
+		return nil, false
+	}
+	s := &c.ContainerStats{}
+	if err := json.Unmarshal(b[i:], s); err != nil {
+		return nil, false
+	}
+	s.Time = time.Now()
+	return s, true
+}
@@ -0,0 +1,88 @@
+package podman
+
+import (
+	"sync"
+
+	"github.com/gocircuit/circuit/kit/pubsub"
+)
+
+// Feed publishes a stream of values of a podman element to subscribers.
+// A feed which keeps its last value replays it to new subscribers.
+type Feed struct {
+	ps   *pubsub.PubSub
+	keep bool
+	pub  sync.Mutex // serializes publishing against closing
+	sync.Mutex
+	last      interface{}
+	closed    bool
+	streaming bool
+}
+
+// NewFeed creates a feed for the named event source.
+func NewFeed(name string, keep bool) *Feed {
+	f := &Feed{keep: keep}
+	f.ps = pubsub.New(name, f.summarize)
+	return f
+}
+
+// Source returns the name of the feed.
+func (f *Feed) Source() string {
+	return f.ps.Source()
+}
+
+func (f *Feed) summarize() []interface{} {
+	f.Lock()
+	defer f.Unlock()
+	if f.last == nil {
+		return nil
+	}
+	return []interface{}{f.last}
+}
+
+// Publish sends v to all subscribers. Values published after Close are dropped.
+func (f *Feed) Publish(v interface{}) {
+	f.pub.Lock()
+	defer f.pub.Unlock()
+	f.Lock()
+	closed := f.closed
+	if f.keep {
+		f.last = v
+	}
+	f.Unlock()
+	if !closed {
+		f.ps.Publish(v)
+	}
+}
+
+// Close ends the stream for all subscribers.
+func (f *Feed) Close() {
+	f.pub.Lock()
+	defer f.pub.Unlock()
+	f.Lock()
+	f.closed = true
+	f.Unlock()
+	f.ps.Close()
+}
+
+// Start marks the feed as being fed by a stream and reports whether it was idle.
+func (f *Feed) Start() bool {
+	f.Lock()
+	defer f.Unlock()
+	if f.streaming || f.closed {
+		return false
+	}
+	f.streaming = true
+	return true
+}
+
+// Done marks the end of the stream feeding f.
+func (f *Feed) Done() {
+	f.Lock()
+	defer f.Unlock()
+	f.streaming = false
+}
+
+// Subscribe returns a new subscription to the feed.
+func (f *Feed) Subscribe() *pubsub.Subscription {
+	return f.ps.Subscribe()
+}
@@ -0,0 +1,321 @@
+package image
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gocircuit/circuit/anchor"
+	c "github.com/gocircuit/circuit/client/podman"
+	"github.com/gocircuit/circuit/element/docker"
+	"github.com/gocircuit/circuit/element/podman"
+	xio "github.com/gocircuit/circuit/kit/x/io"
+	"github.com/gocircuit/circuit/use/circuit"
+)
+
+type Image interface {
+	c.Image
+	X() circuit.X
+
+	// Receive loads the image from an archive sent by another circuit server.
+	Receive(archive circuit.X) error
+}
+
+type image struct {
+	term     *anchor.Terminal
+	be       podman.Backend
+	opts     c.ImageCreateOptions
+	progress *podman.Feed
+}
+
+// Import is the argument to the image element factory which loads an image
+// from an archive, read from a remote cross-reader. It is used by Push to
+// copy an image to other servers.
+type Import struct {
+	c.ImageCreateOptions
+	Archive circuit.X // Cross-interface to an io.ReadCloser of the saved image
+}
+
+func init() {
+	gob.Register(&Import{})
+	anchor.RegisterElement(anchor.Image, ef, yf)
+}
+
+// backend returns the backend managing images of the given engine.
+func backend(engine string) (podman.Backend, error) {
+	switch engine {
+	case "", c.EnginePodman:
+		return podman.Engine()
+	case c.EngineDocker:
+		path := docker.Path()
+		if path == "" {
+			return nil, errors.New("docker not enabled on this server")
+		}
+		return podman.NewCLI(path), nil
+	}
+	return nil, fmt.Errorf("unknown image engine %q", engine)
+}
+
+func MakeImage(t *anchor.Terminal, opts c.ImageCreateOptions) (Image, error) {
+	if opts.Name == "" {
+		return nil, errors.New("image element requires an image name")
+	}
+	be, err := backend(opts.Engine)
+	if err != nil {
+		return nil, err
+	}
+	return &image{
+		term:     t,
+		be:       be,
+		opts:     opts,
+		progress: podman.NewFeed("progress", false),
+	}, nil
+}
+
+// importImage creates an image element holding an image sent by Push.
+func importImage(t *anchor.Terminal, imp *Import) (Image, error) {
+	img, err := MakeImage(t, imp.ImageCreateOptions)
+	if err != nil {
+		return nil, err
+	}
+	if err = img.Receive(imp.Archive); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+func (img *image) Build(opts *c.ImageBuildOptions) error {
+	err := img.be.ImageBuild(img.opts.Name, opts, img.report(c.ImageBuild))
+	img.done(c.ImageBuild, "", err)
+	return err
+}
+
+func (img *image) Exists() error {
+	return img.be.ImageExists(img.opts.Name)
+}
+
+func (img *image) Inspect() (*c.InspectImageData, error) {
+	return img.be.ImageInspect(img.opts.Name)
+}
+
+func (img *image) Load(file string) error {
+	return img.be.ImageLoad(file)
+}
+
+func (img *image) PeekBytes() []byte {
+	data, err := img.Inspect()
+	if err != nil {
+		return []byte{}
+	}
+
+	b, _ := json.MarshalIndent(data, "", "\t")
+	return b
+}
+
+func (img *image) Progress() c.Subscription {
+	return img.progress.Subscribe()
+}
+
+func (img *image) Pull(opts *c.ImagePullOptions) error {
+	if img.opts.Engine == c.EngineDocker {
+		if err := dockerPull(opts); err != nil {
+			return err
+		}
+	}
+	err := img.be.ImagePull(img.opts.Name, opts, img.report(c.ImagePull))
+	img.done(c.ImagePull, "", err)
+	return err
+}
+
+// dockerPull reports an error if opts hold options that docker pull lacks
+func dockerPull(opts *c.ImagePullOptions) error {
+	if opts.Policy != "" {
+		return errors.New("the docker engine has no pull policy option")
+	}
+	if opts.TLSVerify != nil {
+		return errors.New("the docker engine verifies registries per its daemon configuration, not per pull")
+	}
+	return nil
+}
+
+// Push saves the image once and sends the archive to all servers concurrently.
+func (img *image) Push(servers ...string) error {
+	if img.term == nil {
+		return errors.New("image is not attached to an anchor")
+	}
+	if len(servers) == 0 {
+		return errors.New("no servers to push to")
+	}
+	dir, err := os.MkdirTemp("", "circuit-image-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	archive := filepath.Join(dir, "image.tar")
+	if err = img.be.ImageSave(img.opts.Name, archive); err != nil {
+		return err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []string
+	)
+	for _, server := range servers {
+		server = strings.Trim(server, "/")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := img.push(archive, server)
+			img.done(c.ImagePush, server, err)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Sprintf("%s: %v", server, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return fmt.Errorf("push of %s failed on %s", img.opts.Name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// push sends the image archive to the image element under the same anchor path
+// on server, creating the element if the anchor is empty.
+func (img *image) push(archive, server string) error {
+	walk := strings.Split(strings.TrimPrefix(img.term.Path(), "/"), "/")
+	if walk[0] == server {
+		return errors.New("image already hosted on this server")
+	}
+	target, ok := img.term.Peer(server)
+	if !ok {
+		return errors.New("server not found")
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r := &counter{File: f, total: fi.Size(), report: func(n, total int64) {
+		img.progress.Publish(&c.ImageProgress{Op: c.ImagePush, Server: server, Bytes: n, Total: total})
+	}}
+	x := xio.NewXReadCloser(r)
+
+	t := target.Walk(walk[1:])
+	switch kind, y := t.Get(); kind {
+	case "":
+		_, err = t.Make(anchor.Image, &Import{ImageCreateOptions: img.opts, Archive: x})
+	case anchor.Image:
+		err = y.(YImage).Receive(x)
+	default:
+		err = fmt.Errorf("anchor holds a %s element", kind)
+	}
+	f.Close()
+	if err == nil {
+		log.Printf("image %s pushed to server %s", img.opts.Name, server)
+	}
+	return err
+}
+
+func (img *image) Receive(archive circuit.X) error {
+	dir, err := os.MkdirTemp("", "circuit-image-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "image.tar")
+	if err = podman.ReceiveFile(file, xio.NewYReadCloser(archive)); err != nil {
+		return fmt.Errorf("receiving image %s: %v", img.opts.Name, err)
+	}
+	return img.be.ImageLoad(file)
+}
+
+func (img *image) Save(file string) error {
+	return img.be.ImageSave(img.opts.Name, file)
+}
+
+func (img *image) Scrub() {
+	img.progress.Close()
+	if img.opts.Scrub {
+		img.be.ImageRemove(img.opts.Name)
+	}
+}
+
+func (img *image) Tag(tags ...string) error {
+	for _, tag := range tags {
+		if err := img.be.ImageTag(img.opts.Name, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (img *image) X() circuit.X {
+	return circuit.Ref(XImage{img: img})
+}
+
+func (img *image) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XImage{img: img, guard: g})
+}
+
+// report returns a function publishing the output lines of an operation.
+func (img *image) report(op string) func(string) {
+	return func(line string) {
+		img.progress.Publish(&c.ImageProgress{Op: op, Message: line})
+	}
+}
+
+// done publishes the completion of an operation.
+func (img *image) done(op, server string, err error) {
+	p := &c.ImageProgress{Op: op, Server: server, Done: true}
+	if err != nil {
+		p.Err = err.Error()
+	}
+	img.progress.Publish(p)
+}
+
+// counter reports the bytes read from an archive, at most once per reportEvery bytes.
+type counter struct {
+	*os.File
+	n, last, total int64
+	report         func(n, total int64)
+}
+
+const reportEvery = 4 << 20
+
+func (r *counter) Read(p []byte) (int, error) {
+	n, err := r.File.Read(p)
+	r.n += int64(n)
+	if r.n-r.last >= reportEvery || (r.n == r.total && r.n != r.last) {
+		r.last = r.n
+		r.report(r.n, r.total)
+	}
+	return n, err
+}
+
+func ef(t *anchor.Terminal, arg any) (anchor.Element, error) {
+	switch opts := arg.(type) {
+	case c.ImageCreateOptions:
+		return MakeImage(t, opts)
+	case *Import:
+		return importImage(t, opts)
+	}
+	return nil, fmt.Errorf("invalid argument to image element factory, arg=%T", arg)
+}
+
+func yf(x circuit.X) (any, error) {
+	return YImage{x}, nil
+}
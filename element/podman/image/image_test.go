@@ -0,0 +1,19 @@
+package image
+
+import (
+	"testing"
+
+	c "github.com/gocircuit/circuit/client/podman"
+)
+
+func TestDockerPull(t *testing.T) {
+	no := false
+	for _, opts := range []*c.ImagePullOptions{{Policy: "always"}, {TLSVerify: &no}} {
+		if dockerPull(opts) == nil {
+			t.Fatalf("docker pull accepts %+v", opts)
+		}
+	}
+	if err := dockerPull(&c.ImagePullOptions{Platform: "linux/arm64"}); err != nil {
+		t.Fatalf("docker pull refuses platform (%v)", err)
+	}
+}
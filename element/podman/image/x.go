@@ -0,0 +1,162 @@
+package image
+
+import (
+	"github.com/gocircuit/circuit/anchor"
+	c "github.com/gocircuit/circuit/client/podman"
+	"github.com/gocircuit/circuit/kit/pubsub"
+	"github.com/gocircuit/circuit/use/circuit"
+	"github.com/gocircuit/circuit/use/errors"
+)
+
+func init() {
+	circuit.RegisterValue(XImage{})
+}
+
+// X
+type XImage struct {
+	img   Image
+	guard anchor.Guard
+}
+
+// imageVerbs are the verbs of the methods of XImage. Methods changing the
+// image, or writing files on the server, make them. Push reads the image,
+// and checks that the caller may make it on the servers it pushes it to.
+var imageVerbs = anchor.Verbs{
+	"Build":     anchor.Make,
+	"Exists":    anchor.Get,
+	"Inspect":   anchor.Get,
+	"Load":      anchor.Make,
+	"PeekBytes": anchor.Get,
+	"Progress":  anchor.Get,
+	"Pull":      anchor.Make,
+	"Push":      anchor.Get,
+	"Receive":   anchor.Make,
+	"Save":      anchor.Make,
+	"Scrub":     anchor.Scrub,
+	"Tag":       anchor.Make,
+}
+
+func (x XImage) Authorize(method string) error {
+	return x.guard.Authorize(imageVerbs, method)
+}
+
+func (x XImage) Build(opts *c.ImageBuildOptions) error {
+	return errors.Pack(x.img.Build(opts))
+}
+
+func (x XImage) Exists() error {
+	return errors.Pack(x.img.Exists())
+}
+
+func (x XImage) Inspect() (*c.InspectImageData, error) {
+	r, err := x.img.Inspect()
+	return r, errors.Pack(err)
+}
+
+func (x XImage) Load(file string) error {
+	return errors.Pack(x.img.Load(file))
+}
+
+func (x XImage) PeekBytes() []byte {
+	return x.img.PeekBytes()
+}
+
+// Progress subscribes to the progress of the image operations
+func (x XImage) Progress() circuit.X {
+	return circuit.Ref(x.img.Progress())
+}
+
+func (x XImage) Pull(opts *c.ImagePullOptions) error {
+	return errors.Pack(x.img.Pull(opts))
+}
+
+// Push takes a slice, since cross-calls do not expand variadic arguments
+// Push checks that the caller may make the image on the servers, which see
+// this server make it
+func (x XImage) Push(servers []string) error {
+	for _, server := range servers {
+		if err := x.guard.On(server).Check(anchor.Make); err != nil {
+			return errors.Pack(err)
+		}
+	}
+	return errors.Pack(x.img.Push(servers...))
+}
+
+func (x XImage) Receive(archive circuit.X) error {
+	return errors.Pack(x.img.Receive(archive))
+}
+
+func (x XImage) Save(file string) error {
+	return errors.Pack(x.img.Save(file))
+}
+
+func (x XImage) Scrub() {
+	x.img.Scrub()
+	x.guard.Audit(anchor.Scrub, nil, nil)
+}
+
+func (x XImage) Tag(tags []string) error {
+	return errors.Pack(x.img.Tag(tags...))
+}
+
+type YImage struct {
+	X circuit.X
+}
+
+func (y YImage) Build(opts *c.ImageBuildOptions) error {
+	r := y.X.Call("Build", opts)
+	return errors.Unpack(r[0])
+}
+
+func (y YImage) Exists() error {
+	r := y.X.Call("Exists")
+	return errors.Unpack(r[0])
+}
+
+func (y YImage) Inspect() (*c.InspectImageData, error) {
+	r := y.X.Call("Inspect")
+	data, _ := r[0].(*c.InspectImageData)
+	return data, errors.Unpack(r[1])
+}
+
+func (y YImage) Load(file string) error {
+	r := y.X.Call("Load", file)
+	return errors.Unpack(r[0])
+}
+
+func (y YImage) PeekBytes() []byte {
+	return y.X.Call("PeekBytes")[0].([]byte)
+}
+
+func (y YImage) Progress() c.Subscription {
+	return pubsub.YSubscription{X: y.X.Call("Progress")[0].(circuit.X)}
+}
+
+func (y YImage) Pull(opts *c.ImagePullOptions) error {
+	r := y.X.Call("Pull", opts)
+	return errors.Unpack(r[0])
+}
+
+func (y YImage) Push(servers ...string) error {
+	r := y.X.Call("Push", servers)
+	return errors.Unpack(r[0])
+}
+
+func (y YImage) Receive(archive circuit.X) error {
+	r := y.X.Call("Receive", archive)
+	return errors.Unpack(r[0])
+}
+
+func (y YImage) Save(file string) error {
+	r := y.X.Call("Save", file)
+	return errors.Unpack(r[0])
+}
+
+func (y YImage) Scrub() {
+	y.X.Call("Scrub")
+}
+
+func (y YImage) Tag(tags ...string) error {
+	r := y.X.Call("Tag", tags)
+	return errors.Unpack(r[0])
+}
@@ -0,0 +1,954 @@
+package podman
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	c "github.com/gocircuit/circuit/client/podman"
+)
+
+// apiVersion is the libpod REST API version requested by the libpod backend.
+const apiVersion = "v4.0.0"
+
+// APIError is the structured error reported by the libpod REST API.
+type APIError struct {
+	Cause    string `json:"cause"`
+	Message  string `json:"message"`
+	Response int    `json:"response"`
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("libpod: %s (%d)", e.Cause, e.Response)
+	}
+	return fmt.Sprintf("libpod: %s (%d)", e.Message, e.Response)
+}
+
+// IsNotFound reports whether err is a libpod error for a missing object.
+func IsNotFound(err error) bool {
+	e, ok := err.(*APIError)
+	return ok && e.Response == http.StatusNotFound
+}
+
+// libpod is the backend which talks to the libpod REST API over the podman socket.
+type libpod struct {
+	sock string
+	http *http.Client
+}
+
+// NewLibpod returns a backend for the libpod REST API served on the unix socket sock,
+// after checking that the service responds. The socket may be given as a path or as a unix:// URL.
+func NewLibpod(sock string) (Backend, error) {
+	b := newLibpod(sock)
+	if err := b.Ping(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func newLibpod(sock string) *libpod {
+	sock = strings.TrimPrefix(sock, "unix://")
+	dial := func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", sock)
+	}
+	return &libpod{
+		sock: sock,
+		http: &http.Client{Transport: &http.Transport{DialContext: dial}},
+	}
+}
+
+// DefaultSocket returns the conventional location of the podman socket for this user.
+func DefaultSocket() string {
+	if os.Geteuid() != 0 {
+		if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+			return dir + "/podman/podman.sock"
+		}
+	}
+	return "/run/podman/podman.sock"
+}
+
+// Ping checks that the libpod service is reachable.
+func (b *libpod) Ping() error {
+	resp, err := b.http.Get("http://d/_ping")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+func (b *libpod) url(path string, query url.Values) string {
+	u := "http://d/" + apiVersion + "/libpod" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// do sends a request with a JSON (or raw, if body is an io.Reader) body and
+// returns the response if its status indicates success.
+func (b *libpod) do(method, path string, query url.Values, body any) (*http.Response, error) {
+	return b.doContext(context.Background(), method, path, query, body)
+}
+
+func (b *libpod) doContext(ctx context.Context, method, path string, query url.Values, body any) (*http.Response, error) {
+	var r io.Reader
+	var ctype string
+	switch v := body.(type) {
+	case nil:
+	case io.Reader:
+		r, ctype = v, "application/x-tar"
+	default:
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		r, ctype = bytes.NewReader(buf), "application/json"
+	}
+	req, err := http.NewRequestWithContext(ctx, method, b.url(path, query), r)
+	if err != nil {
+		return nil, err
+	}
+	if ctype != "" {
+		req.Header.Set("Content-Type", ctype)
+	}
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// call performs a request and decodes a JSON response into out, if out is not nil.
+func (b *libpod) call(method, path string, query url.Values, in, out any) error {
+	resp, err := b.do(method, path, query, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	e := &APIError{Response: resp.StatusCode}
+	buf, _ := io.ReadAll(resp.Body)
+	if json.Unmarshal(buf, e) != nil || e.Response == 0 {
+		e.Response, e.Cause = resp.StatusCode, strings.TrimSpace(string(buf))
+	}
+	return e
+}
+
+type idResponse struct {
+	ID       string   `json:"Id"`
+	Warnings []string `json:"Warnings,omitempty"`
+}
+
+func query(kv ...string) url.Values {
+	q := url.Values{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] != "" {
+			q.Set(kv[i], kv[i+1])
+		}
+	}
+	return q
+}
+
+func qbool(v bool) string {
+	if !v {
+		return ""
+	}
+	return "true"
+}
+
+func qint[T int | uint](v T) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(int(v))
+}
+
+// container
+
+func (b *libpod) ContainerCreate(opts *c.ContainerCreateOptions) (string, error) {
+	var r idResponse
+	if err := b.call("POST", "/containers/create", nil, containerSpec(opts), &r); err != nil {
+		return "", err
+	}
+	return r.ID, nil
+}
+
+func (b *libpod) ContainerCheckpoint(name string, opts *c.ContainerCheckpointOptions) error {
+	q := query(
+		"export", qbool(opts.Export != ""),
+		"createImage", opts.CreateImage,
+		"fileLocks", qbool(opts.FileLocks),
+		"ignoreRootFS", qbool(opts.IgnoreRootfs),
+		"ignoreVolumes", qbool(opts.IgnoreVolumes),
+		"leaveRunning", qbool(opts.LeaveRunning),
+	)
+	resp, err := b.do("POST", "/containers/"+url.PathEscape(name)+"/checkpoint", q, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if opts.Export == "" {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	f, err := os.Create(opts.Export)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type execCreate struct {
+	AttachStdout bool     `json:"AttachStdout"`
+	AttachStderr bool     `json:"AttachStderr"`
+	Cmd          []string `json:"Cmd"`
+	Env          []string `json:"Env,omitempty"`
+	Privileged   bool     `json:"Privileged,omitempty"`
+	User         string   `json:"User,omitempty"`
+	WorkingDir   string   `json:"WorkingDir,omitempty"`
+}
+
+type execInspect struct {
+	ExitCode int  `json:"ExitCode"`
+	Running  bool `json:"Running"`
+}
+
+func (b *libpod) ContainerExec(name string, opts *c.ContainerExecOptions) ([]byte, error) {
+	ec := execCreate{
+		AttachStdout: !opts.Detach,
+		AttachStderr: !opts.Detach,
+		Cmd:          append([]string{opts.Command}, opts.Args...),
+		Env:          opts.Env,
+		Privileged:   opts.Privileged,
+		User:         opts.User,
+		WorkingDir:   opts.WorkDir,
+	}
+	var r idResponse
+	if err := b.call("POST", "/containers/"+url.PathEscape(name)+"/exec", nil, ec, &r); err != nil {
+		return nil, err
+	}
+	start := map[string]bool{"Detach": opts.Detach, "Tty": false}
+	resp, err := b.do("POST", "/exec/"+url.PathEscape(r.ID)+"/start", nil, start)
+	if err != nil {
+		return nil, err
+	}
+	var stdout, stderr bytes.Buffer
+	err = demux(resp.Body, &stdout, &stderr)
+	resp.Body.Close()
+	if err != nil || opts.Detach {
+		return nil, err
+	}
+	var ei execInspect
+	if err = b.call("GET", "/exec/"+url.PathEscape(r.ID)+"/json", nil, nil, &ei); err != nil {
+		return nil, err
+	}
+	if ei.ExitCode != 0 {
+		return stdout.Bytes(), fmt.Errorf("exec exited with code %d: %s", ei.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func (b *libpod) ContainerInspect(name string) (*c.InspectContainerData, error) {
+	var data c.InspectContainerData
+	if err := b.call("GET", "/containers/"+url.PathEscape(name)+"/json", nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (b *libpod) ContainerKill(name string, sig string) error {
+	return b.call("POST", "/containers/"+url.PathEscape(name)+"/kill", query("signal", sig), nil, nil)
+}
+
+func (b *libpod) ContainerPause(name string) error {
+	return b.call("POST", "/containers/"+url.PathEscape(name)+"/pause", nil, nil, nil)
+}
+
+func (b *libpod) ContainerPorts(name string) ([]string, error) {
+	data, err := b.ContainerInspect(name)
+	if err != nil {
+		return nil, err
+	}
+	var r []string
+	if data.NetworkSettings == nil {
+		return r, nil
+	}
+	for port, hosts := range data.NetworkSettings.Ports {
+		for _, h := range hosts {
+			ip := h.HostIP
+			if ip == "" {
+				ip = "0.0.0.0"
+			}
+			r = append(r, fmt.Sprintf("%s -> %s:%s", port, ip, h.HostPort))
+		}
+	}
+	sort.Strings(r)
+	return r, nil
+}
+
+func (b *libpod) ContainerRemove(name string, opts *c.ContainerRemoveOptions) error {
+	q := query(
+		"depend", qbool(opts.Depend),
+		"force", qbool(opts.Force),
+		"ignore", qbool(opts.Ignore),
+		"timeout", qint(opts.Time),
+		"v", qbool(opts.Volume),
+	)
+	err := b.call("DELETE", "/containers/"+url.PathEscape(name), q, nil, nil)
+	if opts.Ignore && IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (b *libpod) ContainerRename(name, newName string) error {
+	return b.call("POST", "/containers/"+url.PathEscape(name)+"/rename", query("name", newName), nil, nil)
+}
+
+func (b *libpod) ContainerRestore(name string, opts *c.ContainerRestoreOptions) error {
+	q := query(
+		"fileLocks", qbool(opts.FileLocks),
+		"ignoreRootFS", qbool(opts.IgnoreRootfs),
+		"ignoreStaticIP", qbool(opts.IgnoreStaticIp),
+		"ignoreStaticMAC", qbool(opts.IgnoreStaticMac),
+		"ignoreVolumes", qbool(opts.IgnoreVolumes),
+		"import", qbool(opts.Import != ""),
+		"keep", qbool(opts.Keep),
+		"name", opts.Name,
+		"pod", opts.Pod,
+		"publishPorts", opts.Publish,
+		"tcpEstablished", qbool(opts.TcpEstablished),
+	)
+	if opts.Import == "" {
+		return b.call("POST", "/containers/"+url.PathEscape(name)+"/restore", q, nil, nil)
+	}
+	f, err := os.Open(opts.Import)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// The path segment is ignored by libpod when importing from an archive.
+	if name == "" {
+		name = "import"
+	}
+	return b.call("POST", "/containers/"+url.PathEscape(name)+"/restore", q, f, nil)
+}
+
+// ContainerStart attaches to the container before starting it, so that no
+// output is lost, and returns a wait function backed by the libpod wait endpoint.
+func (b *libpod) ContainerStart(name string, stdio Stdio) (func() error, error) {
+	data, err := b.ContainerInspect(name)
+	if err != nil {
+		return nil, err
+	}
+	var tty, stdin bool
+	if data.Config != nil {
+		tty, stdin = data.Config.Tty, data.Config.OpenStdin
+	}
+	stream, err := b.attach(name, stdin)
+	if err != nil {
+		return nil, err
+	}
+	if err = b.call("POST", "/containers/"+url.PathEscape(name)+"/start", nil, nil, nil); err != nil {
+		stream.Close()
+		return nil, err
+	}
+	if stdin && stdio.Stdin != nil {
+		go func() {
+			io.Copy(stream, stdio.Stdin)
+			if cw, ok := stream.(interface{ CloseWrite() error }); ok {
+				cw.CloseWrite()
+			}
+		}()
+	}
+	copied := make(chan error, 1)
+	go func() {
+		defer stream.Close()
+		if tty {
+			_, err := io.Copy(stdio.Stdout, stream)
+			copied <- err
+			return
+		}
+		copied <- demux(stream, stdio.Stdout, stdio.Stderr)
+	}()
+	return func() error {
+		err := b.ContainerWait(name)
+		<-copied
+		return err
+	}, nil
+}
+
+// attach opens a hijacked attach stream to the container.
+func (b *libpod) attach(name string, stdin bool) (io.ReadWriteCloser, error) {
+	q := query("stream", "true", "stdout", "true", "stderr", "true", "stdin", qbool(stdin))
+	req, err := http.NewRequest("POST", b.url("/containers/"+url.PathEscape(name)+"/attach", q), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkResponse(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if rwc, ok := resp.Body.(io.ReadWriteCloser); ok {
+		return rwc, nil
+	}
+	// Server did not upgrade the connection; output is still readable.
+	return readOnlyStream{resp.Body}, nil
+}
+
+func (b *libpod) ContainerWait(name string) error {
+	var code int
+	if err := b.call("POST", "/containers/"+url.PathEscape(name)+"/wait", nil, nil, &code); err != nil {
+		return err
+	}
+	if code != 0 {
+		return fmt.Errorf("container exited with code %d", code)
+	}
+	return nil
+}
+
+// stream issues a GET request for a streaming endpoint and decodes each JSON value
+// of the response with fn, until stop is closed or the response ends.
+func (b *libpod) stream(stop <-chan struct{}, path string, query url.Values, fn func(json.RawMessage)) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	resp, err := b.doContext(ctx, "GET", path, query, nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var v json.RawMessage
+		if err = dec.Decode(&v); err != nil {
+			if err == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		fn(v)
+	}
+}
+
+func (b *libpod) ContainerStats(name string, stop <-chan struct{}, fn func(*c.ContainerStats)) error {
+	q := query("containers", name, "stream", "true", "interval", "1")
+	return b.stream(stop, "/containers/stats", q, func(v json.RawMessage) {
+		var report struct {
+			Stats []json.RawMessage `json:"Stats"`
+		}
+		if json.Unmarshal(v, &report) != nil {
+			return
+		}
+		for _, r := range report.Stats {
+			if s, ok := parseStats(r); ok {
+				fn(s)
+			}
+		}
+	})
+}
+
+func (b *libpod) ContainerEvents(name string, since time.Time, stop <-chan struct{}, fn func(*c.ContainerEvent)) error {
+	filters, _ := json.Marshal(map[string][]string{"container": {name}, "type": {"container"}})
+	q := query("stream", "true", "since", strconv.FormatInt(since.Unix(), 10), "filters", string(filters))
+	return b.stream(stop, "/events", q, func(v json.RawMessage) {
+		if e, ok := parseEvent(v); ok {
+			fn(e)
+		}
+	})
+}
+
+func (b *libpod) ContainerStop(name string, opts *c.ContainerStopOpts) error {
+	q := query("ignore", qbool(opts.Ignore), "timeout", qint(opts.Time))
+	return b.call("POST", "/containers/"+url.PathEscape(name)+"/stop", q, nil, nil)
+}
+
+func (b *libpod) ContainerUnpause(name string) error {
+	return b.call("POST", "/containers/"+url.PathEscape(name)+"/unpause", nil, nil, nil)
+}
+
+// pod
+
+func (b *libpod) PodCreate(opts *c.PodCreateOptions) (string, error) {
+	var r idResponse
+	if err := b.call("POST", "/pods/create", nil, podSpec(opts), &r); err != nil {
+		return "", err
+	}
+	return r.ID, nil
+}
+
+func (b *libpod) PodClone(name string, opts *c.PodCloneOptions) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (b *libpod) PodExists(name string) error {
+	return b.call("GET", "/pods/"+url.PathEscape(name)+"/exists", nil, nil, nil)
+}
+
+func (b *libpod) PodGenerate(name string) ([]byte, error) {
+	resp, err := b.do("GET", "/generate/kube", query("names", name), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (b *libpod) PodInspect(name string) (*c.InspectPodData, error) {
+	var data c.InspectPodData
+	if err := b.call("GET", "/pods/"+url.PathEscape(name)+"/json", nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (b *libpod) PodKill(name string, sig string) error {
+	return b.call("POST", "/pods/"+url.PathEscape(name)+"/kill", query("signal", sig), nil, nil)
+}
+
+func (b *libpod) PodPause(name string) error {
+	return b.call("POST", "/pods/"+url.PathEscape(name)+"/pause", nil, nil, nil)
+}
+
+func (b *libpod) PodRemove(name string) error {
+	err := b.call("DELETE", "/pods/"+url.PathEscape(name), query("force", "true"), nil, nil)
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (b *libpod) PodRestart(name string) error {
+	return b.call("POST", "/pods/"+url.PathEscape(name)+"/restart", nil, nil, nil)
+}
+
+func (b *libpod) PodStart(name string, opts *c.PodStartOptions) error {
+	return b.call("POST", "/pods/"+url.PathEscape(name)+"/start", nil, nil, nil)
+}
+
+func (b *libpod) PodStop(name string, opts *c.PodStopOptions) error {
+	err := b.call("POST", "/pods/"+url.PathEscape(name)+"/stop", query("t", qint(opts.Time)), nil, nil)
+	if opts.Ignore && IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (b *libpod) PodUnpause(name string) error {
+	return b.call("POST", "/pods/"+url.PathEscape(name)+"/unpause", nil, nil, nil)
+}
+
+// network
+
+func (b *libpod) NetworkCreate(opts *c.NetworkCreateOptions) (string, error) {
+	var r struct {
+		ID string `json:"id"`
+	}
+	if err := b.call("POST", "/networks/create", nil, networkSpec(opts), &r); err != nil {
+		return "", err
+	}
+	return r.ID, nil
+}
+
+func (b *libpod) NetworkConnect(name string, opts *c.NetworkConnectOptions) error {
+	body := map[string]any{
+		"container":  opts.Container,
+		"aliases":    opts.Aliases,
+		"static_ips": nonEmpty(opts.StaticIP, opts.StaticIP6),
+		"static_mac": opts.StaticMAC,
+	}
+	return b.call("POST", "/networks/"+url.PathEscape(name)+"/connect", nil, body, nil)
+}
+
+func (b *libpod) NetworkDisconnect(name string, opts *c.NetworkDisconnectOptions) error {
+	body := map[string]any{"Container": opts.Container, "Force": opts.Force}
+	return b.call("POST", "/networks/"+url.PathEscape(name)+"/disconnect", nil, body, nil)
+}
+
+func (b *libpod) NetworkExists(name string) error {
+	return b.call("GET", "/networks/"+url.PathEscape(name)+"/exists", nil, nil, nil)
+}
+
+func (b *libpod) NetworkInspect(name string) (*c.InspectNetworkSettings, error) {
+	var data c.InspectNetworkSettings
+	if err := b.call("GET", "/networks/"+url.PathEscape(name)+"/json", nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (b *libpod) NetworkReload(name string) error {
+	return ErrNotSupported
+}
+
+func (b *libpod) NetworkRemove(name string, opts *c.NetworkRemoveOptions) error {
+	q := query("force", qbool(opts.Force), "timeout", qint(opts.Time))
+	return b.call("DELETE", "/networks/"+url.PathEscape(name), q, nil, nil)
+}
+
+func (b *libpod) NetworkUpdate(name string, opts *c.NetworkUpdateOptions) error {
+	return b.call("POST", "/networks/"+url.PathEscape(name)+"/update", nil, opts, nil)
+}
+
+// volume
+
+func (b *libpod) VolumeCreate(opts *c.VolumeCreateOptions) (string, error) {
+	body := map[string]any{
+		"Name":    opts.Name,
+		"Driver":  opts.Driver,
+		"Label":   keyValues(opts.Label),
+		"Options": keyValues(opts.Options),
+	}
+	var r struct {
+		Name string `json:"Name"`
+	}
+	err := b.call("POST", "/volumes/create", nil, body, &r)
+	if err != nil {
+		if e, ok := err.(*APIError); ok && opts.Ignore && e.Response == http.StatusConflict {
+			return opts.Name, nil
+		}
+		return "", err
+	}
+	return r.Name, nil
+}
+
+func (b *libpod) VolumeExists(name string) error {
+	return b.call("GET", "/volumes/"+url.PathEscape(name)+"/exists", nil, nil, nil)
+}
+
+func (b *libpod) VolumeExport(name string, opts c.VolumeExportOptions) error {
+	if opts.Output == "" {
+		return fmt.Errorf("volume export requires an output file")
+	}
+	resp, err := b.do("GET", "/volumes/"+url.PathEscape(name)+"/export", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	f, err := os.Create(opts.Output)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (b *libpod) VolumeImport(name string, source string) error {
+	if source == "" {
+		return fmt.Errorf("volume import requires a source file")
+	}
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return b.call("POST", "/volumes/"+url.PathEscape(name)+"/import", nil, f, nil)
+}
+
+func (b *libpod) VolumeInspect(name string) (*c.InspectVolumeData, error) {
+	var data c.InspectVolumeData
+	if err := b.call("GET", "/volumes/"+url.PathEscape(name)+"/json", nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (b *libpod) VolumeMount(name string) error {
+	return ErrNotSupported
+}
+
+func (b *libpod) VolumeReload(name string) error {
+	return ErrNotSupported
+}
+
+func (b *libpod) VolumeRemove(name string, opts *c.VolumeRemoveOptions) error {
+	q := query("force", qbool(opts.Force), "timeout", qint(opts.Time))
+	return b.call("DELETE", "/volumes/"+url.PathEscape(name), q, nil, nil)
+}
+
+func (b *libpod) VolumeUnmount(name string) error {
+	return ErrNotSupported
+}
+
+// image
+
+// progress sends a request to an endpoint which reports its progress as a stream
+// of JSON messages, passing the output lines of each message to fn.
+func (b *libpod) progress(method, path string, query url.Values, body any, fn func(string)) error {
+	resp, err := b.do(method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var m struct {
+			Stream string `json:"stream"`
+			Error  string `json:"error"`
+		}
+		if err = dec.Decode(&m); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if m.Error != "" {
+			return errors.New(strings.TrimSpace(m.Error))
+		}
+		for _, l := range splitLines([]byte(m.Stream)) {
+			fn(l)
+		}
+	}
+}
+
+func (b *libpod) ImageBuild(tag string, opts *c.ImageBuildOptions, progress func(string)) error {
+	if opts.Context == "" {
+		return fmt.Errorf("image build requires a context directory")
+	}
+	var buildArgs, labels string
+	if m := keyValues(opts.BuildArgs); m != nil {
+		r, _ := json.Marshal(m)
+		buildArgs = string(r)
+	}
+	if m := keyValues(opts.Labels); m != nil {
+		r, _ := json.Marshal(m)
+		labels = string(r)
+	}
+	q := query(
+		"t", tag,
+		"dockerfile", opts.File,
+		"buildargs", buildArgs,
+		"labels", labels,
+		"target", opts.Target,
+		"platform", opts.Platform,
+		"nocache", qbool(opts.NoCache),
+		"pull", qbool(opts.Pull),
+	)
+	ctx := tarDir(opts.Context)
+	defer ctx.Close()
+	return b.progress("POST", "/build", q, ctx, progress)
+}
+
+func (b *libpod) ImageExists(name string) error {
+	return b.call("GET", "/images/"+url.PathEscape(name)+"/exists", nil, nil, nil)
+}
+
+func (b *libpod) ImageInspect(name string) (*c.InspectImageData, error) {
+	var data c.InspectImageData
+	if err := b.call("GET", "/images/"+url.PathEscape(name)+"/json", nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (b *libpod) ImageLoad(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return b.call("POST", "/images/load", nil, f, nil)
+}
+
+func (b *libpod) ImagePull(name string, opts *c.ImagePullOptions, progress func(string)) error {
+	goos, arch, variant := splitPlatform(opts.Platform)
+	q := query("reference", name, "policy", opts.Policy, "OS", goos, "Arch", arch, "Variant", variant)
+	if opts.TLSVerify != nil && !*opts.TLSVerify {
+		q.Set("tlsVerify", "false")
+	}
+	return b.progress("POST", "/images/pull", q, nil, progress)
+}
+
+func (b *libpod) ImageRemove(name string) error {
+	return b.call("DELETE", "/images/"+url.PathEscape(name), nil, nil, nil)
+}
+
+func (b *libpod) ImageSave(name string, file string) error {
+	resp, err := b.do("GET", "/images/"+url.PathEscape(name)+"/get", query("format", "docker-archive"), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (b *libpod) ImageTag(name string, tag string) error {
+	repo, t := splitTag(tag)
+	return b.call("POST", "/images/"+url.PathEscape(name)+"/tag", query("repo", repo, "tag", t), nil, nil)
+}
+
+// splitTag splits an image reference into repository and tag.
+// A colon before the last slash separates a registry port, not a tag.
+func splitTag(ref string) (repo, tag string) {
+	i := strings.LastIndex(ref, ":")
+	if i < 0 || strings.LastIndex(ref, "/") > i {
+		return ref, "latest"
+	}
+	return ref[:i], ref[i+1:]
+}
+
+// splitPlatform splits a platform of the form os/arch[/variant].
+func splitPlatform(p string) (goos, arch, variant string) {
+	f := strings.SplitN(p, "/", 3)
+	for len(f) < 3 {
+		f = append(f, "")
+	}
+	return f[0], f[1], f[2]
+}
+
+// tarDir streams a tar archive of the build context directory dir.
+func tarDir(dir string) io.ReadCloser {
+	r, w := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(w)
+		err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, file)
+			if err != nil || rel == "." {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			var link string
+			if info.Mode()&fs.ModeSymlink != 0 {
+				if link, err = os.Readlink(file); err != nil {
+					return err
+				}
+			}
+			hdr, err := tar.FileInfoHeader(info, link)
+			if err != nil {
+				return err
+			}
+			hdr.Name = filepath.ToSlash(rel)
+			if err = tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err == nil {
+			err = tw.Close()
+		}
+		w.CloseWithError(err)
+	}()
+	return r
+}
+
+// streams
+
+const (
+	streamStdin  = 0
+	streamStdout = 1
+	streamStderr = 2
+)
+
+// demux splits a multiplexed libpod stream into stdout and stderr. Each frame
+// carries an 8-byte header holding the stream type and the big-endian payload size.
+func demux(r io.Reader, stdout, stderr io.Writer) error {
+	br := bufio.NewReader(r)
+	var hdr [8]byte
+	for {
+		if _, err := io.ReadFull(br, hdr[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		var w io.Writer
+		switch hdr[0] {
+		case streamStdin, streamStdout:
+			w = stdout
+		case streamStderr:
+			w = stderr
+		default:
+			return fmt.Errorf("libpod stream: unknown stream type %d", hdr[0])
+		}
+		n := int64(binary.BigEndian.Uint32(hdr[4:]))
+		if _, err := io.CopyN(w, br, n); err != nil {
+			return err
+		}
+	}
+}
+
+type readOnlyStream struct {
+	io.ReadCloser
+}
+
+func (readOnlyStream) Write(p []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
@@ -0,0 +1,402 @@
+package podman
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	c "github.com/gocircuit/circuit/client/podman"
+)
+
+// The types below mirror the subset of the libpod spec generator that the
+// create options of the circuit podman elements map onto. Options without an
+// equivalent in the REST API are only honored by the command-line backend.
+
+type portMapping struct {
+	HostIP        string `json:"host_ip,omitempty"`
+	ContainerPort uint16 `json:"container_port"`
+	HostPort      uint16 `json:"host_port,omitempty"`
+	Range         uint16 `json:"range,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+type mount struct {
+	Destination string   `json:"destination"`
+	Type        string   `json:"type,omitempty"`
+	Source      string   `json:"source,omitempty"`
+	Options     []string `json:"options,omitempty"`
+}
+
+type namedVolume struct {
+	Name    string   `json:"Name"`
+	Dest    string   `json:"Dest"`
+	Options []string `json:"Options,omitempty"`
+}
+
+type restartSpec struct {
+	Policy  string `json:"restart_policy,omitempty"`
+	Retries *uint  `json:"restart_tries,omitempty"`
+}
+
+type resourceLimits struct {
+	Memory *struct {
+		Limit int64 `json:"limit,omitempty"`
+	} `json:"memory,omitempty"`
+	CPU *struct {
+		Quota  int64  `json:"quota,omitempty"`
+		Period uint64 `json:"period,omitempty"`
+		Shares uint64 `json:"shares,omitempty"`
+	} `json:"cpu,omitempty"`
+}
+
+type specGenerator struct {
+	Name        string            `json:"name,omitempty"`
+	Pod         string            `json:"pod,omitempty"`
+	Image       string            `json:"image"`
+	Command     []string          `json:"command,omitempty"`
+	Entrypoint  []string          `json:"entrypoint,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	EnvHost     bool              `json:"env_host,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Hostname    string            `json:"hostname,omitempty"`
+	WorkDir     string            `json:"work_dir,omitempty"`
+	User        string            `json:"user,omitempty"`
+	Terminal    bool              `json:"terminal,omitempty"`
+	Stdin       bool              `json:"stdin,omitempty"`
+	Remove      bool              `json:"remove,omitempty"`
+	Init        bool              `json:"init,omitempty"`
+	StopSignal  string            `json:"-"`
+	StopTimeout *uint             `json:"stop_timeout,omitempty"`
+	Timezone    string            `json:"timezone,omitempty"`
+	Umask       string            `json:"umask,omitempty"`
+
+	Privileged     bool     `json:"privileged,omitempty"`
+	ReadOnly       bool     `json:"read_only_filesystem,omitempty"`
+	CapAdd         []string `json:"cap_add,omitempty"`
+	CapDrop        []string `json:"cap_drop,omitempty"`
+	SecurityOpt    []string `json:"selinux_opts,omitempty"`
+	OOMScoreAdj    *int     `json:"oom_score_adj,omitempty"`
+	HealthCmd      []string `json:"-"`
+	NoHealthCheck  bool     `json:"-"`
+	RestartPolicy  string   `json:"restart_policy,omitempty"`
+	RestartRetries *uint    `json:"restart_tries,omitempty"`
+
+	Networks     map[string]struct{} `json:"Networks,omitempty"`
+	PortMappings []portMapping       `json:"portmappings,omitempty"`
+	PublishAll   bool                `json:"publish_image_ports,omitempty"`
+	Expose       map[uint16]string   `json:"expose,omitempty"`
+	DNSServers   []string            `json:"dns_server,omitempty"`
+	DNSSearch    []string            `json:"dns_search,omitempty"`
+	DNSOptions   []string            `json:"dns_option,omitempty"`
+	HostAdd      []string            `json:"hostadd,omitempty"`
+
+	Mounts  []mount         `json:"mounts,omitempty"`
+	Volumes []namedVolume   `json:"volumes,omitempty"`
+	Limits  *resourceLimits `json:"resource_limits,omitempty"`
+}
+
+func containerSpec(opts *c.ContainerCreateOptions) *specGenerator {
+	s := &specGenerator{
+		Name:           opts.Name,
+		Pod:            opts.Pod,
+		Image:          opts.Image,
+		Env:            keyValues(opts.Env),
+		EnvHost:        opts.EnvHost,
+		Labels:         keyValues(opts.Label),
+		Annotations:    keyValues(opts.Annotation),
+		Hostname:       opts.Hostname,
+		WorkDir:        opts.Workdir,
+		User:           opts.User,
+		Terminal:       opts.TTY,
+		Stdin:          opts.Interactive,
+		Remove:         opts.Rm,
+		Init:           opts.Init,
+		Timezone:       opts.Timezone,
+		Umask:          opts.Umask,
+		Privileged:     opts.Privileged,
+		ReadOnly:       opts.ReadOnly,
+		CapAdd:         opts.CapAdd,
+		CapDrop:        opts.CapDrop,
+		OOMScoreAdj:    opts.OOMScoreAdj,
+		RestartRetries: opts.Retry,
+		PublishAll:     opts.PublishAll,
+		DNSServers:     opts.DNSServers,
+		DNSSearch:      opts.DNSSearch,
+		DNSOptions:     opts.DNSOptions,
+		HostAdd:        opts.AddHosts,
+	}
+	if opts.Command != "" {
+		s.Command = append([]string{opts.Command}, opts.Args...)
+	}
+	if opts.Entrypoint != nil {
+		s.Entrypoint = strings.Fields(*opts.Entrypoint)
+	}
+	if opts.StopTimeout > 0 {
+		t := opts.StopTimeout
+		s.StopTimeout = &t
+	}
+	s.RestartPolicy, _, _ = strings.Cut(opts.Restart, ":")
+	if len(opts.Networks) > 0 {
+		s.Networks = make(map[string]struct{})
+		for _, n := range opts.Networks {
+			s.Networks[n] = struct{}{}
+		}
+	}
+	for _, p := range opts.PublishPorts {
+		if m, ok := parsePortMapping(p); ok {
+			s.PortMappings = append(s.PortMappings, m)
+		}
+	}
+	for _, e := range opts.Expose {
+		port, proto, _ := strings.Cut(e, "/")
+		if n, err := strconv.ParseUint(port, 10, 16); err == nil {
+			if s.Expose == nil {
+				s.Expose = make(map[uint16]string)
+			}
+			s.Expose[uint16(n)] = proto
+		}
+	}
+	for _, v := range opts.Volume {
+		src, dst, o := parseVolume(v)
+		switch {
+		case dst == "":
+			continue
+		case filepath.IsAbs(src):
+			s.Mounts = append(s.Mounts, mount{Destination: dst, Type: "bind", Source: src, Options: o})
+		default:
+			s.Volumes = append(s.Volumes, namedVolume{Name: src, Dest: dst, Options: o})
+		}
+	}
+	for _, t := range opts.TmpFS {
+		dst, o, _ := strings.Cut(t, ":")
+		s.Mounts = append(s.Mounts, mount{Destination: dst, Type: "tmpfs", Source: "tmpfs", Options: splitComma(o)})
+	}
+	if mem, ok := parseBytes(opts.Memory); ok {
+		s.limits().Memory = &struct {
+			Limit int64 `json:"limit,omitempty"`
+		}{mem}
+	}
+	if opts.CPUS > 0 || opts.CPUShares > 0 {
+		cpu := &struct {
+			Quota  int64  `json:"quota,omitempty"`
+			Period uint64 `json:"period,omitempty"`
+			Shares uint64 `json:"shares,omitempty"`
+		}{Shares: opts.CPUShares}
+		if opts.CPUS > 0 {
+			cpu.Period = 100000
+			cpu.Quota = int64(opts.CPUS * 100000)
+		}
+		s.limits().CPU = cpu
+	}
+	return s
+}
+
+func (s *specGenerator) limits() *resourceLimits {
+	if s.Limits == nil {
+		s.Limits = &resourceLimits{}
+	}
+	return s.Limits
+}
+
+type podSpecGenerator struct {
+	Name         string              `json:"name,omitempty"`
+	Hostname     string              `json:"hostname,omitempty"`
+	Labels       map[string]string   `json:"labels,omitempty"`
+	NoInfra      bool                `json:"no_infra,omitempty"`
+	InfraImage   string              `json:"infra_image,omitempty"`
+	InfraName    string              `json:"infra_name,omitempty"`
+	InfraCommand []string            `json:"infra_command,omitempty"`
+	ExitPolicy   string              `json:"exit_policy,omitempty"`
+	Share        []string            `json:"shared_namespaces,omitempty"`
+	Networks     map[string]struct{} `json:"Networks,omitempty"`
+	PortMappings []portMapping       `json:"portmappings,omitempty"`
+	DNSServers   []string            `json:"dns_server,omitempty"`
+	DNSSearch    []string            `json:"dns_search,omitempty"`
+	DNSOptions   []string            `json:"dns_option,omitempty"`
+	HostAdd      []string            `json:"hostadd,omitempty"`
+	NoHosts      bool                `json:"no_manage_hosts,omitempty"`
+	Volumes      []namedVolume       `json:"volumes,omitempty"`
+	Mounts       []mount             `json:"mounts,omitempty"`
+	Restart      string              `json:"restart_policy,omitempty"`
+}
+
+func podSpec(opts *c.PodCreateOptions) *podSpecGenerator {
+	s := &podSpecGenerator{
+		Name:       opts.Name,
+		Hostname:   opts.Hostname,
+		Labels:     keyValues(opts.Labels),
+		InfraImage: opts.InfraImage,
+		InfraName:  opts.InfraName,
+		ExitPolicy: opts.ExitPolicy,
+		Share:      splitComma(opts.Share),
+		DNSServers: opts.DNSServers,
+		DNSSearch:  opts.DNSSearch,
+		DNSOptions: opts.DNSOptions,
+		HostAdd:    opts.AddHosts,
+		NoHosts:    opts.NoHosts,
+		Restart:    opts.Restart,
+	}
+	if opts.InfraCommand != "" {
+		s.InfraCommand = strings.Fields(opts.InfraCommand)
+	}
+	if len(opts.Networks) > 0 {
+		s.Networks = make(map[string]struct{})
+		for _, n := range opts.Networks {
+			s.Networks[n] = struct{}{}
+		}
+	}
+	for _, p := range opts.PublishPorts {
+		if m, ok := parsePortMapping(p); ok {
+			s.PortMappings = append(s.PortMappings, m)
+		}
+	}
+	for _, v := range opts.Volume {
+		src, dst, o := parseVolume(v)
+		switch {
+		case dst == "":
+			continue
+		case filepath.IsAbs(src):
+			s.Mounts = append(s.Mounts, mount{Destination: dst, Type: "bind", Source: src, Options: o})
+		default:
+			s.Volumes = append(s.Volumes, namedVolume{Name: src, Dest: dst, Options: o})
+		}
+	}
+	return s
+}
+
+type subnet struct {
+	Subnet  string `json:"subnet"`
+	Gateway string `json:"gateway,omitempty"`
+}
+
+type networkCreate struct {
+	Name             string            `json:"name,omitempty"`
+	Driver           string            `json:"driver,omitempty"`
+	NetworkInterface string            `json:"network_interface,omitempty"`
+	Subnets          []subnet          `json:"subnets,omitempty"`
+	IPv6Enabled      bool              `json:"ipv6_enabled,omitempty"`
+	Internal         bool              `json:"internal,omitempty"`
+	DNSEnabled       bool              `json:"dns_enabled"`
+	NetworkDNS       []string          `json:"network_dns_servers,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	Options          map[string]string `json:"options,omitempty"`
+}
+
+func networkSpec(opts *c.NetworkCreateOptions) *networkCreate {
+	n := &networkCreate{
+		Name:             opts.Name,
+		Driver:           opts.Driver,
+		NetworkInterface: opts.InterfaceName,
+		IPv6Enabled:      opts.IPv6,
+		Internal:         opts.Internal,
+		DNSEnabled:       !opts.DisableDNS,
+		NetworkDNS:       opts.NetworkDNSServers,
+		Labels:           keyValues(opts.Labels),
+		Options:          keyValues(opts.Options),
+	}
+	for i, s := range opts.Subnets {
+		sn := subnet{Subnet: s}
+		if i < len(opts.Gateways) {
+			sn.Gateway = opts.Gateways[i]
+		}
+		n.Subnets = append(n.Subnets, sn)
+	}
+	return n
+}
+
+// parsePortMapping parses a publish specification of the form
+// [[ip:][hostPort]:]containerPort[/protocol].
+func parsePortMapping(p string) (m portMapping, ok bool) {
+	p, m.Protocol, _ = strings.Cut(p, "/")
+	parts := strings.Split(p, ":")
+	var host string
+	switch len(parts) {
+	case 1:
+	case 2:
+		host = parts[0]
+	case 3:
+		m.HostIP, host = parts[0], parts[1]
+	default:
+		return m, false
+	}
+	cp, err := strconv.ParseUint(parts[len(parts)-1], 10, 16)
+	if err != nil {
+		return m, false
+	}
+	m.ContainerPort = uint16(cp)
+	if host != "" {
+		hp, err := strconv.ParseUint(host, 10, 16)
+		if err != nil {
+			return m, false
+		}
+		m.HostPort = uint16(hp)
+	}
+	return m, true
+}
+
+// parseVolume splits a volume specification of the form src:dst[:options].
+func parseVolume(v string) (src, dst string, opts []string) {
+	parts := strings.SplitN(v, ":", 3)
+	switch len(parts) {
+	case 1:
+		return "", "", nil
+	case 2:
+		return parts[0], parts[1], nil
+	}
+	return parts[0], parts[1], splitComma(parts[2])
+}
+
+// parseBytes parses sizes such as 512m or 2g into bytes.
+func parseBytes(s string) (int64, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return 0, false
+	}
+	mult := int64(1)
+	switch s[len(s)-1] {
+	case 'b':
+		s = s[:len(s)-1]
+	case 'k':
+		mult, s = 1<<10, s[:len(s)-1]
+	case 'm':
+		mult, s = 1<<20, s[:len(s)-1]
+	case 'g':
+		mult, s = 1<<30, s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n * mult, true
+}
+
+// keyValues converts a list of key=value strings into a map.
+func keyValues(kv []string) map[string]string {
+	if len(kv) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(kv))
+	for _, s := range kv {
+		k, v, _ := strings.Cut(s, "=")
+		m[k] = v
+	}
+	return m
+}
+
+func splitComma(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func nonEmpty(s ...string) []string {
+	var r []string
+	for _, x := range s {
+		if x != "" {
+			r = append(r, x)
+		}
+	}
+	return r
+}
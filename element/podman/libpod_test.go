@@ -0,0 +1,263 @@
+package podman
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	c "github.com/gocircuit/circuit/client/podman"
+)
+
+// serveLibpod starts a fake libpod service on a unix socket and returns a backend connected to it.
+func serveLibpod(t *testing.T, h http.Handler) *libpod {
+	sock := filepath.Join(t.TempDir(), "podman.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("listen (%v)", err)
+	}
+	srv := &http.Server{Handler: h}
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+	return newLibpod("unix://" + sock)
+}
+
+func TestLibpodCreate(t *testing.T) {
+	var spec map[string]any
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("/"+apiVersion+"/libpod/containers/create", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("method %s", r.Method)
+		}
+		json.NewDecoder(r.Body).Decode(&spec)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"Id":"c0ffee","Warnings":[]}`))
+	})
+	b := serveLibpod(t, mux)
+	if err := b.Ping(); err != nil {
+		t.Fatalf("ping (%v)", err)
+	}
+	id, err := b.ContainerCreate(&c.ContainerCreateOptions{
+		Name:         "web",
+		Image:        "nginx",
+		Command:      "nginx",
+		Args:         []string{"-g", "daemon off;"},
+		Env:          []string{"A=1"},
+		PublishPorts: []string{"127.0.0.1:8080:80/tcp"},
+		Memory:       "512m",
+	})
+	if err != nil {
+		t.Fatalf("create (%v)", err)
+	}
+	if id != "c0ffee" {
+		t.Fatalf("id %q", id)
+	}
+	if spec["name"] != "web" || spec["image"] != "nginx" {
+		t.Fatalf("spec %v", spec)
+	}
+	if cmd := spec["command"].([]any); len(cmd) != 3 || cmd[2] != "daemon off;" {
+		t.Fatalf("command %v", cmd)
+	}
+	pm := spec["portmappings"].([]any)[0].(map[string]any)
+	if pm["host_ip"] != "127.0.0.1" || pm["host_port"] != 8080.0 || pm["container_port"] != 80.0 {
+		t.Fatalf("port mapping %v", pm)
+	}
+	mem := spec["resource_limits"].(map[string]any)["memory"].(map[string]any)
+	if mem["limit"] != float64(512<<20) {
+		t.Fatalf("memory %v", mem)
+	}
+}
+
+func TestLibpodErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+apiVersion+"/libpod/containers/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"cause":"no such container","message":"no container with name or ID \"x\" found","response":404}`))
+	})
+	b := serveLibpod(t, mux)
+	_, err := b.ContainerInspect("x")
+	if !IsNotFound(err) {
+		t.Fatalf("expecting not found, got %v", err)
+	}
+	if err = b.ContainerRemove("x", &c.ContainerRemoveOptions{Ignore: true}); err != nil {
+		t.Fatalf("remove with ignore (%v)", err)
+	}
+	if err = b.ContainerRemove("x", &c.ContainerRemoveOptions{}); err == nil {
+		t.Fatalf("remove without ignore should fail")
+	}
+}
+
+func TestLibpodEscape(t *testing.T) {
+	var paths []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+	})
+	b := serveLibpod(t, mux)
+	// Names are single path segments, whatever they hold
+	b.ContainerRename("../../pods/p", "web")
+	b.PodKill("p/kill?x=", "KILL")
+	b.ImageExists("docker.io/library/alpine:3")
+	want := []string{
+		"/" + apiVersion + "/libpod/containers/..%2F..%2Fpods%2Fp/rename",
+		"/" + apiVersion + "/libpod/pods/p%2Fkill%3Fx=/kill",
+		"/" + apiVersion + "/libpod/images/docker.io%2Flibrary%2Falpine:3/exists",
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("paths %q", paths)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Fatalf("path %s, expected %s", paths[i], want[i])
+		}
+	}
+}
+
+func TestDemux(t *testing.T) {
+	var stream bytes.Buffer
+	frame := func(fd byte, p string) {
+		hdr := make([]byte, 8)
+		hdr[0] = fd
+		binary.BigEndian.PutUint32(hdr[4:], uint32(len(p)))
+		stream.Write(hdr)
+		stream.WriteString(p)
+	}
+	frame(1, "hello ")
+	frame(2, "oops")
+	frame(1, "world")
+	var stdout, stderr bytes.Buffer
+	if err := demux(&stream, &stdout, &stderr); err != nil {
+		t.Fatalf("demux (%v)", err)
+	}
+	if stdout.String() != "hello world" || stderr.String() != "oops" {
+		t.Fatalf("stdout=%q stderr=%q", stdout.String(), stderr.String())
+	}
+}
+
+func TestParsePortMapping(t *testing.T) {
+	for _, x := range []struct {
+		spec string
+		m    portMapping
+		ok   bool
+	}{
+		{"80", portMapping{ContainerPort: 80}, true},
+		{"8080:80", portMapping{HostPort: 8080, ContainerPort: 80}, true},
+		{"10.0.0.1::53/udp", portMapping{HostIP: "10.0.0.1", ContainerPort: 53, Protocol: "udp"}, true},
+		{"a:b", portMapping{}, false},
+	} {
+		m, ok := parsePortMapping(x.spec)
+		if ok != x.ok || (ok && m != x.m) {
+			t.Fatalf("%s: got %+v %v", x.spec, m, ok)
+		}
+	}
+}
+
+func TestLibpodStreams(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+apiVersion+"/libpod/events", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("since") == "" {
+			t.Errorf("events without since")
+		}
+		w.Write([]byte(`{"Type":"container","Action":"start","time":1700000000,"Actor":{"ID":"c0ffee","Attributes":{"name":"web"}}}` + "\n"))
+		w.Write([]byte(`{"Type":"container","Action":"exec","time":1700000001}` + "\n"))
+		w.Write([]byte(`{"Type":"container","Action":"died","time":1700000002,"Actor":{"Attributes":{"containerExitCode":"137"}}}` + "\n"))
+	})
+	mux.HandleFunc("/"+apiVersion+"/libpod/containers/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Error":null,"Stats":[{"CPU":12.5,"MemUsage":1048576,"MemLimit":2097152,"NetInput":10,"PIDs":3}]}` + "\n"))
+	})
+	b := serveLibpod(t, mux)
+
+	var events []*c.ContainerEvent
+	stop := make(chan struct{})
+	if err := b.ContainerEvents("web", time.Now(), stop, func(e *c.ContainerEvent) { events = append(events, e) }); err != nil {
+		t.Fatalf("events (%v)", err)
+	}
+	if len(events) != 2 || events[0].Status != c.EventStart || events[1].Status != c.EventDie || events[1].ExitCode != 137 {
+		t.Fatalf("events %+v", events)
+	}
+	if events[0].Attributes["name"] != "web" || events[0].Time.Unix() != 1700000000 {
+		t.Fatalf("event %+v", events[0])
+	}
+
+	var stats []*c.ContainerStats
+	if err := b.ContainerStats("web", stop, func(s *c.ContainerStats) { stats = append(stats, s) }); err != nil {
+		t.Fatalf("stats (%v)", err)
+	}
+	if len(stats) != 1 || stats[0].CPU != 12.5 || stats[0].MemUsage != 1<<20 || stats[0].PIDs != 3 || stats[0].Time.IsZero() {
+		t.Fatalf("stats %+v", stats)
+	}
+}
+
+func TestLibpodImage(t *testing.T) {
+	var files []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+apiVersion+"/libpod/images/pull", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("reference") != "alpine" || q.Get("Arch") != "arm64" {
+			t.Errorf("pull query %v", q)
+		}
+		w.Write([]byte(`{"stream":"Copying blob 1\nCopying config 2\n"}` + "\n"))
+		w.Write([]byte(`{"images":["f00d"],"id":"f00d"}` + "\n"))
+	})
+	mux.HandleFunc("/"+apiVersion+"/libpod/build", func(w http.ResponseWriter, r *http.Request) {
+		tr := tar.NewReader(r.Body)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("build context (%v)", err)
+				return
+			}
+			files = append(files, hdr.Name)
+		}
+		w.Write([]byte(`{"stream":"STEP 1/1: FROM alpine\n"}` + "\n"))
+		w.Write([]byte(`{"error":"build failed"}` + "\n"))
+	})
+	b := serveLibpod(t, mux)
+
+	var lines []string
+	progress := func(l string) { lines = append(lines, l) }
+	if err := b.ImagePull("alpine", &c.ImagePullOptions{Platform: "linux/arm64"}, progress); err != nil {
+		t.Fatalf("pull (%v)", err)
+	}
+	if len(lines) != 2 || lines[1] != "Copying config 2" {
+		t.Fatalf("pull progress %q", lines)
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "Containerfile"), []byte("FROM alpine\n"), 0644)
+	os.Mkdir(filepath.Join(dir, "etc"), 0755)
+	os.WriteFile(filepath.Join(dir, "etc", "conf"), []byte("x"), 0644)
+	lines = nil
+	err := b.ImageBuild("app", &c.ImageBuildOptions{Context: dir}, progress)
+	if err == nil || err.Error() != "build failed" {
+		t.Fatalf("build error %v", err)
+	}
+	if len(lines) != 1 || len(files) != 3 || files[2] != "etc/conf" {
+		t.Fatalf("build progress %q, context %q", lines, files)
+	}
+}
+
+func TestSplitTag(t *testing.T) {
+	for _, x := range [][3]string{
+		{"alpine", "alpine", "latest"},
+		{"alpine:3.19", "alpine", "3.19"},
+		{"registry:5000/app", "registry:5000/app", "latest"},
+		{"registry:5000/app:v1", "registry:5000/app", "v1"},
+	} {
+		if repo, tag := splitTag(x[0]); repo != x[1] || tag != x[2] {
+			t.Fatalf("%s: got %s %s", x[0], repo, tag)
+		}
+	}
+}
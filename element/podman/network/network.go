@@ -2,10 +2,8 @@ package network
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
-	"os/exec"
 	"runtime"
 
 	"github.com/gocircuit/circuit/anchor"
@@ -22,6 +20,7 @@ type Network interface {
 }
 
 type network struct {
+	be   podman.Backend
 	name string
 	id   string
 }
@@ -33,32 +32,30 @@ func init() {
 func MakeNetwork(opts c.NetworkCreateOptions) (Network, error) {
 	// Check if podman enabled on this server
 	// TODO make this a capability of the server when it joins the cluster.
-	if podman.Path == "" {
-		return nil, errors.New("podman not installed on this server")
+	be, err := podman.Engine()
+	if err != nil {
+		return nil, err
 	}
 
 	// determine name
 	opts.Name = element.ElementName(opts.Name)
 
-	// Create a new exec.Cmd
-	args := opts.CmdLine()
-	log.Printf("cmd line: %s %v", podman.Path, args)
-	r, err := exec.Command(podman.Path, args...).Output()
+	id, err := be.NetworkCreate(&opts)
 	if err != nil {
-		log.Printf("error running command: %s %v - error:%v", podman.Path, args, err)
+		log.Printf("error creating network %s: %v", opts.Name, err)
 		return nil, err
 	}
 
-	// Create a new container
 	netw := &network{
+		be:   be,
 		name: opts.Name,
-		id:   string(r),
+		id:   id,
 	}
 
 	// GC...
 	runtime.SetFinalizer(netw,
-		func(c *network) {
-			exec.Command(podman.Path, "rm", c.name).Run()
+		func(n *network) {
+			n.be.NetworkRemove(n.name, &c.NetworkRemoveOptions{Force: true})
 		},
 	)
 
@@ -66,29 +63,19 @@ func MakeNetwork(opts c.NetworkCreateOptions) (Network, error) {
 }
 
 func (n *network) Connect(opts *c.NetworkConnectOptions) error {
-	return exec.Command(podman.Path, opts.CmdLine(n.name)...).Run()
+	return n.be.NetworkConnect(n.name, opts)
 }
 
 func (n *network) Disconnect(opts *c.NetworkDisconnectOptions) error {
-	return exec.Command(podman.Path, opts.CmdLine(n.name)...).Run()
+	return n.be.NetworkDisconnect(n.name, opts)
 }
 
 func (n *network) Exists() error {
-	return exec.Command(podman.Path, "network", "exists", n.name).Run()
+	return n.be.NetworkExists(n.name)
 }
 
 func (n *network) Inspect() (*c.InspectNetworkSettings, error) {
-	b, err := exec.Command(podman.Path, "network", "inspect", n.name).Output()
-	if err != nil {
-		return nil, err
-	}
-
-	data, err := element.ParseJSONArrayFirst[*c.InspectNetworkSettings](b)
-	if err != nil {
-		return nil, err
-	}
-
-	return data, nil
+	return n.be.NetworkInspect(n.name)
 }
 
 func (n *network) PeekBytes() []byte {
@@ -103,20 +90,23 @@ func (n *network) PeekBytes() []byte {
 }
 
 func (n *network) Reload() error {
-	return exec.Command(podman.Path, "network", "reload", n.name).Run()
+	return n.be.NetworkReload(n.name)
 }
 
 func (n *network) Scrub() {
-	opts := c.NetworkRemoveOptions{Force: true}
-	exec.Command(podman.Path, opts.CmdLine(n.name)...).Run()
+	n.be.NetworkRemove(n.name, &c.NetworkRemoveOptions{Force: true})
 }
 
 func (n *network) Update(opts *c.NetworkUpdateOptions) error {
-	return exec.Command(podman.Path, opts.CmdLine(n.name)...).Run()
+	return n.be.NetworkUpdate(n.name, opts)
 }
 
 func (n *network) X() circuit.X {
-	return circuit.Ref(XNetwork{n})
+	return circuit.Ref(XNetwork{nw: n})
+}
+
+func (n *network) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XNetwork{nw: n, guard: g})
 }
 
 func ef(t *anchor.Terminal, arg any) (anchor.Element, error) {
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"github.com/gocircuit/circuit/anchor"
 	c "github.com/gocircuit/circuit/client/podman"
 	"github.com/gocircuit/circuit/use/circuit"
 	"github.com/gocircuit/circuit/use/errors"
@@ -12,40 +13,59 @@ func init() {
 
 // X
 type XNetwork struct {
-	Network
+	nw    Network
+	guard anchor.Guard
+}
+
+// networkVerbs are the verbs of the methods of XNetwork. Methods changing
+// the configuration of the network make it.
+var networkVerbs = anchor.Verbs{
+	"Connect":    anchor.Make,
+	"Disconnect": anchor.Make,
+	"Exists":     anchor.Get,
+	"Inspect":    anchor.Get,
+	"PeekBytes":  anchor.Get,
+	"Reload":     anchor.Signal,
+	"Scrub":      anchor.Scrub,
+	"Update":     anchor.Make,
+}
+
+func (x XNetwork) Authorize(method string) error {
+	return x.guard.Authorize(networkVerbs, method)
 }
 
 func (x XNetwork) Connect(opts *c.NetworkConnectOptions) error {
-	return errors.Pack(x.Network.Connect(opts))
+	return errors.Pack(x.nw.Connect(opts))
 }
 
 func (x XNetwork) Disconnect(opts *c.NetworkDisconnectOptions) error {
-	return errors.Pack(x.Network.Disconnect(opts))
+	return errors.Pack(x.nw.Disconnect(opts))
 }
 
 func (x XNetwork) Exists() error {
-	return errors.Pack(x.Network.Exists())
+	return errors.Pack(x.nw.Exists())
 }
 
 func (x XNetwork) Inspect() (*c.InspectNetworkSettings, error) {
-	d, err := x.Network.Inspect()
+	d, err := x.nw.Inspect()
 	return d, errors.Pack(err)
 }
 
 func (x XNetwork) PeekBytes() []byte {
-	return x.Network.PeekBytes()
+	return x.nw.PeekBytes()
 }
 
 func (x XNetwork) Reload() error {
-	return errors.Pack(x.Network.Reload())
+	return errors.Pack(x.nw.Reload())
 }
 
 func (x XNetwork) Scrub() {
-	x.Network.Scrub()
+	x.nw.Scrub()
+	x.guard.Audit(anchor.Scrub, nil, nil)
 }
 
 func (x XNetwork) Update(opts *c.NetworkUpdateOptions) error {
-	return errors.Pack(x.Network.Update(opts))
+	return errors.Pack(x.nw.Update(opts))
 }
 
 type YNetwork struct {
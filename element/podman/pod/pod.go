@@ -2,10 +2,8 @@ package pod
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
-	"os/exec"
 	"runtime"
 
 	"github.com/gocircuit/circuit/anchor"
@@ -21,6 +19,7 @@ type Pod interface {
 }
 
 type pod struct {
+	be   podman.Backend
 	name string
 	id   string
 }
@@ -32,75 +31,66 @@ func init() {
 func makePod(opts c.PodCreateOptions) (Pod, error) {
 	// Check if podman enabled on this server
 	// TODO make this a capability of the server when it joins the cluster.
-	if podman.Path == "" {
-		return nil, errors.New("podman not installed on this server")
+	be, err := podman.Engine()
+	if err != nil {
+		return nil, err
 	}
 
 	// determine name
 	opts.Name = element.ElementName(opts.Name)
 
-	// Create a new exec.Cmd
-	args := opts.CmdLine()
-	log.Printf("cmd line: %s %v", podman.Path, args)
-	r, err := exec.Command(podman.Path, args...).Output()
+	id, err := be.PodCreate(&opts)
 	if err != nil {
-		log.Printf("error running command, MakePod: %s %v - error:%v", podman.Path, args, err)
+		log.Printf("error creating pod %s: %v", opts.Name, err)
 		return nil, err
 	}
 
-	// Create a new container
+	return newPod(be, opts.Name, id), nil
+}
+
+func newPod(be podman.Backend, name, id string) *pod {
 	p := &pod{
-		name: opts.Name,
-		id:   string(r),
+		be:   be,
+		name: name,
+		id:   id,
 	}
 
 	// GC...
 	runtime.SetFinalizer(p,
-		func(c *pod) {
-			exec.Command(podman.Path, "rm", c.name).Run()
+		func(p *pod) {
+			p.be.PodRemove(p.name)
 		},
 	)
 
-	return p, nil
+	return p
 }
 
 func (p *pod) Clone(opts *c.PodCloneOptions) (c.Pod, error) {
-	b, err := exec.Command(podman.Path, opts.CmdLine()...).Output()
+	opts.Name = element.ElementName(opts.Name)
+	id, err := p.be.PodClone(p.name, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	// create a new pod anchor
-	np := &pod{
-		name: "", // TODO get name for new pod
-		id:   string(b),
-	}
-
-	return np, nil
+	// TODO create a new pod anchor
+	return newPod(p.be, opts.Name, id), nil
 }
 
 // TODO capture error (1 returned from command) and return pod not exist error
 func (p *pod) Exists() error {
-	return exec.Command(podman.Path, "pod", "exists", p.name).Run()
+	return p.be.PodExists(p.name)
 }
 
-func (p *pod) Inspect() (*c.InspectPodData, error) {
-	b, err := exec.Command(podman.Path, "pod", "inspect", p.name).Output()
-	if err != nil {
-		return nil, err
-	}
-
-	data, err := element.ParseJSONArrayFirst[*c.InspectPodData](b)
-	if err != nil {
-		return nil, err
-	}
-
-	return data, nil
+func (p *pod) Generate() ([]byte, error) {
+	return p.be.PodGenerate(p.name)
+}
 
+func (p *pod) Inspect() (*c.InspectPodData, error) {
+	return p.be.PodInspect(p.name)
 }
 
 func (p *pod) Pause() error {
-	return exec.Command(podman.Path, "pod", "pause", p.name).Run()
+	return p.be.PodPause(p.name)
 }
 
 func (p *pod) PeekBytes() []byte {
@@ -114,38 +104,35 @@ func (p *pod) PeekBytes() []byte {
 }
 
 func (p *pod) Restart() error {
-	return exec.Command(podman.Path, "pod", "restart", p.name).Run()
+	return p.be.PodRestart(p.name)
 }
 
 func (p *pod) Scrub() {
-	// TODO should force removal and ignore when pod is missing
-	args := []string{"pod", "rm", "--force", "--ignore", p.name}
-	exec.Command(podman.Path, args...).Run()
+	p.be.PodRemove(p.name)
 }
 
 func (p *pod) Signal(sig string) error {
-	args := []string{"pod", "kill"}
-	if sig != "" {
-		args = append(args, "--signal", sig)
-	}
-	args = append(args, p.name)
-	return exec.Command(podman.Path, args...).Run()
+	return p.be.PodKill(p.name, sig)
 }
 
 func (p *pod) Start(opts *c.PodStartOptions) error {
-	return exec.Command(podman.Path, opts.CmdLine(p.name)...).Run()
+	return p.be.PodStart(p.name, opts)
 }
 
 func (p *pod) Stop(opts *c.PodStopOptions) error {
-	return exec.Command(podman.Path, opts.CmdLine(p.name)...).Run()
+	return p.be.PodStop(p.name, opts)
 }
 
 func (p *pod) Unpause() error {
-	return exec.Command(podman.Path, "pod", "unpause", p.name).Run()
+	return p.be.PodUnpause(p.name)
 }
 
 func (p *pod) X() circuit.X {
-	return circuit.Ref(XPod{p})
+	return circuit.Ref(XPod{p: p})
+}
+
+func (p *pod) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XPod{p: p, guard: g})
 }
 
 func ef(t *anchor.Terminal, arg any) (anchor.Element, error) {
@@ -1,6 +1,7 @@
 package pod
 
 import (
+	"github.com/gocircuit/circuit/anchor"
 	"github.com/gocircuit/circuit/client/podman"
 	"github.com/gocircuit/circuit/use/circuit"
 	"github.com/gocircuit/circuit/use/errors"
@@ -11,54 +12,84 @@ func init() {
 }
 
 type XPod struct {
-	Pod
+	p     Pod
+	guard anchor.Guard
+}
+
+// podVerbs are the verbs of the methods of XPod. Cloning a pod makes one,
+// and methods changing the state of its processes signal them.
+var podVerbs = anchor.Verbs{
+	"Clone":     anchor.Make,
+	"Exists":    anchor.Get,
+	"Generate":  anchor.Get,
+	"Inspect":   anchor.Get,
+	"Pause":     anchor.Signal,
+	"PeekBytes": anchor.Get,
+	"Restart":   anchor.Signal,
+	"Scrub":     anchor.Scrub,
+	"Signal":    anchor.Signal,
+	"Start":     anchor.Signal,
+	"Stop":      anchor.Signal,
+	"Unpause":   anchor.Signal,
+}
+
+func (x XPod) Authorize(method string) error {
+	return x.guard.Authorize(podVerbs, method)
 }
 
 // skeleton adapter
 func (x XPod) Clone(opts *podman.PodCloneOptions) (podman.Pod, error) {
-	p, err := x.Pod.Clone(opts)
+	p, err := x.p.Clone(opts)
 	return p, errors.Pack(err)
 }
 
 func (x XPod) Exists() error {
-	return errors.Pack(x.Pod.Exists())
+	return errors.Pack(x.p.Exists())
+}
+
+func (x XPod) Generate() ([]byte, error) {
+	b, err := x.p.Generate()
+	return b, errors.Pack(err)
 }
 
 func (x XPod) Inspect() (*podman.InspectPodData, error) {
-	d, err := x.Pod.Inspect()
+	d, err := x.p.Inspect()
 	return d, errors.Pack(err)
 }
 
 func (x XPod) Pause() error {
-	return errors.Pack(x.Pod.Pause())
+	return errors.Pack(x.p.Pause())
 }
 
 func (x XPod) PeekBytes() []byte {
-	return x.Pod.PeekBytes()
+	return x.p.PeekBytes()
 }
 
 func (x XPod) Restart() error {
-	return errors.Pack(x.Pod.Restart())
+	return errors.Pack(x.p.Restart())
 }
 
 func (x XPod) Scrub() {
-	x.Pod.Scrub()
+	x.p.Scrub()
+	x.guard.Audit(anchor.Scrub, nil, nil)
 }
 
 func (x XPod) Signal(sig string) error {
-	return errors.Pack(x.Pod.Signal(sig))
+	err := x.p.Signal(sig)
+	x.guard.Audit(anchor.Signal, sig, err)
+	return errors.Pack(err)
 }
 
 func (x XPod) Start(opts *podman.PodStartOptions) error {
-	return errors.Pack(x.Pod.Start(opts))
+	return errors.Pack(x.p.Start(opts))
 }
 
 func (x XPod) Stop(opts *podman.PodStopOptions) error {
-	return errors.Pack(x.Pod.Stop(opts))
+	return errors.Pack(x.p.Stop(opts))
 }
 
 func (x XPod) Unpause() error {
-	return errors.Pack(x.Pod.Unpause())
+	return errors.Pack(x.p.Unpause())
 }
 
 // stub proxy
@@ -77,6 +108,12 @@ func (y YPod) Exists() error {
 	return errors.Unpack(r[0])
 }
 
+func (y YPod) Generate() ([]byte, error) {
+	r := y.X.Call("Generate")
+	b, _ := r[0].([]byte)
+	return b, errors.Unpack(r[1])
+}
+
 func (y YPod) Inspect() (*podman.InspectPodData, error) {
 	r := y.X.Call("Inspect")
 	d, _ := r[0].(*podman.InspectPodData)
@@ -102,7 +139,7 @@ func (y YPod) Scrub() {
 }
 
 func (y YPod) Signal(sig string) error {
-	r := y.X.Call("Signal")
+	r := y.X.Call("Signal", sig)
 	return errors.Unpack(r[0])
 }
 
@@ -1,13 +1,17 @@
 package podman
 
 import (
+	"io"
 	"log"
+	"os"
 
 	"github.com/gocircuit/circuit/element"
 )
 
+// Path is the podman binary resolved by ResolvePodman.
 var Path string
 
+// ResolvePodman locates the podman binary and installs the command-line backend.
 func ResolvePodman() (string, error) {
 	exe, err := element.ResolveExe("podman", "version")
 	if err != nil {
@@ -16,5 +20,21 @@ func ResolvePodman() (string, error) {
 	}
 
 	Path = exe
+	Use(NewCLI(exe))
 	return Path, nil
 }
+
+// ReceiveFile copies r, typically a cross-reader of an archive sent by another
+// circuit server, into a new file and closes r.
+func ReceiveFile(file string, r io.ReadCloser) error {
+	defer r.Close()
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
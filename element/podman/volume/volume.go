@@ -2,10 +2,8 @@ package volume
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
-	"os/exec"
 	"runtime"
 
 	"github.com/gocircuit/circuit/anchor"
@@ -21,6 +19,7 @@ type Volume interface {
 }
 
 type volume struct {
+	be   podman.Backend
 	name string
 	id   string
 }
@@ -32,73 +31,54 @@ func init() {
 func MakeVolume(opts c.VolumeCreateOptions) (Volume, error) {
 	// Check if podman enabled on this server
 	// TODO make this a capability of the server when it joins the cluster.
-	if podman.Path == "" {
-		return nil, errors.New("podman not installed on this server")
+	be, err := podman.Engine()
+	if err != nil {
+		return nil, err
 	}
 
 	// determine name
 	opts.Name = element.ElementName(opts.Name)
 
-	// Create a new exec.Cmd
-	args := opts.CmdLine()
-	log.Printf("cmd line: %s %v", podman.Path, args)
-	r, err := exec.Command(podman.Path, args...).Output()
+	id, err := be.VolumeCreate(&opts)
 	if err != nil {
-		log.Printf("error running command: %s %v - error:%v", podman.Path, args, err)
+		log.Printf("error creating volume %s: %v", opts.Name, err)
 		return nil, err
 	}
 
-	// Create a new container
 	vol := &volume{
+		be:   be,
 		name: opts.Name,
-		id:   string(r),
+		id:   id,
 	}
 
 	// GC...
 	runtime.SetFinalizer(vol,
-		func(c *volume) {
-			exec.Command(podman.Path, "rm", c.name).Run()
+		func(v *volume) {
+			v.be.VolumeRemove(v.name, &c.VolumeRemoveOptions{Force: true})
 		},
 	)
 
 	return vol, nil
-
 }
 
 func (v *volume) Exists() error {
-	return exec.Command(podman.Path, "volume", "exists", v.name).Run()
+	return v.be.VolumeExists(v.name)
 }
 
 func (v *volume) Export(opts c.VolumeExportOptions) error {
-	return exec.Command(podman.Path, opts.CmdLine(v.name)...).Run()
+	return v.be.VolumeExport(v.name, opts)
 }
 
 func (v *volume) Import(source string) error {
-	args := []string{"volume", "import", v.name}
-	if source != "" {
-		args = append(args, source)
-	}
-
-	return exec.Command(podman.Path, args...).Run()
+	return v.be.VolumeImport(v.name, source)
 }
 
 func (v *volume) Inspect() (*c.InspectVolumeData, error) {
-	b, err := exec.Command(podman.Path, "volume", "inspect", v.name).Output()
-	if err != nil {
-		return nil, err
-	}
-
-	data, err := element.ParseJSONArrayFirst[*c.InspectVolumeData](b)
-	if err != nil {
-		return nil, err
-	}
-
-	return data, nil
-
+	return v.be.VolumeInspect(v.name)
 }
 
 func (v *volume) Mount() error {
-	return exec.Command(podman.Path, "volume", "mount", v.name).Run()
+	return v.be.VolumeMount(v.name)
 }
 
 func (v *volume) PeekBytes() []byte {
@@ -112,20 +92,23 @@ func (v *volume) PeekBytes() []byte {
 }
 
 func (v *volume) Reload() error {
-	return exec.Command(podman.Path, "volume", "reload", v.name).Run()
+	return v.be.VolumeReload(v.name)
 }
 
 func (v *volume) Scrub() {
-	opts := c.VolumeRemoveOptions{Force: true}
-	exec.Command(podman.Path, opts.CmdLine(v.name)...).Run()
+	v.be.VolumeRemove(v.name, &c.VolumeRemoveOptions{Force: true})
 }
 
 func (v *volume) Unmount() error {
-	return exec.Command(podman.Path, "volume", "unmount", v.name).Run()
+	return v.be.VolumeUnmount(v.name)
 }
 
 func (v *volume) X() circuit.X {
-	return circuit.Ref(XVolume{v})
+	return circuit.Ref(XVolume{v: v})
+}
+
+func (v *volume) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XVolume{v: v, guard: g})
 }
 
 func ef(t *anchor.Terminal, arg any) (anchor.Element, error) {
@@ -1,6 +1,7 @@
 package volume
 
 import (
+	"github.com/gocircuit/circuit/anchor"
 	c "github.com/gocircuit/circuit/client/podman"
 	"github.com/gocircuit/circuit/use/circuit"
 	"github.com/gocircuit/circuit/use/errors"
@@ -12,44 +13,64 @@ func init() {
 
 // X
 type XVolume struct {
-	Volume
+	v     Volume
+	guard anchor.Guard
+}
+
+// volumeVerbs are the verbs of the methods of XVolume. Methods changing the
+// contents or mounts of the volume, or writing files on the server, make them.
+var volumeVerbs = anchor.Verbs{
+	"Exists":    anchor.Get,
+	"Export":    anchor.Make,
+	"Import":    anchor.Make,
+	"Inspect":   anchor.Get,
+	"Mount":     anchor.Make,
+	"PeekBytes": anchor.Get,
+	"Reload":    anchor.Signal,
+	"Scrub":     anchor.Scrub,
+	"Unmount":   anchor.Make,
+}
+
+func (x XVolume) Authorize(method string) error {
+	return x.guard.Authorize(volumeVerbs, method)
 }
 
 func (x XVolume) Exists() error {
-	return errors.Pack(x.Volume.Exists())
+	return errors.Pack(x.v.Exists())
 }
 
 func (x XVolume) Export(opts c.VolumeExportOptions) error {
-	return errors.Pack(x.Volume.Export(opts))
+	return errors.Pack(x.v.Export(opts))
 }
 
 func (x XVolume) Import(source string) error {
-	return errors.Pack(x.Volume.Import(source))
+	return errors.Pack(x.v.Import(source))
 }
 
 func (x XVolume) Inspect() (*c.InspectVolumeData, error) {
-	r, err := x.Volume.Inspect()
+	r, err := x.v.Inspect()
 	return r, errors.Pack(err)
 }
 
 func (x XVolume) Mount() error {
-	return errors.Pack(x.Volume.Mount())
+	return errors.Pack(x.v.Mount())
 }
 
 func (x XVolume) PeekBytes() []byte {
-	return x.Volume.PeekBytes()
+	return x.v.PeekBytes()
 }
 
 func (x XVolume) Reload() error {
-	return errors.Pack(x.Volume.Reload())
+	return errors.Pack(x.v.Reload())
 }
 
 func (x XVolume) Scrub() {
-	x.Volume.Scrub()
+	x.v.Scrub()
+	x.guard.Audit(anchor.Scrub, nil, nil)
 }
 
 func (x XVolume) Unmount() error {
-	return errors.Pack(x.Volume.Unmount())
+	return errors.Pack(x.v.Unmount())
 }
 
 type YVolume struct {
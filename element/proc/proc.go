@@ -8,6 +8,7 @@
 package proc
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -25,7 +26,7 @@ import (
 )
 
 type Proc interface {
-	client.Proc
+	client.ProcContext
 	IsDone() bool
 	X() circuit.X
 }
@@ -50,7 +51,9 @@ func init() {
 	anchor.RegisterElement("proc", ef, yf)
 }
 
-func makeProc(cmd client.Cmd) Proc {
+// MakeProc starts an OS process and returns a process element, which is not
+// attached to any anchor.
+func MakeProc(cmd client.Cmd) Proc {
 	p := &proc{}
 	// std*
 	p.cmd.cmd.Stdin, p.stdin = interruptible.BufferPipe(32e3)
@@ -71,6 +74,8 @@ func makeProc(cmd client.Cmd) Proc {
 	if err := p.cmd.cmd.Start(); err != nil {
 		p.cmd.wait <- fmt.Errorf("exec error: %s", err.Error())
 		close(p.cmd.wait)
+		p.cmd.cmd.Stdout.(io.Closer).Close()
+		p.cmd.cmd.Stderr.(io.Closer).Close()
 		return p
 	}
 	go func() {
@@ -95,7 +100,11 @@ func (p *proc) Stderr() io.ReadCloser {
 }
 
 func (p *proc) X() circuit.X {
-	return circuit.Ref(XProc{p})
+	return circuit.Ref(XProc{p: p})
+}
+
+func (p *proc) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XProc{p: p, guard: g})
 }
 
 func (p *proc) Scrub() {
@@ -109,6 +118,10 @@ func (p *proc) Scrub() {
 }
 
 func (p *proc) Wait() (client.ProcStat, error) {
+	return p.WaitContext(context.Background())
+}
+
+func (p *proc) WaitContext(ctx context.Context) (client.ProcStat, error) {
 	select {
 	case exit, ok := <-p.wait:
 		if !ok {
@@ -120,6 +133,8 @@ func (p *proc) Wait() (client.ProcStat, error) {
 		return p.peek(), nil
 	case <-p.abr:
 		return client.ProcStat{}, errors.New("aborted")
+	case <-ctx.Done():
+		return client.ProcStat{}, ctx.Err()
 	}
 }
 
@@ -214,7 +229,7 @@ func ef(t *anchor.Terminal, arg any) (anchor.Element, error) {
 	if !ok {
 		return nil, fmt.Errorf("invalid argument to proc element, expecting type client.Cmd got %T", arg)
 	}
-	elem := makeProc(cmd)
+	elem := MakeProc(cmd)
 
 	go func() {
 		defer func() {
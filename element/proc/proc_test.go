@@ -0,0 +1,158 @@
+package proc
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocircuit/circuit/anchor"
+	"github.com/gocircuit/circuit/client"
+	"github.com/gocircuit/circuit/element/server"
+	"github.com/gocircuit/circuit/sys/lang"
+	"github.com/gocircuit/circuit/tissue"
+	"github.com/gocircuit/circuit/use/circuit"
+)
+
+func TestMakeProc(t *testing.T) {
+	p := MakeProc(client.Cmd{Path: "/bin/echo", Args: []string{"hi"}})
+	p.Stdin().Close()
+	out, err := io.ReadAll(p.Stdout())
+	if err != nil || string(out) != "hi\n" {
+		t.Fatalf("stdout %q (%v)", out, err)
+	}
+	if _, err = p.Wait(); err != nil {
+		t.Fatalf("wait (%v)", err)
+	}
+}
+
+// The output of a process that fails to start ends, rather than blocking its readers
+func TestMakeProcFailed(t *testing.T) {
+	p := MakeProc(client.Cmd{Path: "/nonexistent"})
+	done := make(chan error, 2)
+	for _, r := range []io.Reader{p.Stdout(), p.Stderr()} {
+		go func(r io.Reader) {
+			_, err := io.ReadAll(r)
+			done <- err
+		}(r)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("read (%v)", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("output of failed process not closed")
+		}
+	}
+	if stat, _ := p.Wait(); stat.Exit == nil {
+		t.Fatalf("failed process exited without error")
+	}
+}
+
+// rootService hands the root terminal of a server to remote callers
+type rootService struct {
+	x circuit.PermX
+}
+
+func (s rootService) Root() circuit.X {
+	return s.x
+}
+
+// denied returns the error f panics with
+func denied(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = r.(error)
+		}
+	}()
+	f()
+	return nil
+}
+
+// A caller allowed to get anchors only can neither kill the server, nor read
+// its audit log, nor scrub or signal a process through the cross-interfaces
+// of their elements, and its attempts are audited
+func TestGuard(t *testing.T) {
+	rt, caller := lang.New(lang.NewSandbox()), lang.New(lang.NewSandbox())
+	circuit.Bind(rt)
+	kin, _, _ := tissue.NewKin()
+	root, x := anchor.NewTerm("X1", nil)
+	root.Attach(anchor.Server, server.New(kin, root))
+	elm, err := root.Walk([]string{"sleep"}).Make(anchor.Proc, client.Cmd{Path: "/bin/sleep", Args: []string{"60"}})
+	if err != nil {
+		t.Fatalf("make (%v)", err)
+	}
+	defer elm.Scrub()
+	p, err := anchor.ParsePolicy([]byte(`{"rules": [{"who": ["*"], "verbs": ["get"], "anchors": ["/..."]}]}`))
+	if err != nil {
+		t.Fatalf("policy (%v)", err)
+	}
+	anchor.UsePolicy(p)
+	defer anchor.UsePolicy(nil)
+	a, err := anchor.OpenAudit(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("audit (%v)", err)
+	}
+	defer a.Close()
+	anchor.UseAudit(a)
+	defer anchor.UseAudit(nil)
+
+	rt.Listen("root", rootService{x})
+	y := anchor.YTerminal{X: caller.Dial(rt.ServerAddr(), "root").Call("Root")[0].(circuit.X)}
+	_, v := y.Get()
+	srv := v.(server.YServer)
+	if srv.Peek().Addr == "" {
+		t.Fatalf("server peek")
+	}
+	_, v = y.Walk([]string{"sleep"}).Get()
+	proc := v.(YProc)
+	if proc.Peek().Cmd.Path != "/bin/sleep" {
+		t.Fatalf("process peek")
+	}
+	for name, f := range map[string]func(){
+		"suicide": srv.Suicide,
+		"audit": func() {
+			if _, err := srv.Audit(time.Time{}); err != nil {
+				panic(err)
+			}
+		},
+		"scrub": proc.Scrub,
+		"signal": func() {
+			if err := proc.Signal("KILL"); err != nil {
+				panic(err)
+			}
+		},
+	} {
+		if err := denied(f); !errors.Is(err, anchor.ErrDenied) {
+			t.Fatalf("%s not denied (%v)", name, err)
+		}
+	}
+	if elm.(Proc).IsDone() {
+		t.Fatalf("process scrubbed")
+	}
+
+	// Denials, and scrubs through elements, are audited
+	anchor.UsePolicy(nil)
+	proc.Scrub()
+	rec, err := anchor.Audited(time.Time{})
+	if err != nil || len(rec) != 5 {
+		t.Fatalf("audited %v (%v)", rec, err)
+	}
+	verbs := map[string]bool{}
+	for _, r := range rec[:4] {
+		if r.Identity != anchor.Anonymous || !strings.Contains(r.Error, anchor.ErrDenied.Error()) {
+			t.Fatalf("denial record %+v", r)
+		}
+		verbs[r.Verb] = true
+	}
+	if !verbs[anchor.Admin] || !verbs[anchor.Scrub] || !verbs[anchor.Signal] {
+		t.Fatalf("denied verbs %v", verbs)
+	}
+	if r := rec[4]; r.Verb != anchor.Scrub || r.Anchor != "/X1/sleep" || r.Kind != anchor.Proc || r.Error != "" {
+		t.Fatalf("scrub record %+v", r)
+	}
+}
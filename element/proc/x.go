@@ -8,8 +8,10 @@
 package proc
 
 import (
+	"context"
 	"io"
 
+	"github.com/gocircuit/circuit/anchor"
 	"github.com/gocircuit/circuit/client"
 	xio "github.com/gocircuit/circuit/kit/x/io"
 	"github.com/gocircuit/circuit/use/circuit"
@@ -21,7 +23,28 @@ func init() {
 }
 
 type XProc struct {
-	Proc
+	p     Proc
+	guard anchor.Guard
+}
+
+// procVerbs are the verbs of the methods of XProc
+var procVerbs = anchor.Verbs{
+	"Wait":        anchor.Get,
+	"WaitContext": anchor.Get,
+	"Signal":      anchor.Signal,
+	"GetEnv":      anchor.Get,
+	"GetCmd":      anchor.Get,
+	"IsDone":      anchor.Get,
+	"Stdin":       anchor.Stdin,
+	"Stdout":      anchor.Get,
+	"Stderr":      anchor.Get,
+	"Peek":        anchor.Get,
+	"PeekBytes":   anchor.Get,
+	"Scrub":       anchor.Scrub,
+}
+
+func (x XProc) Authorize(method string) error {
+	return x.guard.Authorize(procVerbs, method)
 }
 
 // func unpack(stat Stat) Stat {
@@ -35,35 +58,60 @@ type XProc struct {
 // }
 
 func (x XProc) Wait() (client.ProcStat, error) {
-	stat, err := x.Proc.Wait()
+	stat, err := x.p.Wait()
 	stat.Exit = errors.Pack(stat.Exit)
 	return stat, errors.Pack(err)
 }
 
+func (x XProc) WaitContext(ctx context.Context) (client.ProcStat, error) {
+	stat, err := x.p.WaitContext(ctx)
+	return stat, errors.Pack(err)
+}
+
 func (x XProc) Signal(sig string) error {
-	return errors.Pack(x.Proc.Signal(sig))
+	err := x.p.Signal(sig)
+	x.guard.Audit(anchor.Signal, sig, err)
+	return errors.Pack(err)
 }
 
 func (x XProc) Stdin() circuit.X {
-	return xio.NewXWriteCloser(x.Proc.Stdin())
+	x.guard.Audit(anchor.Stdin, nil, nil)
+	return xio.NewXWriteCloser(x.p.Stdin())
 }
 
 func (x XProc) Stdout() circuit.X {
-	return xio.NewXReadCloser(x.Proc.Stdout())
+	return xio.NewXReadCloser(x.p.Stdout())
 }
 
 func (x XProc) Stderr() circuit.X {
-	return xio.NewXReadCloser(x.Proc.Stderr())
+	return xio.NewXReadCloser(x.p.Stderr())
 }
 
 func (x XProc) Peek() client.ProcStat {
-	ps := x.Proc.Peek()
+	ps := x.p.Peek()
 	ps.Exit = errors.Pack(ps.Exit)
 	return ps
 }
 
 func (x XProc) PeekBytes() []byte {
-	return x.Proc.PeekBytes()
+	return x.p.PeekBytes()
+}
+
+func (x XProc) GetEnv() []string {
+	return x.p.GetEnv()
+}
+
+func (x XProc) GetCmd() client.Cmd {
+	return x.p.GetCmd()
+}
+
+func (x XProc) IsDone() bool {
+	return x.p.IsDone()
+}
+
+func (x XProc) Scrub() {
+	x.p.Scrub()
+	x.guard.Audit(anchor.Scrub, nil, nil)
 }
 
 type YProc struct {
@@ -77,6 +125,21 @@ func (y YProc) Wait() (client.ProcStat, error) {
 	return ps, errors.Unpack(r[1])
 }
 
+// WaitContext returns the error of ctx if it is done first; other failures of the call are panics
+func (y YProc) WaitContext(ctx context.Context) (_ client.ProcStat, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if err = ctx.Err(); err == nil {
+				panic(r)
+			}
+		}
+	}()
+	r := y.X.CallContext(ctx, "WaitContext")
+	ps := r[0].(client.ProcStat)
+	ps.Exit = errors.Unpack(ps.Exit)
+	return ps, errors.Unpack(r[1])
+}
+
 func (y YProc) Signal(sig string) error {
 	r := y.X.Call("Signal", sig)
 	return errors.Unpack(r[0])
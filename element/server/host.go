@@ -0,0 +1,75 @@
+//go:build !windows
+
+package server
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// cpuTime returns the user and system CPU time used by this process
+func cpuTime() time.Duration {
+	var u syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &u); err != nil {
+		return 0
+	}
+	return time.Duration(u.Utime.Nano() + u.Stime.Nano())
+}
+
+// loadAvg returns the load averages of the host, which are zero where
+// /proc/loadavg is not available
+func loadAvg() (r [3]float64) {
+	b, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return
+	}
+	for i, f := range strings.Fields(string(b)) {
+		if i == len(r) {
+			break
+		}
+		r[i], _ = strconv.ParseFloat(f, 64)
+	}
+	return
+}
+
+// memInfo returns the total and available memory of the host, which are zero
+// where /proc/meminfo is not available
+func memInfo() (total, free uint64) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// e.g. "MemTotal:       16318712 kB"
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total = kb << 10
+		case "MemAvailable:":
+			free = kb << 10
+		}
+	}
+	return
+}
+
+// diskFree returns the space available to the server in the file system of dir
+func diskFree(dir string) uint64 {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(dir, &fs); err != nil {
+		return 0
+	}
+	return uint64(fs.Bavail) * uint64(fs.Bsize)
+}
@@ -0,0 +1,19 @@
+package server
+
+import "time"
+
+func cpuTime() time.Duration {
+	return 0
+}
+
+func loadAvg() (r [3]float64) {
+	return
+}
+
+func memInfo() (total, free uint64) {
+	return
+}
+
+func diskFree(dir string) uint64 {
+	return 0
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"errors"
+	"sync"
+)
+
+var keys struct {
+	sync.Mutex
+	reload func() error
+}
+
+// UseKeyReload sets the function which reloads the shared keys of this server,
+// or the list of revoked certificates of a server using TLS.
+func UseKeyReload(reload func() error) {
+	keys.Lock()
+	defer keys.Unlock()
+	keys.reload = reload
+}
+
+// ReloadKeys reloads the shared keys, or the list of revoked certificates,
+// of this server, which apply to connections made afterwards.
+func ReloadKeys() error {
+	keys.Lock()
+	reload := keys.reload
+	keys.Unlock()
+	if reload == nil {
+		return errors.New("server uses neither shared keys nor a revocation list")
+	}
+	return reload()
+}
@@ -12,12 +12,14 @@ import (
 	"errors"
 	"io"
 	"os"
+	"runtime"
 	"runtime/pprof"
 	"time"
 
 	"github.com/gocircuit/circuit/anchor"
 	cli "github.com/gocircuit/circuit/client/server"
 	"github.com/gocircuit/circuit/kit/interruptible"
+	"github.com/gocircuit/circuit/sys/acid"
 	"github.com/gocircuit/circuit/tissue"
 	"github.com/gocircuit/circuit/use/circuit"
 	"github.com/gocircuit/circuit/use/n"
@@ -33,6 +35,7 @@ type Server interface {
 type server struct {
 	addr   string
 	kin    *tissue.Kin
+	term   *anchor.Terminal
 	joined time.Time
 }
 
@@ -40,10 +43,12 @@ func init() {
 	anchor.RegisterElement("server", ef, yf)
 }
 
-func New(kin *tissue.Kin) Server {
+// New returns the server element of the anchor root term
+func New(kin *tissue.Kin, term *anchor.Terminal) Server {
 	return &server{
 		addr:   kin.Avatar().X.Addr().String(),
 		kin:    kin,
+		term:   term,
 		joined: time.Now(),
 	}
 }
@@ -60,6 +65,22 @@ func (s *server) Suicide() {
 	os.Exit(0)
 }
 
+func (s *server) ReloadKeys() error {
+	return ReloadKeys()
+}
+
+func (s *server) Audit(since time.Time) ([]cli.AuditRecord, error) {
+	return anchor.Audited(since)
+}
+
+func (s *server) RuntimeStat() (*acid.RuntimeStat, error) {
+	a, ok := circuit.DialSelf("acid").(*acid.Acid)
+	if !ok {
+		return nil, errors.New("no acid service")
+	}
+	return a.RuntimeStat()
+}
+
 func (s *server) Profile(name string) (io.ReadCloser, error) {
 	p := pprof.Lookup(name)
 	if p == nil {
@@ -86,6 +107,22 @@ func (s *server) Peek() cli.ServerStat {
 	return cli.ServerStat{
 		Addr:   s.addr,
 		Joined: s.joined,
+		Host:   HostStat(),
+	}
+}
+
+func (s *server) Top() cli.ServerTop {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return cli.ServerTop{
+		Addr:       s.addr,
+		Time:       time.Now(),
+		CPUTime:    cpuTime(),
+		NumCPU:     runtime.NumCPU(),
+		Mem:        m.Sys,
+		Load:       loadAvg(),
+		Goroutines: runtime.NumGoroutine(),
+		Elements:   s.term.Count(),
 	}
 }
 
@@ -101,7 +138,11 @@ func (s *server) IsDone() bool {
 func (s *server) Scrub() {}
 
 func (s *server) X() circuit.X {
-	return circuit.Ref(XServer{s})
+	return circuit.Ref(XServer{s: s})
+}
+
+func (s *server) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XServer{s: s, guard: g})
 }
 
 func ef(t *anchor.Terminal, arg any) (anchor.Element, error) {
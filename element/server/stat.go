@@ -0,0 +1,94 @@
+package server
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+
+	cli "github.com/gocircuit/circuit/client/server"
+)
+
+// host holds what circuit start knows about the server's host
+var host = struct {
+	sync.Mutex
+	varDir  string
+	version string
+	caps    map[string]bool
+	labels  map[string]string
+}{caps: make(map[string]bool), labels: make(map[string]string)}
+
+// Advertise adds a capability, such as an optional element backend the
+// server can run, to those reported by the server
+func Advertise(capability string) {
+	host.Lock()
+	defer host.Unlock()
+	host.caps[capability] = true
+}
+
+// Label attaches a key=value label to the server, which placement
+// constraints can select on
+func Label(key, value string) {
+	host.Lock()
+	defer host.Unlock()
+	host.labels[key] = value
+}
+
+// UseVarDir sets the var directory, whose free space the server reports
+func UseVarDir(dir string) {
+	host.Lock()
+	defer host.Unlock()
+	host.varDir = dir
+}
+
+// UseVersion sets the circuit version reported by the server. The version
+// of the main module is reported when it is not set.
+func UseVersion(v string) {
+	host.Lock()
+	defer host.Unlock()
+	host.version = v
+}
+
+// HostStat returns the current description of this server's host
+func HostStat() cli.HostStat {
+	host.Lock()
+	dir, v := host.varDir, host.version
+	caps := make([]string, 0, len(host.caps))
+	for c := range host.caps {
+		caps = append(caps, c)
+	}
+	var labels map[string]string
+	if len(host.labels) > 0 {
+		labels = make(map[string]string, len(host.labels))
+		for k, v := range host.labels {
+			labels[k] = v
+		}
+	}
+	host.Unlock()
+	sort.Strings(caps)
+
+	if v == "" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			v = bi.Main.Version
+		}
+	}
+	name, _ := os.Hostname()
+	total, free := memInfo()
+	r := cli.HostStat{
+		Hostname:     name,
+		OS:           runtime.GOOS,
+		Arch:         runtime.GOARCH,
+		NumCPU:       runtime.NumCPU(),
+		MemTotal:     total,
+		MemFree:      free,
+		Load:         loadAvg(),
+		Version:      v,
+		Capabilities: caps,
+		Labels:       labels,
+	}
+	if dir != "" {
+		r.DiskFree = diskFree(dir)
+	}
+	return r
+}
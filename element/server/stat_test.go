@@ -0,0 +1,31 @@
+package server
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestHostStat(t *testing.T) {
+	Advertise("wasm")
+	Advertise("podman")
+	Advertise("wasm")
+	UseVarDir(t.TempDir())
+	UseVersion("v1.2.3")
+
+	h := HostStat()
+	if h.OS != runtime.GOOS || h.Arch != runtime.GOARCH || h.NumCPU != runtime.NumCPU() {
+		t.Fatalf("platform %+v", h)
+	}
+	if h.Version != "v1.2.3" {
+		t.Fatalf("version %q", h.Version)
+	}
+	if len(h.Capabilities) != 2 || h.Capabilities[0] != "podman" || h.Capabilities[1] != "wasm" {
+		t.Fatalf("capabilities %v", h.Capabilities)
+	}
+	if !h.Capable("podman") || h.Capable("docker") {
+		t.Fatalf("capable %v", h.Capabilities)
+	}
+	if runtime.GOOS == "linux" && (h.MemTotal == 0 || h.MemFree > h.MemTotal || h.DiskFree == 0) {
+		t.Fatalf("resources %+v", h)
+	}
+}
@@ -10,9 +10,12 @@ package server
 import (
 	// "fmt"
 	"io"
+	"time"
 
+	"github.com/gocircuit/circuit/anchor"
 	cli "github.com/gocircuit/circuit/client/server"
 	xio "github.com/gocircuit/circuit/kit/x/io"
+	"github.com/gocircuit/circuit/sys/acid"
 	"github.com/gocircuit/circuit/use/circuit"
 	"github.com/gocircuit/circuit/use/errors"
 )
@@ -23,19 +26,84 @@ func init() {
 
 // XServer…
 type XServer struct {
-	*server
+	s     *server
+	guard anchor.Guard
+}
+
+// serverVerbs are the verbs of the methods of XServer
+var serverVerbs = anchor.Verbs{
+	"Profile":     anchor.Admin,
+	"Peek":        anchor.Get,
+	"PeekBytes":   anchor.Get,
+	"Top":         anchor.Get,
+	"IsDone":      anchor.Get,
+	"Rejoin":      anchor.Admin,
+	"Suicide":     anchor.Admin,
+	"ReloadKeys":  anchor.Admin,
+	"Audit":       anchor.Admin,
+	"RuntimeStat": anchor.Admin,
+	"Scrub":       anchor.Scrub,
+}
+
+func (x XServer) Authorize(method string) error {
+	return x.guard.Authorize(serverVerbs, method)
 }
 
 func (x XServer) Profile(name string) (circuit.X, error) {
-	r, err := x.server.Profile(name)
+	r, err := x.s.Profile(name)
 	if err != nil {
 		return nil, errors.Pack(err)
 	}
 	return xio.NewXReadCloser(r), nil
 }
 
+func (x XServer) Peek() cli.ServerStat {
+	return x.s.Peek()
+}
+
+func (x XServer) PeekBytes() []byte {
+	return x.s.PeekBytes()
+}
+
+func (x XServer) Top() cli.ServerTop {
+	return x.s.Top()
+}
+
+func (x XServer) IsDone() bool {
+	return x.s.IsDone()
+}
+
 func (x XServer) Rejoin(addr string) error {
-	return errors.Pack(x.server.Rejoin(addr))
+	err := x.s.Rejoin(addr)
+	x.guard.Audit(anchor.Admin, addr, err)
+	return errors.Pack(err)
+}
+
+func (x XServer) Suicide() {
+	x.guard.Audit(anchor.Admin, "Suicide", nil)
+	x.s.Suicide()
+}
+
+func (x XServer) ReloadKeys() error {
+	err := x.s.ReloadKeys()
+	x.guard.Audit(anchor.Admin, "ReloadKeys", err)
+	return errors.Pack(err)
+}
+
+// Audit returns the audit log to callers allowed to administer the server
+func (x XServer) Audit(since time.Time) ([]cli.AuditRecord, error) {
+	r, err := x.s.Audit(since)
+	return r, errors.Pack(err)
+}
+
+func (x XServer) RuntimeStat() (*acid.RuntimeStat, error) {
+	r, err := x.s.RuntimeStat()
+	return r, errors.Pack(err)
+}
+
+func (x XServer) Scrub() {
+	x.s.Scrub()
+	x.guard.Audit(anchor.Scrub, nil, nil)
 }
 
 // YServer…
@@ -55,6 +123,10 @@ func (y YServer) Peek() cli.ServerStat {
 	return y.X.Call("Peek")[0].(cli.ServerStat)
 }
 
+func (y YServer) Top() cli.ServerTop {
+	return y.X.Call("Top")[0].(cli.ServerTop)
+}
+
 func (y YServer) PeekBytes() []byte {
 	return y.X.Call("PeekBytes")[0].([]byte)
 }
@@ -63,6 +135,18 @@ func (y YServer) Rejoin(addr string) error {
 	return errors.Unpack(y.X.Call("Rejoin", addr)[0])
 }
 
+func (y YServer) ReloadKeys() error {
+	return errors.Unpack(y.X.Call("ReloadKeys")[0])
+}
+
+func (y YServer) Audit(since time.Time) ([]cli.AuditRecord, error) {
+	r := y.X.Call("Audit", since)
+	if err := errors.Unpack(r[1]); err != nil {
+		return nil, err
+	}
+	return r[0].([]cli.AuditRecord), nil
+}
+
 func (y YServer) IsDone() bool {
 	return y.X.Call("IsDone")[0].(bool)
 }
@@ -74,3 +158,11 @@ func (y YServer) Scrub() {
 func (y YServer) Suicide() {
 	y.X.Call("Suicide")
 }
+
+func (y YServer) RuntimeStat() (*acid.RuntimeStat, error) {
+	r := y.X.Call("RuntimeStat")
+	if err := errors.Unpack(r[1]); err != nil {
+		return nil, err
+	}
+	return r[0].(*acid.RuntimeStat), nil
+}
@@ -64,7 +64,11 @@ func MakeValve(n int) Valve {
 }
 
 func (v *valve) X() circuit.X {
-	return circuit.Ref(XValve{v})
+	return circuit.Ref(XValve{v: v})
+}
+
+func (v *valve) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XValve{v: v, guard: g})
 }
 
 func (v *valve) incSend() {
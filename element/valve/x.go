@@ -10,6 +10,7 @@ package valve
 import (
 	"io"
 
+	"github.com/gocircuit/circuit/anchor"
 	"github.com/gocircuit/circuit/client"
 	xio "github.com/gocircuit/circuit/kit/x/io"
 	"github.com/gocircuit/circuit/use/circuit"
@@ -21,11 +22,29 @@ func init() {
 }
 
 type XValve struct {
-	Valve Valve
+	v     Valve
+	guard anchor.Guard
+}
+
+// valveVerbs are the verbs of the methods of XValve. Sending to a channel,
+// and closing it, write to it as stdin writes to a process.
+var valveVerbs = anchor.Verbs{
+	"Send":      anchor.Stdin,
+	"Close":     anchor.Stdin,
+	"Recv":      anchor.Get,
+	"Scrub":     anchor.Scrub,
+	"IsDone":    anchor.Get,
+	"Cap":       anchor.Get,
+	"Stat":      anchor.Get,
+	"PeekBytes": anchor.Get,
+}
+
+func (x XValve) Authorize(method string) error {
+	return x.guard.Authorize(valveVerbs, method)
 }
 
 func (x XValve) Send() (circuit.X, error) {
-	w, err := x.Valve.Send()
+	w, err := x.v.Send()
 	if err != nil {
 		return nil, errors.Pack(err)
 	}
@@ -33,11 +52,11 @@ func (x XValve) Send() (circuit.X, error) {
 }
 
 func (x XValve) Close() error {
-	return errors.Pack(x.Valve.Close())
+	return errors.Pack(x.v.Close())
 }
 
 func (x XValve) Recv() (circuit.X, error) {
-	r, err := x.Valve.Recv()
+	r, err := x.v.Recv()
 	if err != nil {
 		return nil, errors.Pack(err)
 	}
@@ -45,23 +64,24 @@ func (x XValve) Recv() (circuit.X, error) {
 }
 
 func (x XValve) Scrub() {
-	x.Valve.Scrub()
+	x.v.Scrub()
+	x.guard.Audit(anchor.Scrub, nil, nil)
 }
 
 func (x XValve) IsDone() bool {
-	return x.Valve.IsDone()
+	return x.v.IsDone()
 }
 
 func (x XValve) Cap() int {
-	return x.Valve.Cap()
+	return x.v.Cap()
 }
 
 func (x XValve) Stat() client.ChanStat {
-	return x.Valve.Stat()
+	return x.v.Stat()
 }
 
 func (x XValve) PeekBytes() []byte {
-	return x.Valve.PeekBytes()
+	return x.v.PeekBytes()
 }
 
 type YValve struct {
@@ -0,0 +1,126 @@
+package wasm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	ws "github.com/gocircuit/circuit/client/wasm"
+)
+
+// ExecCommand is the hidden circuit subcommand which runs a module in a child
+// process of the server, so that WASI can own the standard streams of the
+// process without disturbing the server's.
+const ExecCommand = "wasm-exec"
+
+// The child reads its configuration from the first extra file and reports its
+// result on the second.
+const (
+	confFD   = 3
+	resultFD = 4
+)
+
+// Runtime is a WebAssembly engine able to run the wasm element's modules.
+type Runtime interface {
+	// Run executes the _start function of the module at exe.Path, wiring WASI,
+	// when enabled, to the standard streams of the process. Run enforces the
+	// limits of exe and returns a *LimitError when one terminates the module.
+	Run(exe *ws.Execute) (exit int, stats ws.Statistics, err error)
+	Version() ws.Version
+}
+
+// LimitError reports that a module was terminated by one of its limits
+type LimitError struct {
+	Limit ws.LimitKind
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("wasm %s limit exceeded", e.Limit)
+}
+
+var runtimes = struct {
+	sync.Mutex
+	m   map[string]Runtime
+	use string
+}{m: make(map[string]Runtime), use: ws.RuntimeWazero}
+
+// RegisterRuntime makes a runtime available under name
+func RegisterRuntime(name string, rt Runtime) {
+	runtimes.Lock()
+	defer runtimes.Unlock()
+	runtimes.m[name] = rt
+}
+
+// UseRuntime sets the runtime of executions which do not name one
+func UseRuntime(name string) error {
+	runtimes.Lock()
+	defer runtimes.Unlock()
+	if _, ok := runtimes.m[name]; !ok {
+		return fmt.Errorf("wasm runtime %s not available, have %v", name, runtimeNames())
+	}
+	runtimes.use = name
+	return nil
+}
+
+// lookupRuntime resolves the runtime named by an execution
+func lookupRuntime(name string) (string, Runtime, error) {
+	runtimes.Lock()
+	defer runtimes.Unlock()
+	if name == "" {
+		name = runtimes.use
+	}
+	rt, ok := runtimes.m[name]
+	if !ok {
+		return "", nil, fmt.Errorf("wasm runtime %s not available, have %v", name, runtimeNames())
+	}
+	return name, rt, nil
+}
+
+func runtimeNames() (r []string) {
+	for n := range runtimes.m {
+		r = append(r, n)
+	}
+	sort.Strings(r)
+	return
+}
+
+// result is what the child process reports back to the element
+type result struct {
+	ws.Result
+	Stats ws.Statistics
+}
+
+// Exec runs the module configured by the parent server and returns the exit
+// code for the child process.
+func Exec() int {
+	var exe ws.Execute
+	conf := os.NewFile(confFD, "conf")
+	err := json.NewDecoder(conf).Decode(&exe)
+	conf.Close()
+
+	r := &result{Result: ws.Result{Code: 1}}
+	if err != nil {
+		r.Err = fmt.Sprintf("reading wasm configuration: %v", err)
+	} else if _, rt, err := lookupRuntime(exe.Runtime); err != nil {
+		r.Err = err.Error()
+	} else {
+		var exit int
+		exit, r.Stats, err = rt.Run(&exe)
+		r.Code = uint(exit)
+		if err != nil {
+			r.Err = err.Error()
+		}
+		var le *LimitError
+		if errors.As(err, &le) {
+			r.Limit = le.Limit
+		}
+	}
+
+	out := os.NewFile(resultFD, "result")
+	json.NewEncoder(out).Encode(r)
+	out.Close()
+	return int(r.Code)
+}
@@ -0,0 +1,320 @@
+package wasm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocircuit/circuit/anchor"
+	ws "github.com/gocircuit/circuit/client/wasm"
+	"github.com/gocircuit/circuit/kit/interruptible"
+	"github.com/gocircuit/circuit/use/circuit"
+)
+
+// FunctionRuntime is implemented by runtimes which can keep a module loaded
+// in the server to serve invocations of its exported functions.
+type FunctionRuntime interface {
+	Load(exe *ws.Execute) (Module, error)
+}
+
+// Module is a compiled module from which instances are created
+type Module interface {
+	Instantiate() (Instance, error)
+	Close()
+}
+
+// Instance is a module instance serving one invocation at a time. Limits of
+// the execution apply to each invocation.
+type Instance interface {
+	Invoke(name string, args []any) ([]any, error)
+	Close()
+}
+
+// function is a wasm element in function mode
+type function struct {
+	pool   *pool
+	exit   chan struct{}
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	stderr io.ReadCloser
+
+	sync.Mutex
+	stat ws.Status
+}
+
+func makeFunction(exe *ws.Execute, name string, rt Runtime) (_ Wasm, err error) {
+	frt, ok := rt.(FunctionRuntime)
+	if !ok {
+		return nil, fmt.Errorf("wasm runtime %s does not support function mode", name)
+	}
+	if len(exe.Module) == 0 && exe.Path == "" {
+		return nil, errors.New("wasm module path or bytes missing")
+	}
+	mod, err := frt.Load(exe)
+	if err != nil {
+		return nil, err
+	}
+	n := exe.Function.Instances
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+	f := &function{
+		pool: newPool(mod, n),
+		exit: make(chan struct{}),
+		stat: ws.Status{
+			Created: time.Now(),
+			Path:    exe.Path,
+			Args:    exe.WasiConf.Args,
+			Running: true,
+			Started: time.Now(),
+			Runtime: name,
+			Version: rt.Version(),
+		},
+	}
+	// Functions have no standard streams
+	var (
+		r io.ReadCloser
+		w io.WriteCloser
+	)
+	r, f.stdin = interruptible.BufferPipe(1)
+	r.Close()
+	f.stdout, w = interruptible.BufferPipe(1)
+	w.Close()
+	f.stderr, w = interruptible.BufferPipe(1)
+	w.Close()
+	// Fail early on modules which cannot be instantiated
+	in, err := f.pool.get()
+	if err != nil {
+		mod.Close()
+		return nil, err
+	}
+	f.pool.put(in, false)
+	return f, nil
+}
+
+func (f *function) Invoke(name string, args ...any) ([]any, error) {
+	if f.IsDone() {
+		return nil, errors.New("wasm function module scrubbed")
+	}
+	in, err := f.pool.get()
+	if err != nil {
+		return nil, err
+	}
+	r, err := in.Invoke(name, args)
+	// A failed invocation may leave the instance in any state
+	f.pool.put(in, err != nil)
+
+	f.Lock()
+	defer f.Unlock()
+	f.stat.Invocations++
+	var le *LimitError
+	if errors.As(err, &le) {
+		f.stat.Limit = le.Limit
+	}
+	return r, err
+}
+
+func (f *function) Wait() (*ws.Status, error) {
+	<-f.exit
+	return f.Peek()
+}
+
+func (f *function) Peek() (*ws.Status, error) {
+	f.Lock()
+	defer f.Unlock()
+	stat := f.stat
+	return &stat, nil
+}
+
+func (f *function) PeekBytes() []byte {
+	s, _ := f.Peek()
+	b, _ := json.MarshalIndent(s, "", "\t")
+	return b
+}
+
+func (f *function) Scrub() {
+	f.Lock()
+	defer f.Unlock()
+	select {
+	case <-f.exit:
+		return
+	default:
+	}
+	f.stat.Running, f.stat.Finished = false, time.Now()
+	close(f.exit)
+	f.pool.close()
+}
+
+func (f *function) Signal(sig string) error {
+	return errors.New("wasm function modules cannot be signalled")
+}
+
+func (f *function) IsDone() bool {
+	select {
+	case <-f.exit:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f *function) Stdin() io.WriteCloser {
+	return f.stdin
+}
+
+func (f *function) Stdout() io.ReadCloser {
+	return f.stdout
+}
+
+func (f *function) Stderr() io.ReadCloser {
+	return f.stderr
+}
+
+func (f *function) X() circuit.X {
+	return circuit.Ref(XWasm{w: f})
+}
+
+func (f *function) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XWasm{w: f, guard: g})
+}
+
+// pool keeps up to n instances of a module, created on demand
+type pool struct {
+	mod  Module
+	idle chan Instance
+	live chan struct{}
+
+	sync.Mutex
+	closed bool
+}
+
+func newPool(mod Module, n int) *pool {
+	return &pool{
+		mod:  mod,
+		idle: make(chan Instance, n),
+		live: make(chan struct{}, n),
+	}
+}
+
+// get returns an idle instance, or a new one while there are fewer than n
+func (p *pool) get() (Instance, error) {
+	select {
+	case in := <-p.idle:
+		return in, nil
+	default:
+	}
+	select {
+	case in := <-p.idle:
+		return in, nil
+	case p.live <- struct{}{}:
+		in, err := p.mod.Instantiate()
+		if err != nil {
+			<-p.live
+			return nil, err
+		}
+		return in, nil
+	}
+}
+
+// put returns an instance to the pool, or discards it when broken
+func (p *pool) put(in Instance, broken bool) {
+	p.Lock()
+	defer p.Unlock()
+	if broken || p.closed {
+		in.Close()
+		<-p.live
+		return
+	}
+	p.idle <- in
+}
+
+func (p *pool) close() {
+	p.Lock()
+	defer p.Unlock()
+	p.closed = true
+	for {
+		select {
+		case in := <-p.idle:
+			in.Close()
+			<-p.live
+		default:
+			p.mod.Close()
+			return
+		}
+	}
+}
+
+// convertArg converts an invocation argument to the Go type of the wasm value
+// type t, one of i32, i64, f32 and f64. Strings, as given on the command
+// line, are parsed.
+func convertArg(t string, v any) (any, error) {
+	if s, ok := v.(string); ok {
+		var err error
+		switch t {
+		case "i32", "i64":
+			v, err = strconv.ParseInt(s, 0, 64)
+		case "f32", "f64":
+			v, err = strconv.ParseFloat(s, 64)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("argument %q is not an %s", s, t)
+		}
+	}
+	var (
+		i     int64
+		f     float64
+		isInt bool
+	)
+	switch x := v.(type) {
+	case int:
+		i, isInt = int64(x), true
+	case int32:
+		i, isInt = int64(x), true
+	case int64:
+		i, isInt = x, true
+	case uint32:
+		i, isInt = int64(x), true
+	case uint64:
+		i, isInt = int64(x), true
+	case float32:
+		f = float64(x)
+	case float64:
+		f = x
+	default:
+		return nil, fmt.Errorf("argument %v of type %T is not a number", v, v)
+	}
+	if isInt {
+		f = float64(i)
+	} else {
+		i = int64(f)
+	}
+	switch t {
+	case "i32":
+		return int32(i), nil
+	case "i64":
+		return i, nil
+	case "f32":
+		return float32(f), nil
+	case "f64":
+		return f, nil
+	}
+	return nil, fmt.Errorf("wasm value type %s not supported", t)
+}
+
+func convertArgs(types []string, args []any) (r []any, err error) {
+	if len(types) != len(args) {
+		return nil, fmt.Errorf("function takes %d arguments, got %d", len(types), len(args))
+	}
+	r = make([]any, len(args))
+	for i, a := range args {
+		if r[i], err = convertArg(types[i], a); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
@@ -3,37 +3,44 @@ package wasm
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
-	"runtime"
+	"sync"
+	"time"
 
 	"github.com/gocircuit/circuit/anchor"
 	ws "github.com/gocircuit/circuit/client/wasm"
 	"github.com/gocircuit/circuit/element/proc"
+	"github.com/gocircuit/circuit/kit/interruptible"
 	"github.com/gocircuit/circuit/use/circuit"
-	"github.com/second-state/WasmEdge-go/wasmedge"
 )
 
-// Statistics wasm runtime statistics
-type Statistics struct {
-}
+// StdBufferLen is the size of the buffers of the standard streams of a module
+const StdBufferLen = 32e3
+
+// TimeoutGrace is how long past its timeout a module is given to terminate
+// before its process is killed
+const TimeoutGrace = 5 * time.Second
 
 type Wasm interface {
 	ws.Wasm
 	X() circuit.X
 }
 
+// container runs a module in a child process of the server, see Exec
 type container struct {
-	name string
-	cmd  *exec.Cmd
+	cmd *exec.Cmd
+	tmp string // module uploaded with the request, removed on scrub
 
 	stdin  io.WriteCloser
 	stdout io.ReadCloser
 	stderr io.ReadCloser
-	exit   <-chan error
+	exit   <-chan struct{}
 
-	vm *wasmedge.VM
+	sync.Mutex
+	stat ws.Status
 }
 
 func init() {
@@ -41,53 +48,141 @@ func init() {
 }
 
 func MakeContainer(exe *ws.Execute) (_ Wasm, err error) {
-	wasmedge.SetLogErrorLevel()
-
-	// build wasm config from execute config
-	conf := buildConfigure(exe)
+	name, rt, err := lookupRuntime(exe.Runtime)
+	if err != nil {
+		return nil, err
+	}
+	if name == ws.RuntimeWazero {
+		if err = wazeroLimits(exe); err != nil {
+			return nil, err
+		}
+	}
+	if exe.Function.IsFunction {
+		return makeFunction(exe, name, rt)
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
 
-	//var conf = wasmedge.NewConfigure(wasmedge.REFERENCE_TYPES)
-	conf.AddConfig(wasmedge.WASI)
-	var vm = wasmedge.NewVMWithConfig(conf)
-	var wasi = vm.GetImportModule(wasmedge.WASI)
+	con := &container{
+		stat: ws.Status{
+			Created: time.Now(),
+			Path:    exe.Path,
+			Args:    exe.WasiConf.Args,
+			Runtime: name,
+			Version: rt.Version(),
+		},
+	}
+	// The child reads the module from a file, so uploaded bytes are spilled to disk
+	conf := *exe
+	conf.Runtime = name
+	if len(exe.Module) > 0 {
+		f, err := os.CreateTemp("", "circuit-*.wasm")
+		if err != nil {
+			return nil, err
+		}
+		_, err = f.Write(exe.Module)
+		f.Close()
+		if err != nil {
+			os.Remove(f.Name())
+			return nil, err
+		}
+		con.tmp, conf.Path, conf.Module = f.Name(), f.Name(), nil
+	}
+	if conf.Path == "" {
+		return nil, errors.New("wasm module path or bytes missing")
+	}
+	defer func() {
+		if err != nil && con.tmp != "" {
+			os.Remove(con.tmp)
+		}
+	}()
+	buf, err := json.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
 
-	ch := make(chan error, 1)
-	con := &container{}
+	confr, confw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	resr, resw, err := os.Pipe()
+	if err != nil {
+		confr.Close()
+		confw.Close()
+		return nil, err
+	}
+	con.cmd = exec.Command(self, ExecCommand)
+	con.cmd.ExtraFiles = []*os.File{confr, resw}
+	con.cmd.Stdin, con.stdin = interruptible.BufferPipe(StdBufferLen)
+	con.stdout, con.cmd.Stdout = interruptible.BufferPipe(StdBufferLen)
+	con.stderr, con.cmd.Stderr = interruptible.BufferPipe(StdBufferLen)
+	err = con.cmd.Start()
+	confr.Close()
+	resw.Close()
+	if err != nil {
+		confw.Close()
+		resr.Close()
+		return nil, err
+	}
+	go func() {
+		confw.Write(buf)
+		confw.Close()
+	}()
 
-	wasi.InitWasi(
-		os.Args[1:],     // The args
-		os.Environ(),    // The envs
-		[]string{".:."}, // The mapping directories
+	con.stat.Running, con.stat.Started = true, time.Now()
+	ch := make(chan struct{})
+	con.exit = ch
+	// The runtime stops a module at its timeout and reports it. Should the
+	// child not comply, it is killed after a grace period.
+	var (
+		expired bool
+		timer   *time.Timer
 	)
-
+	if exe.Timeout > 0 {
+		timer = time.AfterFunc(exe.Timeout+TimeoutGrace, func() {
+			con.Lock()
+			expired = true
+			con.Unlock()
+			con.cmd.Process.Kill()
+		})
+	}
 	go func() {
-		ch <- con.cmd.Wait()
+		var r result
+		derr := json.NewDecoder(resr).Decode(&r)
+		resr.Close()
+		// Release the stdin copier, which Wait would otherwise block on
+		con.stdin.Close()
+		werr := con.cmd.Wait()
+		if timer != nil {
+			timer.Stop()
+		}
+
+		con.Lock()
+		con.stat.Running, con.stat.Finished = false, time.Now()
+		if derr == nil {
+			con.stat.ExitCode, con.stat.Err, con.stat.Stats = int(r.Code), r.Err, r.Stats
+			con.stat.Limit = r.Limit
+		} else {
+			// The child did not get to report, e.g. it was killed by a signal
+			con.stat.ExitCode = con.cmd.ProcessState.ExitCode()
+			if werr != nil {
+				con.stat.Err = werr.Error()
+			}
+			if expired {
+				con.stat.Limit = ws.LimitTimeout
+			}
+		}
+		con.Unlock()
+
 		close(ch)
 		con.cmd.Stdout.(io.Closer).Close()
 		con.cmd.Stderr.(io.Closer).Close()
 	}()
-
-	// Determine if functinal or application wasm execution
-	// Instantiate wasm. _start refers to the main() function
-	vm.RunWasmFile(os.Args[1], "_start")
-
-	runtime.SetFinalizer(con,
-		func(c *container) {
-			c.vm.Release()
-		},
-	)
-
-	conf.Release()
-
 	return con, nil
 }
 
-func buildConfigure(exe *ws.Execute) *wasmedge.Configure {
-	conf := &wasmedge.Configure{}
-
-	return conf
-}
-
 func (con *container) Wait() (_ *ws.Status, err error) {
 	<-con.exit
 	return con.Peek()
@@ -105,8 +200,11 @@ func (con *container) Stderr() io.ReadCloser {
 	return con.stderr
 }
 
-func (con *container) Peek() (stat *ws.Status, err error) {
-	return
+func (con *container) Peek() (*ws.Status, error) {
+	con.Lock()
+	defer con.Unlock()
+	stat := con.stat
+	return &stat, nil
 }
 
 func (con *container) PeekBytes() []byte {
@@ -120,7 +218,17 @@ func (con *container) PeekBytes() []byte {
 }
 
 func (con *container) Scrub() {
-	//exec.Command(dkr, "rm", con.name).Run()
+	if !con.IsDone() {
+		con.cmd.Process.Kill()
+		<-con.exit
+	}
+	if con.tmp != "" {
+		os.Remove(con.tmp)
+	}
+}
+
+func (con *container) Invoke(string, ...any) ([]any, error) {
+	return nil, errors.New("wasm module is not in function mode")
 }
 
 func (con *container) Signal(sig string) error {
@@ -128,8 +236,8 @@ func (con *container) Signal(sig string) error {
 	if !ok {
 		return errors.New("signal name not recognized")
 	}
-	if con.cmd.Process == nil {
-		return errors.New("no process")
+	if con.IsDone() {
+		return errors.New("no running module to signal")
 	}
 	return con.cmd.Process.Signal(signo)
 }
@@ -144,13 +252,17 @@ func (con *container) IsDone() bool {
 }
 
 func (con *container) X() circuit.X {
-	return circuit.Ref(XWasm{con})
+	return circuit.Ref(XWasm{w: con})
+}
+
+func (con *container) GuardedX(g anchor.Guard) circuit.X {
+	return circuit.Ref(XWasm{w: con, guard: g})
 }
 
 func ef(t *anchor.Terminal, arg any) (anchor.Element, error) {
 	exe, ok := arg.(ws.Execute)
 	if !ok {
-		return nil, errors.New("invalid wasm argument")
+		return nil, fmt.Errorf("invalid argument to wasm element factory, arg=%T", arg)
 	}
 
 	w, err := MakeContainer(&exe)
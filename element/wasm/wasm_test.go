@@ -0,0 +1,185 @@
+package wasm
+
+import (
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	ws "github.com/gocircuit/circuit/client/wasm"
+)
+
+// hello writes "hi\n" to stdout and exits with code 7
+//
+//	(module
+//	  (import "wasi_snapshot_preview1" "proc_exit" (func $exit (param i32)))
+//	  (import "wasi_snapshot_preview1" "fd_write" (func $write (param i32 i32 i32 i32) (result i32)))
+//	  (memory (export "memory") 1)
+//	  (data (i32.const 0) "\08\00\00\00\03\00\00\00hi\n")
+//	  (func (export "_start")
+//	    (drop (call $write (i32.const 1) (i32.const 0) (i32.const 1) (i32.const 16)))
+//	    (call $exit (i32.const 7))))
+var hello = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, 0x01, 0x10, 0x03, 0x60,
+	0x01, 0x7f, 0x00, 0x60, 0x04, 0x7f, 0x7f, 0x7f, 0x7f, 0x01, 0x7f, 0x60,
+	0x00, 0x00, 0x02, 0x46, 0x02, 0x16, 0x77, 0x61, 0x73, 0x69, 0x5f, 0x73,
+	0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x5f, 0x70, 0x72, 0x65, 0x76,
+	0x69, 0x65, 0x77, 0x31, 0x09, 0x70, 0x72, 0x6f, 0x63, 0x5f, 0x65, 0x78,
+	0x69, 0x74, 0x00, 0x00, 0x16, 0x77, 0x61, 0x73, 0x69, 0x5f, 0x73, 0x6e,
+	0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x5f, 0x70, 0x72, 0x65, 0x76, 0x69,
+	0x65, 0x77, 0x31, 0x08, 0x66, 0x64, 0x5f, 0x77, 0x72, 0x69, 0x74, 0x65,
+	0x00, 0x01, 0x03, 0x02, 0x01, 0x02, 0x05, 0x03, 0x01, 0x00, 0x01, 0x07,
+	0x13, 0x02, 0x06, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x00, 0x02, 0x06,
+	0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x02, 0x00, 0x0a, 0x13, 0x01, 0x11,
+	0x00, 0x41, 0x01, 0x41, 0x00, 0x41, 0x01, 0x41, 0x10, 0x10, 0x01, 0x1a,
+	0x41, 0x07, 0x10, 0x00, 0x0b, 0x0b, 0x11, 0x01, 0x00, 0x41, 0x00, 0x0b,
+	0x0b, 0x08, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x68, 0x69, 0x0a,
+}
+
+// spin calls a function in an endless loop, with two pages of memory
+//
+//	(module
+//	  (memory 2)
+//	  (func $f)
+//	  (func (export "_start") (loop (call $f) (br 0))))
+var spin = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, 0x01, 0x04, 0x01, 0x60,
+	0x00, 0x00, 0x03, 0x03, 0x02, 0x00, 0x00, 0x05, 0x03, 0x01, 0x00, 0x02,
+	0x07, 0x0a, 0x01, 0x06, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x00, 0x01,
+	0x0a, 0x0e, 0x02, 0x02, 0x00, 0x0b, 0x09, 0x00, 0x03, 0x40, 0x10, 0x00,
+	0x0c, 0x00, 0x0b, 0x0b,
+}
+
+// loop loops endlessly without calls
+//
+//	(module
+//	  (func (export "_start") (loop (br 0))))
+var loop = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, 0x01, 0x04, 0x01, 0x60,
+	0x00, 0x00, 0x03, 0x02, 0x01, 0x00, 0x07, 0x0a, 0x01, 0x06, 0x5f, 0x73,
+	0x74, 0x61, 0x72, 0x74, 0x00, 0x00, 0x0a, 0x09, 0x01, 0x07, 0x00, 0x03,
+	0x40, 0x0c, 0x00, 0x0b, 0x0b,
+}
+
+// add is a function module
+//
+//	(module
+//	  (func (export "add") (param i32 i32) (result i32)
+//	    (i32.add (local.get 0) (local.get 1))))
+var add = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, 0x01, 0x07, 0x01, 0x60,
+	0x02, 0x7f, 0x7f, 0x01, 0x7f, 0x03, 0x02, 0x01, 0x00, 0x07, 0x07, 0x01,
+	0x03, 0x61, 0x64, 0x64, 0x00, 0x00, 0x0a, 0x09, 0x01, 0x07, 0x00, 0x20,
+	0x00, 0x20, 0x01, 0x6a, 0x0b,
+}
+
+// The test binary doubles as the child process running the modules
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == ExecCommand {
+		os.Exit(Exec())
+	}
+	os.Exit(m.Run())
+}
+
+func TestWazero(t *testing.T) {
+	w, err := MakeContainer(&ws.Execute{
+		Module:   hello,
+		Runtime:  ws.RuntimeWazero,
+		WasiConf: ws.WasiConf{Enable: true},
+	})
+	if err != nil {
+		t.Fatalf("make (%v)", err)
+	}
+	out, err := io.ReadAll(w.Stdout())
+	if err != nil || string(out) != "hi\n" {
+		t.Fatalf("stdout %q (%v)", out, err)
+	}
+	stat, err := w.Wait()
+	if err != nil {
+		t.Fatalf("wait (%v)", err)
+	}
+	if stat.Running || stat.ExitCode != 7 || stat.Err != "" || stat.Runtime != ws.RuntimeWazero {
+		t.Fatalf("status %+v", stat)
+	}
+	w.Scrub()
+}
+
+func TestUnknownRuntime(t *testing.T) {
+	if _, err := MakeContainer(&ws.Execute{Module: hello, Runtime: "nope"}); err == nil {
+		t.Fatalf("expecting error")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	for _, x := range []struct {
+		exe   ws.Execute
+		limit ws.LimitKind
+	}{
+		{ws.Execute{Module: spin, Fuel: 1000, Timeout: time.Minute}, ws.LimitFuel},
+		{ws.Execute{Module: loop, Fuel: 1000, Timeout: 100 * time.Millisecond}, ws.LimitTimeout},
+		{ws.Execute{Module: spin, Timeout: 100 * time.Millisecond}, ws.LimitTimeout},
+		{ws.Execute{Module: spin, MaxMemoryPageSize: 1}, ws.LimitMemory},
+	} {
+		w, err := MakeContainer(&x.exe)
+		if err != nil {
+			t.Fatalf("make (%v)", err)
+		}
+		stat, _ := w.Wait()
+		if stat.Limit != x.limit || stat.ExitCode == 0 {
+			t.Fatalf("expecting %s limit, got %+v", x.limit, stat)
+		}
+		if x.limit == ws.LimitFuel && stat.Stats.TotalCost <= 1000 {
+			t.Fatalf("fuel %d", stat.Stats.TotalCost)
+		}
+		w.Scrub()
+	}
+}
+
+// Fuel, which wazero meters in calls, does not bound loops without calls
+func TestFuelWithoutTimeout(t *testing.T) {
+	if _, err := MakeContainer(&ws.Execute{Module: loop, Fuel: 1000}); err == nil {
+		t.Fatalf("fuel without timeout accepted")
+	}
+	if _, err := MakeContainer(&ws.Execute{Module: add, Fuel: 1000, Function: ws.Functional{IsFunction: true}}); err == nil {
+		t.Fatalf("function fuel without timeout accepted")
+	}
+}
+
+func TestFunction(t *testing.T) {
+	w, err := MakeContainer(&ws.Execute{
+		Module:   add,
+		Function: ws.Functional{IsFunction: true, Instances: 2},
+	})
+	if err != nil {
+		t.Fatalf("make (%v)", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r, err := w.Invoke("add", i, "40")
+			if err != nil || len(r) != 1 || r[0] != int32(i+40) {
+				t.Errorf("invoke %d: %v (%v)", i, r, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if _, err := w.Invoke("add", 1); err == nil {
+		t.Fatalf("expecting argument count error")
+	}
+	if _, err := w.Invoke("sub", 1, 2); err == nil {
+		t.Fatalf("expecting missing function error")
+	}
+	if stat, _ := w.Peek(); !stat.Running || stat.Invocations != 10 {
+		t.Fatalf("status %+v", stat)
+	}
+	w.Scrub()
+	if stat, _ := w.Wait(); stat.Running {
+		t.Fatalf("status %+v", stat)
+	}
+	if _, err := w.Invoke("add", 1, 2); err == nil {
+		t.Fatalf("expecting error after scrub")
+	}
+}
@@ -0,0 +1,295 @@
+//go:build wasmedge
+
+package wasm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ws "github.com/gocircuit/circuit/client/wasm"
+	"github.com/second-state/WasmEdge-go/wasmedge"
+)
+
+// The WasmEdge runtime links the WasmEdge C library and is only built with
+// the wasmedge build tag.
+func init() {
+	RegisterRuntime(ws.RuntimeWasmEdge, wasmedgeRuntime{})
+}
+
+type wasmedgeRuntime struct{}
+
+func (wasmedgeRuntime) Run(exe *ws.Execute) (exit int, stats ws.Statistics, err error) {
+	setLogLevel(exe.LogLevel)
+
+	conf := buildConfigure(exe)
+	defer conf.Release()
+	vm := wasmedge.NewVMWithConfig(conf)
+	defer vm.Release()
+
+	var wasi *wasmedge.Module
+	if exe.WasiConf.Enable {
+		wasi = vm.GetImportModule(wasmedge.WASI)
+		wasi.InitWasi(
+			append([]string{filepath.Base(exe.Path)}, exe.WasiConf.Args...),
+			exe.WasiConf.Env,
+			exe.WasiConf.PreOpens,
+		)
+	}
+
+	s := vm.GetStatistics()
+	if exe.Fuel > 0 {
+		s.SetCostLimit(exe.Fuel)
+	}
+	defer func() {
+		stats = ws.Statistics{
+			InstCount:     s.GetInstrCount(),
+			InstPerSecond: s.GetInstrPerSecond(),
+			TotalCost:     s.GetTotalCost(),
+		}
+	}()
+
+	if exe.Timeout > 0 {
+		a := vm.AsyncRunWasmFile(exe.Path, "_start")
+		defer a.Release()
+		if !a.WaitFor(int(exe.Timeout.Milliseconds())) {
+			a.Cancel()
+			return 1, stats, &LimitError{ws.LimitTimeout}
+		}
+		_, err = a.GetResult()
+	} else {
+		_, err = vm.RunWasmFile(exe.Path, "_start")
+	}
+	switch {
+	case err != nil && exe.Fuel > 0 && strings.Contains(err.Error(), "cost limit exceeded"):
+		return 1, stats, &LimitError{ws.LimitFuel}
+	case err != nil && memoryFull(vm, exe.MaxMemoryPageSize):
+		return 1, stats, &LimitError{ws.LimitMemory}
+	case err != nil:
+		return 1, stats, err
+	case wasi != nil:
+		exit = int(wasi.WasiGetExitCode())
+		if exit != 0 && memoryFull(vm, exe.MaxMemoryPageSize) {
+			return exit, stats, &LimitError{ws.LimitMemory}
+		}
+	}
+	return
+}
+
+// memoryFull reports whether the memory of the module reached its page limit
+func memoryFull(vm *wasmedge.VM, pages uint) bool {
+	if pages == 0 {
+		return false
+	}
+	mod := vm.GetActiveModule()
+	if mod == nil {
+		return false
+	}
+	mem := mod.FindMemory("memory")
+	return mem != nil && mem.GetPageSize() >= pages
+}
+
+// Load keeps the module bytes; each instance is a VM of its own, since VMs
+// are not safe for concurrent use. WASI of function modules uses the standard
+// streams of the server.
+func (wasmedgeRuntime) Load(exe *ws.Execute) (Module, error) {
+	bin := exe.Module
+	if len(bin) == 0 {
+		var err error
+		if bin, err = os.ReadFile(exe.Path); err != nil {
+			return nil, err
+		}
+	}
+	return &wasmedgeModule{exe: exe, bin: bin}, nil
+}
+
+type wasmedgeModule struct {
+	exe *ws.Execute
+	bin []byte
+}
+
+func (m *wasmedgeModule) Instantiate() (_ Instance, err error) {
+	setLogLevel(m.exe.LogLevel)
+	conf := buildConfigure(m.exe)
+	in := &wasmedgeInstance{exe: m.exe, conf: conf, vm: wasmedge.NewVMWithConfig(conf)}
+	defer func() {
+		if err != nil {
+			in.Close()
+		}
+	}()
+	if m.exe.WasiConf.Enable {
+		in.vm.GetImportModule(wasmedge.WASI).InitWasi(
+			append([]string{filepath.Base(m.exe.Path)}, m.exe.WasiConf.Args...),
+			m.exe.WasiConf.Env,
+			m.exe.WasiConf.PreOpens,
+		)
+	}
+	if err = in.vm.LoadWasmBuffer(m.bin); err != nil {
+		return nil, err
+	}
+	if err = in.vm.Validate(); err != nil {
+		return nil, err
+	}
+	if err = in.vm.Instantiate(); err != nil {
+		return nil, err
+	}
+	init, args := m.exe.Function.Name, m.exe.Function.Args
+	if init == "" && in.vm.GetFunctionType("_initialize") != nil {
+		init = "_initialize"
+	}
+	if init != "" {
+		a := make([]any, len(args))
+		for i, x := range args {
+			a[i] = x
+		}
+		if _, err = in.Invoke(init, a); err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+func (m *wasmedgeModule) Close() {}
+
+type wasmedgeInstance struct {
+	exe  *ws.Execute
+	conf *wasmedge.Configure
+	vm   *wasmedge.VM
+}
+
+func (in *wasmedgeInstance) Invoke(name string, args []any) (r []any, err error) {
+	ft := in.vm.GetFunctionType(name)
+	if ft == nil {
+		return nil, fmt.Errorf("wasm module exports no function %s", name)
+	}
+	types := make([]string, 0, ft.GetParametersLength())
+	for _, t := range ft.GetParameters() {
+		types = append(types, t.String())
+	}
+	if args, err = convertArgs(types, args); err != nil {
+		return nil, err
+	}
+
+	// Fuel is given anew to each invocation
+	if in.exe.Fuel > 0 {
+		s := in.vm.GetStatistics()
+		s.SetCostLimit(s.GetTotalCost() + in.exe.Fuel)
+	}
+	if in.exe.Timeout > 0 {
+		a := in.vm.AsyncExecute(name, args...)
+		defer a.Release()
+		if !a.WaitFor(int(in.exe.Timeout.Milliseconds())) {
+			a.Cancel()
+			return nil, &LimitError{ws.LimitTimeout}
+		}
+		r, err = a.GetResult()
+	} else {
+		r, err = in.vm.Execute(name, args...)
+	}
+	switch {
+	case err != nil && in.exe.Fuel > 0 && strings.Contains(err.Error(), "cost limit exceeded"):
+		return nil, &LimitError{ws.LimitFuel}
+	case err != nil && memoryFull(in.vm, in.exe.MaxMemoryPageSize):
+		return nil, &LimitError{ws.LimitMemory}
+	}
+	return r, err
+}
+
+func (in *wasmedgeInstance) Close() {
+	in.vm.Release()
+	in.conf.Release()
+}
+
+func (wasmedgeRuntime) Version() ws.Version {
+	return ws.Version{
+		Major: wasmedge.GetVersionMajor(),
+		Minor: wasmedge.GetVersionMinor(),
+		Patch: wasmedge.GetVersionPatch(),
+	}
+}
+
+func setLogLevel(l ws.LogLevel) {
+	switch l {
+	case ws.LOG_DEBUG:
+		wasmedge.SetLogDebugLevel()
+	case "":
+		wasmedge.SetLogOff()
+	default:
+		wasmedge.SetLogErrorLevel()
+	}
+}
+
+// buildConfigure translates the execute configuration to a wasmedge configuration
+func buildConfigure(exe *ws.Execute) *wasmedge.Configure {
+	conf := wasmedge.NewConfigure()
+	if exe.WasiConf.Enable {
+		conf.AddConfig(wasmedge.WASI)
+	}
+	for _, p := range proposals(&exe.AddProposals) {
+		conf.AddConfig(p)
+	}
+	for _, p := range proposals(&exe.RemoveProposals) {
+		conf.RemoveConfig(p)
+	}
+	if exe.MaxMemoryPageSize > 0 {
+		conf.SetMaxMemoryPage(exe.MaxMemoryPageSize)
+	}
+	conf.SetForceInterpreter(exe.ForceInterpreter)
+
+	switch exe.CompileOpts.OptLevel {
+	case ws.OptLevel_O0:
+		conf.SetCompilerOptimizationLevel(wasmedge.CompilerOptLevel_O0)
+	case ws.OptLevel_O1:
+		conf.SetCompilerOptimizationLevel(wasmedge.CompilerOptLevel_O1)
+	case ws.OptLevel_O2:
+		conf.SetCompilerOptimizationLevel(wasmedge.CompilerOptLevel_O2)
+	case ws.OptLevel_O3:
+		conf.SetCompilerOptimizationLevel(wasmedge.CompilerOptLevel_O3)
+	case ws.OptLevel_Os:
+		conf.SetCompilerOptimizationLevel(wasmedge.CompilerOptLevel_Os)
+	case ws.OptLevel_Oz:
+		conf.SetCompilerOptimizationLevel(wasmedge.CompilerOptLevel_Oz)
+	}
+	switch exe.CompileOpts.OutputFormat {
+	case ws.NativeOutputFormat:
+		conf.SetCompilerOutputFormat(wasmedge.CompilerOutputFormat_Native)
+	case ws.WasmOutputFormat:
+		conf.SetCompilerOutputFormat(wasmedge.CompilerOutputFormat_Wasm)
+	}
+	conf.SetCompilerDumpIR(exe.CompileOpts.DumpIR)
+	conf.SetCompilerGenericBinary(exe.CompileOpts.GenericBinary)
+
+	conf.SetStatisticsInstructionCounting(exe.StatsOpts.CountInstructions)
+	conf.SetStatisticsTimeMeasuring(exe.StatsOpts.TimeMeasurement)
+	conf.SetStatisticsCostMeasuring(exe.StatsOpts.CostMeasurement || exe.Fuel > 0)
+	return conf
+}
+
+func proposals(p *ws.Proposals) (r []wasmedge.Proposal) {
+	for _, x := range []struct {
+		on bool
+		p  wasmedge.Proposal
+	}{
+		{p.ImportExportMutGlobals, wasmedge.IMPORT_EXPORT_MUT_GLOBALS},
+		{p.NonTrapFloatToIntConversions, wasmedge.NON_TRAP_FLOAT_TO_INT_CONVERSIONS},
+		{p.SignEextensionOperators, wasmedge.SIGN_EXTENSION_OPERATORS},
+		{p.MultiValue, wasmedge.MULTI_VALUE},
+		{p.BulkMemoryOperations, wasmedge.BULK_MEMORY_OPERATIONS},
+		{p.ReferenceTypes, wasmedge.REFERENCE_TYPES},
+		{p.SIMD, wasmedge.SIMD},
+		{p.TailCall, wasmedge.TAIL_CALL},
+		{p.MultiMemories, wasmedge.MULTI_MEMORIES},
+		{p.Annotations, wasmedge.ANNOTATIONS},
+		{p.Memory64, wasmedge.MEMORY64},
+		{p.ExceptionHandling, wasmedge.EXCEPTION_HANDLING},
+		{p.ExtendedConst, wasmedge.EXTENDED_CONST},
+		{p.Threads, wasmedge.THREADS},
+		{p.FunctionReferences, wasmedge.FUNCTION_REFERENCES},
+	} {
+		if x.on {
+			r = append(r, x.p)
+		}
+	}
+	return
+}
@@ -0,0 +1,421 @@
+package wasm
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
+	ws "github.com/gocircuit/circuit/client/wasm"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// The wazero runtime is written in Go and available on every server.
+func init() {
+	RegisterRuntime(ws.RuntimeWazero, wazeroRuntime{})
+}
+
+type wazeroRuntime struct{}
+
+func (wazeroRuntime) Run(exe *ws.Execute) (exit int, stats ws.Statistics, err error) {
+	bin, err := os.ReadFile(exe.Path)
+	if err != nil {
+		return 1, stats, err
+	}
+	conf, err := wazeroConfig(exe)
+	if err != nil {
+		return 1, stats, err
+	}
+
+	// Limits stop the module by cancelling its context
+	ctx := context.Background()
+	if exe.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, exe.Timeout)
+		defer cancel()
+	}
+	var m *meter
+	if metered(exe) {
+		ctx, m = withMeter(ctx, exe)
+		defer m.cancel()
+		defer func() {
+			stats.TotalCost = uint(m.calls)
+		}()
+	}
+
+	r, cm, mod, err := compile(ctx, conf, bin, exe)
+	if err != nil {
+		return 1, stats, err
+	}
+	defer r.Close(context.Background())
+
+	// Instantiation runs the _start function
+	_, err = r.InstantiateModule(ctx, cm, mod)
+	var ee *sys.ExitError
+	switch {
+	case err == nil:
+		return 0, stats, nil
+	case errors.As(err, &ee) && ee.ExitCode() != sys.ExitCodeContextCanceled && ee.ExitCode() != sys.ExitCodeDeadlineExceeded:
+		if ee.ExitCode() != 0 && m.limit() == ws.LimitMemory {
+			return int(ee.ExitCode()), stats, &LimitError{ws.LimitMemory}
+		}
+		return int(ee.ExitCode()), stats, nil
+	}
+	return 1, stats, limitError(err, m)
+}
+
+func (wazeroRuntime) Load(exe *ws.Execute) (Module, error) {
+	bin := exe.Module
+	if len(bin) == 0 {
+		var err error
+		if bin, err = os.ReadFile(exe.Path); err != nil {
+			return nil, err
+		}
+	}
+	conf, err := wazeroConfig(exe)
+	if err != nil {
+		return nil, err
+	}
+	// Listeners are bound at compilation, meters are given to each call
+	ctx := context.Background()
+	if metered(exe) {
+		ctx = experimental.WithFunctionListenerFactory(ctx, meterListener{})
+	}
+	r, cm, mod, err := compile(ctx, conf, bin, exe)
+	if err != nil {
+		return nil, err
+	}
+	// Functions do not run _start and have no standard streams
+	mod = mod.WithStartFunctions().
+		WithStdin(strings.NewReader("")).
+		WithStdout(io.Discard).
+		WithStderr(io.Discard)
+	return &wazeroModule{exe: exe, r: r, cm: cm, conf: mod}, nil
+}
+
+// wazeroLimits reports an error if the limits of exe do not bound the work of
+// the module. Fuel, metered in function calls, does not bound a loop that
+// makes no calls, which only a timeout stops.
+func wazeroLimits(exe *ws.Execute) error {
+	if exe.Fuel > 0 && exe.Timeout <= 0 {
+		return errors.New("wazero meters fuel in function calls, which does not bound loops without calls; set a timeout along with fuel")
+	}
+	return nil
+}
+
+// wazeroConfig translates the execute configuration to a wazero configuration
+func wazeroConfig(exe *ws.Execute) (wazero.RuntimeConfig, error) {
+	if err := wazeroLimits(exe); err != nil {
+		return nil, err
+	}
+	conf := wazero.NewRuntimeConfig()
+	if exe.ForceInterpreter {
+		conf = wazero.NewRuntimeConfigInterpreter()
+	}
+	features, err := wazeroFeatures(exe)
+	if err != nil {
+		return nil, err
+	}
+	conf = conf.WithCoreFeatures(features)
+	if exe.MaxMemoryPageSize > 0 {
+		conf = conf.WithMemoryLimitPages(uint32(exe.MaxMemoryPageSize))
+	}
+	if exe.Timeout > 0 || metered(exe) {
+		conf = conf.WithCloseOnContextDone(true)
+	}
+	return conf, nil
+}
+
+// compile prepares a runtime, with WASI when enabled, and compiles the module
+func compile(ctx context.Context, conf wazero.RuntimeConfig, bin []byte, exe *ws.Execute) (
+	r wazero.Runtime, cm wazero.CompiledModule, mod wazero.ModuleConfig, err error) {
+
+	r = wazero.NewRuntimeWithConfig(ctx, conf)
+	mod = wazero.NewModuleConfig().WithName("")
+	if exe.WasiConf.Enable {
+		wasi_snapshot_preview1.MustInstantiate(ctx, r)
+		if mod, err = wasiConfig(mod, exe); err != nil {
+			r.Close(ctx)
+			return nil, nil, nil, err
+		}
+	}
+	if cm, err = r.CompileModule(ctx, bin); err != nil {
+		r.Close(ctx)
+		// wazero rejects memories declared beyond the page limit
+		if exe.MaxMemoryPageSize > 0 && strings.Contains(err.Error(), "over limit") {
+			err = &LimitError{ws.LimitMemory}
+		}
+		return nil, nil, nil, err
+	}
+	return r, cm, mod, nil
+}
+
+type wazeroModule struct {
+	exe  *ws.Execute
+	r    wazero.Runtime
+	cm   wazero.CompiledModule
+	conf wazero.ModuleConfig
+}
+
+func (m *wazeroModule) Instantiate() (Instance, error) {
+	ctx := context.Background()
+	mod, err := m.r.InstantiateModule(ctx, m.cm, m.conf)
+	if err != nil {
+		return nil, err
+	}
+	in := &wazeroInstance{exe: m.exe, mod: mod}
+	init, args := m.exe.Function.Name, m.exe.Function.Args
+	if init == "" && mod.ExportedFunction("_initialize") != nil {
+		init = "_initialize"
+	}
+	if init != "" {
+		a := make([]any, len(args))
+		for i, x := range args {
+			a[i] = x
+		}
+		if _, err = in.Invoke(init, a); err != nil {
+			mod.Close(ctx)
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+func (m *wazeroModule) Close() {
+	m.r.Close(context.Background())
+}
+
+type wazeroInstance struct {
+	exe *ws.Execute
+	mod api.Module
+}
+
+func (in *wazeroInstance) Invoke(name string, args []any) ([]any, error) {
+	fn := in.mod.ExportedFunction(name)
+	if fn == nil {
+		return nil, fmt.Errorf("wasm module exports no function %s", name)
+	}
+	def := fn.Definition()
+	types := make([]string, len(def.ParamTypes()))
+	for i, t := range def.ParamTypes() {
+		types[i] = api.ValueTypeName(t)
+	}
+	conv, err := convertArgs(types, args)
+	if err != nil {
+		return nil, err
+	}
+	params := make([]uint64, len(conv))
+	for i, a := range conv {
+		switch x := a.(type) {
+		case int32:
+			params[i] = api.EncodeI32(x)
+		case int64:
+			params[i] = api.EncodeI64(x)
+		case float32:
+			params[i] = api.EncodeF32(x)
+		case float64:
+			params[i] = api.EncodeF64(x)
+		}
+	}
+
+	ctx := context.Background()
+	if in.exe.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, in.exe.Timeout)
+		defer cancel()
+	}
+	var m *meter
+	if metered(in.exe) {
+		ctx, m = withMeter(ctx, in.exe)
+		defer m.cancel()
+	}
+	res, err := fn.Call(ctx, params...)
+	if err != nil {
+		return nil, limitError(err, m)
+	}
+	r := make([]any, len(res))
+	for i, t := range def.ResultTypes() {
+		switch t {
+		case api.ValueTypeI32:
+			r[i] = api.DecodeI32(res[i])
+		case api.ValueTypeI64:
+			r[i] = int64(res[i])
+		case api.ValueTypeF32:
+			r[i] = api.DecodeF32(res[i])
+		case api.ValueTypeF64:
+			r[i] = api.DecodeF64(res[i])
+		default:
+			return nil, fmt.Errorf("wasm value type %s not supported", api.ValueTypeName(t))
+		}
+	}
+	return r, nil
+}
+
+func (in *wazeroInstance) Close() {
+	in.mod.Close(context.Background())
+}
+
+// limitError attributes the failure of a module to the limit which caused it
+func limitError(err error, m *meter) error {
+	var ee *sys.ExitError
+	switch {
+	case errors.As(err, &ee) && ee.ExitCode() == sys.ExitCodeDeadlineExceeded:
+		return &LimitError{ws.LimitTimeout}
+	case m.limit() != "":
+		return &LimitError{m.limit()}
+	}
+	return err
+}
+
+// meter counts function calls against the fuel of an execution and notes
+// when the memory of the module reaches its page limit. The meter of a call
+// travels in its context, where the listeners of the module find it.
+type meter struct {
+	fuel      uint64
+	pages     uint32
+	calls     uint64
+	exhausted bool
+	full      bool
+	cancel    context.CancelFunc
+}
+
+type meterKey struct{}
+
+func metered(exe *ws.Execute) bool {
+	return exe.Fuel > 0 || exe.MaxMemoryPageSize > 0
+}
+
+// withMeter returns a context carrying a new meter, cancelled when the fuel
+// runs out
+func withMeter(ctx context.Context, exe *ws.Execute) (context.Context, *meter) {
+	m := &meter{fuel: uint64(exe.Fuel), pages: uint32(exe.MaxMemoryPageSize)}
+	ctx, m.cancel = context.WithCancel(ctx)
+	ctx = context.WithValue(ctx, meterKey{}, m)
+	return experimental.WithFunctionListenerFactory(ctx, meterListener{}), m
+}
+
+func (m *meter) limit() ws.LimitKind {
+	switch {
+	case m == nil:
+		return ""
+	case m.exhausted:
+		return ws.LimitFuel
+	case m.full:
+		// A module failing once its memory hit the cap ran out of memory
+		return ws.LimitMemory
+	}
+	return ""
+}
+
+type meterListener struct{}
+
+func (meterListener) NewFunctionListener(api.FunctionDefinition) experimental.FunctionListener {
+	return experimental.FunctionListenerFunc(meterListener{}.before)
+}
+
+func (meterListener) before(ctx context.Context, mod api.Module, _ api.FunctionDefinition, _ []uint64, _ experimental.StackIterator) {
+	m, ok := ctx.Value(meterKey{}).(*meter)
+	if !ok {
+		return
+	}
+	m.calls++
+	if m.fuel > 0 && m.calls > m.fuel && !m.exhausted {
+		m.exhausted = true
+		m.cancel()
+	}
+	if m.pages > 0 && !m.full {
+		if mem := mod.Memory(); mem != nil && mem.Size() >= m.pages*65536 {
+			m.full = true
+		}
+	}
+}
+
+// Version reports the version of the wazero module linked in the server
+func (wazeroRuntime) Version() (v ws.Version) {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/tetratelabs/wazero" {
+				fmt.Sscanf(dep.Version, "v%d.%d.%d", &v.Major, &v.Minor, &v.Patch)
+			}
+		}
+	}
+	return
+}
+
+func wasiConfig(mod wazero.ModuleConfig, exe *ws.Execute) (wazero.ModuleConfig, error) {
+	mod = mod.
+		WithArgs(append([]string{filepath.Base(exe.Path)}, exe.WasiConf.Args...)...).
+		WithStdin(os.Stdin).
+		WithStdout(os.Stdout).
+		WithStderr(os.Stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithSysNanosleep().
+		WithRandSource(rand.Reader)
+	for _, kv := range exe.WasiConf.Env {
+		k, v, _ := strings.Cut(kv, "=")
+		mod = mod.WithEnv(k, v)
+	}
+	// Preopens map guest:host directories as WasmEdge does, with an optional
+	// :readonly suffix
+	fs := wazero.NewFSConfig()
+	for _, p := range exe.WasiConf.PreOpens {
+		guest, host, ok := strings.Cut(p, ":")
+		if !ok {
+			host = guest
+		}
+		host, ro := strings.CutSuffix(host, ":readonly")
+		if _, err := os.Stat(host); err != nil {
+			return nil, fmt.Errorf("preopen %s: %v", p, err)
+		}
+		if ro {
+			fs = fs.WithReadOnlyDirMount(host, guest)
+		} else {
+			fs = fs.WithDirMount(host, guest)
+		}
+	}
+	return mod.WithFSConfig(fs), nil
+}
+
+// wazeroFeatures maps the proposals of an execution to wazero core features,
+// starting from the WebAssembly 2.0 feature set.
+func wazeroFeatures(exe *ws.Execute) (api.CoreFeatures, error) {
+	f := api.CoreFeaturesV2
+	set := func(p *ws.Proposals, on bool) error {
+		for _, x := range []struct {
+			set     bool
+			feature api.CoreFeatures
+		}{
+			{p.ImportExportMutGlobals, api.CoreFeatureMutableGlobal},
+			{p.NonTrapFloatToIntConversions, api.CoreFeatureNonTrappingFloatToIntConversion},
+			{p.SignEextensionOperators, api.CoreFeatureSignExtensionOps},
+			{p.MultiValue, api.CoreFeatureMultiValue},
+			{p.BulkMemoryOperations, api.CoreFeatureBulkMemoryOperations},
+			{p.ReferenceTypes, api.CoreFeatureReferenceTypes},
+			{p.SIMD, api.CoreFeatureSIMD},
+			{p.Threads, experimental.CoreFeaturesThreads},
+		} {
+			if x.set {
+				f = f.SetEnabled(x.feature, on)
+			}
+		}
+		if on && (p.TailCall || p.MultiMemories || p.Annotations || p.Memory64 ||
+			p.ExceptionHandling || p.ExtendedConst || p.FunctionReferences) {
+			return errors.New("proposal not supported by the wazero runtime")
+		}
+		return nil
+	}
+	if err := set(&exe.AddProposals, true); err != nil {
+		return 0, err
+	}
+	return f, set(&exe.RemoveProposals, false)
+}
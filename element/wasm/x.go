@@ -3,6 +3,7 @@ package wasm
 import (
 	"io"
 
+	"github.com/gocircuit/circuit/anchor"
 	ws "github.com/gocircuit/circuit/client/wasm"
 	xio "github.com/gocircuit/circuit/kit/x/io"
 	"github.com/gocircuit/circuit/use/circuit"
@@ -15,37 +16,75 @@ func init() {
 
 // XWasm repreents the skeleton for the server side Wasm container
 type XWasm struct {
-	Wasm
+	w     Wasm
+	guard anchor.Guard
+}
+
+// wasmVerbs are the verbs of the methods of XWasm. Invoking a function
+// feeds it input, as stdin does a module run as a command.
+var wasmVerbs = anchor.Verbs{
+	"Wait":      anchor.Get,
+	"Signal":    anchor.Signal,
+	"Stdin":     anchor.Stdin,
+	"Stdout":    anchor.Get,
+	"Stderr":    anchor.Get,
+	"IsDone":    anchor.Get,
+	"Peek":      anchor.Get,
+	"PeekBytes": anchor.Get,
+	"Invoke":    anchor.Stdin,
+	"Scrub":     anchor.Scrub,
+}
+
+func (x XWasm) Authorize(method string) error {
+	return x.guard.Authorize(wasmVerbs, method)
 }
 
 func (x XWasm) Wait() (*ws.Status, error) {
-	stat, err := x.Wasm.Wait()
+	stat, err := x.w.Wait()
 	return stat, errors.Pack(err)
 }
 
 func (x XWasm) Signal(sig string) error {
-	return errors.Pack(x.Wasm.Signal(sig))
+	err := x.w.Signal(sig)
+	x.guard.Audit(anchor.Signal, sig, err)
+	return errors.Pack(err)
 }
 
 func (x XWasm) Stdin() circuit.X {
-	return xio.NewXWriteCloser(x.Wasm.Stdin())
+	x.guard.Audit(anchor.Stdin, nil, nil)
+	return xio.NewXWriteCloser(x.w.Stdin())
 }
 
 func (x XWasm) Stdout() circuit.X {
-	return xio.NewXReadCloser(x.Wasm.Stdout())
+	return xio.NewXReadCloser(x.w.Stdout())
 }
 
 func (x XWasm) Stderr() circuit.X {
-	return xio.NewXReadCloser(x.Wasm.Stderr())
+	return xio.NewXReadCloser(x.w.Stderr())
 }
 
 func (x XWasm) Peek() (*ws.Status, error) {
-	stat, err := x.Wasm.Peek()
+	stat, err := x.w.Peek()
 	return stat, errors.Pack(err)
 }
 
 func (x XWasm) PeekBytes() []byte {
-	return x.Wasm.PeekBytes()
+	return x.w.PeekBytes()
+}
+
+func (x XWasm) IsDone() bool {
+	return x.w.IsDone()
+}
+
+func (x XWasm) Scrub() {
+	x.w.Scrub()
+	x.guard.Audit(anchor.Scrub, nil, nil)
+}
+
+// Invoke takes a slice, since cross-calls do not expand variadic arguments
+func (x XWasm) Invoke(name string, args []any) ([]any, error) {
+	r, err := x.w.Invoke(name, args...)
+	return r, errors.Pack(err)
 }
 
 type YWasm struct {
@@ -81,6 +120,12 @@ func (y YWasm) PeekBytes() []byte {
 	return y.X.Call("PeekBytes")[0].([]byte)
 }
 
+func (y YWasm) Invoke(name string, args ...any) ([]any, error) {
+	r := y.X.Call("Invoke", name, args)
+	res, _ := r[0].([]any)
+	return res, errors.Unpack(r[1])
+}
+
 func (y YWasm) Stdin() io.WriteCloser {
 	return xio.NewYWriteCloser(y.X.Call("Stdin")[0])
 }
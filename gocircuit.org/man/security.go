@@ -13,8 +13,11 @@ const securityBody = `
 <h2>Security</h2>
 
 <p>By default, circuit servers and clients communicate over plaintext TCP.
-A HMAC-based symmetric authentication, followed by an asymmetric
-RC4 stream cipher is supported.
+Authentication and encryption with a private key shared by the circuit is
+supported. Every connection derives its own session keys from the shared
+key and random nonces of both peers, and is encrypted with AES-GCM or
+ChaCha20-Poly1305. Peers of older releases, which use HMAC and RC4 with the
+same key, are refused with an error asking to upgrade them.
 
 <p>To enable encryption, use the <code>-hmac</code> command-line option to point
 the circuit executable to a file containing the private key for your circuit.
@@ -8,9 +8,8 @@
 package interruptible
 
 import (
-	"bufio"
-	"io"
 	"runtime"
+	"sync"
 )
 
 //
@@ -34,14 +33,85 @@ func Pipe() (Reader, Writer) {
 }
 
 //  ww<–pipe–>wr <–copy–> rw<–pipe–>rr
+//
+// Up to n bytes written are held for a slow reader; anything held is
+// delivered as soon as the reader asks for it.
 func BufferPipe(n int) (Reader, Writer) {
 	x1, x0 := Pipe()
 	x3, x2 := Pipe()
+	b := &buffer{n: n}
+	b.cond = sync.NewCond(&b.Mutex)
+	go func() {
+		p := make([]byte, n)
+		for {
+			k, err := x1.Read(p)
+			b.put(p[:k])
+			if err != nil {
+				b.close()
+				return
+			}
+		}
+	}()
 	go func() {
-		bx2 := bufio.NewWriterSize(x2, n)
-		io.Copy(bx2, x1)
-		bx2.Flush()
-		x2.Close()
+		for {
+			p, ok := b.get()
+			if !ok {
+				x2.Close()
+				return
+			}
+			if _, err := x2.Write(p); err != nil {
+				x2.Close()
+				return
+			}
+		}
 	}()
 	return x3, x0
 }
+
+// buffer holds up to n bytes in transit between the two pipes of a BufferPipe
+type buffer struct {
+	sync.Mutex
+	cond   *sync.Cond
+	buf    []byte
+	n      int
+	closed bool
+}
+
+// put appends p, waiting while the buffer is full
+func (b *buffer) put(p []byte) {
+	b.Lock()
+	defer b.Unlock()
+	for len(p) > 0 {
+		for len(b.buf) >= b.n {
+			b.cond.Wait()
+		}
+		k := min(len(p), b.n-len(b.buf))
+		b.buf = append(b.buf, p[:k]...)
+		p = p[k:]
+		b.cond.Broadcast()
+	}
+}
+
+// get waits for and takes everything buffered. It returns false once the
+// buffer is closed and empty.
+func (b *buffer) get() ([]byte, bool) {
+	b.Lock()
+	defer b.Unlock()
+	for len(b.buf) == 0 && !b.closed {
+		b.cond.Wait()
+	}
+	if len(b.buf) == 0 {
+		return nil, false
+	}
+	p := b.buf
+	b.buf = nil
+	b.cond.Broadcast()
+	return p, true
+}
+
+func (b *buffer) close() {
+	b.Lock()
+	defer b.Unlock()
+	b.closed = true
+	b.cond.Broadcast()
+}
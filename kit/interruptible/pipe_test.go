@@ -11,6 +11,7 @@ import (
 	"io"
 	"math/rand"
 	"testing"
+	"time"
 )
 
 func TestPipe(t *testing.T) {
@@ -23,6 +24,50 @@ func TestBuffer(t *testing.T) {
 	testPipe(r, w, t)
 }
 
+// Writes smaller than the buffer reach the reader without waiting for more
+func TestBufferDelivers(t *testing.T) {
+	r, w := BufferPipe(1000)
+	if _, err := w.Write([]byte("hi\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	p := make([]byte, 10)
+	n, err := r.Read(p)
+	if err != nil || string(p[:n]) != "hi\n" {
+		t.Fatalf("read %q (%v)", p[:n], err)
+	}
+	w.Close()
+	if _, err = r.Read(p); err != io.EOF {
+		t.Fatalf("read after close: %v", err)
+	}
+}
+
+// Writes of up to the buffer size complete without a reader
+func TestBufferHolds(t *testing.T) {
+	r, w := BufferPipe(1000)
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Write(make([]byte, 1000))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("write blocked on the reader")
+	}
+	p := make([]byte, 2000)
+	var n int
+	for n < 1000 {
+		k, err := r.Read(p[n:])
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		n += k
+	}
+}
+
 func testPipe(r io.ReadCloser, w io.WriteCloser, t *testing.T) {
 	const N = 2200
 	ch := make(chan int)
@@ -31,35 +76,41 @@ func testPipe(r io.ReadCloser, w io.WriteCloser, t *testing.T) {
 		data[i] = byte(rand.Int())
 	}
 	go func() { // write goroutine
+		defer func() { ch <- 1 }()
+		defer w.Close()
 		m := 0
 		x := data
 		for len(x) > 0 {
 			n, err := w.Write(x)
 			if err != nil {
-				t.Fatalf("write: %v", err)
+				t.Errorf("write: %v", err)
+				return
 			}
 			x = x[n:]
 			m += n
 		}
-		w.Close()
-		ch <- 1
 	}()
 	y := make([]byte, N)
 	go func() { // read goroutine
+		defer func() { ch <- 1 }()
+		defer r.Close()
 		z := y
 		m := 0
 		for len(z) > 0 {
 			n, err := r.Read(z)
 			if err != nil {
-				t.Fatalf("read: %v", err)
+				t.Errorf("read: %v", err)
+				return
 			}
 			z = z[n:]
 			m += n
 		}
-		ch <- 1
 	}()
 	<-ch
 	<-ch
+	if t.Failed() {
+		return
+	}
 	for i, _ := range data {
 		if data[i] != y[i] {
 			t.Fatalf("index %d differs: %v vs %v", i, data[i], y[i])
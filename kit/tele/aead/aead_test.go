@@ -0,0 +1,157 @@
+package aead
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/gocircuit/circuit/kit/tele/hmac"
+)
+
+var key = NewKeyring([]byte("the circuit's shared private key"))
+
+func listen(t *testing.T, key *Keyring) (net.Addr, <-chan []byte) {
+	l := NewTransport(key).Listen(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if l == nil {
+		t.Fatalf("listen")
+	}
+	accepted := make(chan []byte, 1)
+	go func() {
+		for {
+			c := l.Accept()
+			if c == nil {
+				return
+			}
+			go func() {
+				for {
+					chunk, err := c.Read()
+					if err != nil {
+						return
+					}
+					accepted <- chunk
+					c.Write(chunk)
+				}
+			}()
+		}
+	}()
+	return l.Addr(), accepted
+}
+
+func TestAEAD(t *testing.T) {
+	HandshakeTimeout = time.Second
+	addr, accepted := listen(t, key)
+
+	// Both suites carry chunks back and forth
+	for _, s := range []Suite{AESGCM, ChaCha20Poly1305} {
+		Suites = []Suite{s, AESGCM}
+		c, err := NewTransport(key).Dial(addr)
+		if err != nil {
+			t.Fatalf("dial %v (%v)", s, err)
+		}
+		for _, m := range []string{"hello", "", "world"} {
+			if err = c.Write([]byte(m)); err != nil {
+				t.Fatalf("write (%v)", err)
+			}
+			if chunk := <-accepted; string(chunk) != m {
+				t.Fatalf("accepted %q", chunk)
+			}
+			if chunk, err := c.Read(); err != nil || string(chunk) != m {
+				t.Fatalf("read %q (%v)", chunk, err)
+			}
+		}
+		c.Close()
+	}
+	Suites = []Suite{AESGCM, ChaCha20Poly1305}
+
+	// Another key is refused
+	if _, err := NewTransport(NewKeyring([]byte("another key"))).Dial(addr); !errors.Is(err, ErrAuth) {
+		t.Fatalf("dial with another key (%v)", err)
+	}
+
+	// Legacy peers are refused on either side
+	if c, err := hmac.NewTransport(key.Keys()[0]).Dial(addr); err == nil {
+		c.Write([]byte("hello"))
+		select {
+		case chunk := <-accepted:
+			t.Fatalf("chunk %q from legacy peer accepted", chunk)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	l := hmac.NewTransport(key.Keys()[0]).Listen(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	go l.Accept()
+	if _, err := NewTransport(key).Dial(l.Addr()); !errors.Is(err, ErrLegacy) {
+		t.Fatalf("dial into legacy peer (%v)", err)
+	}
+}
+
+func TestKeyring(t *testing.T) {
+	HandshakeTimeout = time.Second
+	old, next := []byte("old key"), []byte("next key")
+	ring := NewKeyring(old)
+	addr, accepted := listen(t, ring)
+	exchange := func(keys ...[]byte) error {
+		c, err := NewTransport(NewKeyring(keys...)).Dial(addr)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		if err = c.Write([]byte("hello")); err != nil {
+			return err
+		}
+		<-accepted
+		_, err = c.Read()
+		return err
+	}
+
+	// A peer which rotated its keys reaches a peer which did not, and vice versa
+	if err := exchange(next, old); err != nil {
+		t.Fatalf("rotated dialer (%v)", err)
+	}
+	ring.Set(next, old)
+	if err := exchange(old); err != nil {
+		t.Fatalf("rotated acceptor (%v)", err)
+	}
+	// Once the old key is retired, peers holding only it are refused
+	ring.Set(next)
+	if err := exchange(old); !errors.Is(err, ErrAuth) {
+		t.Fatalf("retired key accepted (%v)", err)
+	}
+	if err := exchange(next); err != nil {
+		t.Fatalf("next key (%v)", err)
+	}
+
+	keys, err := ParseKeyring([]byte("# rotated\n" + string(FormatKeyring([][]byte{next, old}))))
+	if err != nil || len(keys) != 2 || string(keys[0]) != string(next) || string(keys[1]) != string(old) {
+		t.Fatalf("parse %q (%v)", keys, err)
+	}
+	if _, err = ParseKeyring([]byte("\n")); err == nil {
+		t.Fatalf("empty keyring parsed")
+	}
+}
+
+// Announced chunk lengths cost no memory until their bytes arrive
+func TestReadBounded(t *testing.T) {
+	announce := func(k uint64) *codecConn {
+		q := binary.AppendUvarint(nil, k)
+		return &codecConn{r: bufio.NewReader(bytes.NewReader(append(q, "short"...)))}
+	}
+	if _, err := announce(1<<20).read(48); !errors.Is(err, ErrAuth) {
+		t.Fatalf("oversized chunk (%v)", err)
+	}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := announce(maxChunk - 1).read(maxChunk)
+	runtime.ReadMemStats(&after)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("truncated chunk (%v)", err)
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Fatalf("truncated chunk allocated %d bytes", n)
+	}
+}
@@ -0,0 +1,402 @@
+// Package aead implements carrier transports over TCP, authenticated and
+// encrypted with a private key shared by all endpoints.
+//
+// Every connection begins with a handshake, in which both endpoints send a
+// hello carrying the cipher suites they support, identifiers of the keys in
+// their keyring, and a random nonce. Session keys, one for either direction,
+// are derived from a key both endpoints hold and both nonces with HKDF-SHA256. Each endpoint then sends a sealed digest of both
+// hellos, which proves knowledge of the shared key and guards the suite
+// negotiation. Chunks are sealed with AES-256-GCM or ChaCha20-Poly1305,
+// under nonces counting the chunks sent in each direction.
+//
+// Peers speaking the legacy HMAC/RC4 transport of package hmac are recognized
+// by their opening message and refused with ErrLegacy.
+package aead
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gocircuit/circuit/kit/tele/codec"
+	"github.com/gocircuit/circuit/kit/tele/trace"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Suite identifies an AEAD cipher
+type Suite byte
+
+const (
+	AESGCM Suite = iota + 1
+	ChaCha20Poly1305
+)
+
+func (s Suite) String() string {
+	switch s {
+	case AESGCM:
+		return "aes-256-gcm"
+	case ChaCha20Poly1305:
+		return "chacha20-poly1305"
+	}
+	return fmt.Sprintf("suite(%d)", byte(s))
+}
+
+// Suites lists the suites that transports created afterwards support, in the
+// order they prefer them. The preference of the dialing endpoint decides.
+var Suites = []Suite{AESGCM, ChaCha20Poly1305}
+
+// HandshakeTimeout bounds the time a connection may take to authenticate
+var HandshakeTimeout = 10 * time.Second
+
+var (
+	// ErrLegacy is returned when the peer speaks the legacy HMAC/RC4 transport
+	ErrLegacy = errors.New("peer uses the legacy HMAC/RC4 transport, which is no longer supported; upgrade it")
+	// ErrAuth is returned when the peer does not hold the shared key
+	ErrAuth = errors.New("authentication error, the peer holds a different key")
+)
+
+// magic opens every hello. The legacy transport opens with a JSON object.
+var magic = []byte("CIRCUIT/AEAD/1\n")
+
+const (
+	nonceLen = 32
+	maxChunk = 1 << 30
+	// readStep bounds the memory a chunk takes beyond its received bytes
+	readStep = 64 << 10
+)
+
+// NewTransport returns a carrier transport authenticating with the keys in keys.
+func NewTransport(keys *Keyring) codec.CarrierTransport {
+	return &codecTransport{
+		Frame:  trace.NewFrame("aead"),
+		keys:   keys,
+		suites: append([]Suite{}, Suites...),
+	}
+}
+
+type codecTransport struct {
+	trace.Frame
+	keys   *Keyring
+	suites []Suite
+}
+
+func (ct *codecTransport) Listen(addr net.Addr) codec.CarrierListener {
+	t := addr.String()
+	if strings.Index(t, ":") < 0 {
+		t = t + ":0"
+	}
+	l, err := net.Listen("tcp", t)
+	if err != nil {
+		return nil
+	}
+	return &codecListener{ct, l}
+}
+
+func (ct *codecTransport) Dial(addr net.Addr) (codec.CarrierConn, error) {
+	c, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		return nil, err
+	}
+	return newCodecConn(trace.NewFrame("aead", "dial"), c.(*net.TCPConn), ct, true)
+}
+
+type codecListener struct {
+	ct *codecTransport
+	net.Listener
+}
+
+func (l *codecListener) Addr() net.Addr {
+	return l.Listener.Addr()
+}
+
+func (l *codecListener) Accept() codec.CarrierConn {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			log.Printf("error accepting tcp connection: %v", err)
+			return nil
+		}
+		cc, err := newCodecConn(trace.NewFrame("aead", "acpt"), c.(*net.TCPConn), l.ct, false)
+		if err != nil {
+			log.Printf("handshake with %s failed: %v", c.RemoteAddr(), err)
+			continue
+		}
+		return cc
+	}
+}
+
+type codecConn struct {
+	trace.Frame
+	tcp  *net.TCPConn
+	r    *bufio.Reader
+	seal cipher.AEAD
+	open cipher.AEAD
+	sent uint64 // chunks sealed
+	rcvd uint64 // chunks opened
+}
+
+func newCodecConn(f trace.Frame, tcp *net.TCPConn, ct *codecTransport, dialer bool) (*codecConn, error) {
+	tcp.SetKeepAlive(true)
+	c := &codecConn{
+		Frame: f,
+		tcp:   tcp,
+		r:     bufio.NewReader(tcp),
+	}
+	tcp.SetDeadline(time.Now().Add(HandshakeTimeout))
+	if err := c.handshake(ct.keys.Keys(), ct.suites, dialer); err != nil {
+		tcp.Close()
+		return nil, err
+	}
+	tcp.SetDeadline(time.Time{})
+	return c, nil
+}
+
+// hello is the opening message of either endpoint
+type hello struct {
+	suites []Suite
+	keys   [][]byte // key identifiers
+	nonce  []byte
+}
+
+func (h *hello) bytes() []byte {
+	var b bytes.Buffer
+	b.Write(magic)
+	b.WriteByte(byte(len(h.suites)))
+	for _, s := range h.suites {
+		b.WriteByte(byte(s))
+	}
+	b.WriteByte(byte(len(h.keys)))
+	for _, id := range h.keys {
+		b.Write(id)
+	}
+	b.Write(h.nonce)
+	return b.Bytes()
+}
+
+func readHello(r *bufio.Reader) (*hello, error) {
+	peek, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if peek[0] == '{' {
+		return nil, ErrLegacy
+	}
+	m := make([]byte, len(magic))
+	if _, err = io.ReadFull(r, m); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(m, magic) {
+		return nil, errors.New("peer does not speak the aead transport")
+	}
+	h := &hello{}
+	suites, err := readCounted(r, 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range suites {
+		h.suites = append(h.suites, Suite(s))
+	}
+	ids, err := readCounted(r, keyIDLen)
+	if err != nil {
+		return nil, err
+	}
+	for len(ids) > 0 {
+		h.keys, ids = append(h.keys, ids[:keyIDLen]), ids[keyIDLen:]
+	}
+	h.nonce = make([]byte, nonceLen)
+	if _, err = io.ReadFull(r, h.nonce); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// readCounted reads a count byte, followed by as many items of size bytes
+func readCounted(r *bufio.Reader, size int) ([]byte, error) {
+	k, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, int(k)*size)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// negotiate returns the first suite preferred by the dialer, which the acceptor supports
+func negotiate(dialer, acceptor []Suite) (Suite, error) {
+	for _, s := range dialer {
+		for _, t := range acceptor {
+			if s == t {
+				return s, nil
+			}
+		}
+	}
+	return 0, errors.New("no cipher suite in common with the peer")
+}
+
+// chooseKey returns the first key of the dialer which the acceptor holds as well
+func chooseKey(keys [][]byte, dialer, acceptor [][]byte) ([]byte, error) {
+	for _, d := range dialer {
+		for _, a := range acceptor {
+			if !bytes.Equal(d, a) {
+				continue
+			}
+			for _, key := range keys {
+				if bytes.Equal(keyID(key), d) {
+					return key, nil
+				}
+			}
+		}
+	}
+	return nil, ErrAuth
+}
+
+func (c *codecConn) handshake(keys [][]byte, suites []Suite, dialer bool) error {
+	if len(keys) == 0 || len(keys) > maxKeys {
+		return errors.New("keyring holds no keys or too many")
+	}
+	// Exchange hellos
+	local := &hello{suites: suites, nonce: make([]byte, nonceLen)}
+	for _, key := range keys {
+		local.keys = append(local.keys, keyID(key))
+	}
+	if _, err := rand.Read(local.nonce); err != nil {
+		return err
+	}
+	if _, err := c.tcp.Write(local.bytes()); err != nil {
+		return err
+	}
+	remote, err := readHello(c.r)
+	if err != nil {
+		return err
+	}
+	dh, ah := local, remote
+	if !dialer {
+		dh, ah = remote, local
+	}
+	suite, err := negotiate(dh.suites, ah.suites)
+	if err != nil {
+		return err
+	}
+	key, err := chooseKey(keys, dh.keys, ah.keys)
+	if err != nil {
+		return err
+	}
+	// Derive the session keys of both directions
+	salt := append(append([]byte{}, dh.nonce...), ah.nonce...)
+	dkey, akey := make([]byte, 32), make([]byte, 32)
+	kdf := hkdf.New(sha256.New, key, salt, []byte("circuit aead "+suite.String()))
+	if _, err = io.ReadFull(kdf, dkey); err != nil {
+		return err
+	}
+	if _, err = io.ReadFull(kdf, akey); err != nil {
+		return err
+	}
+	if !dialer {
+		dkey, akey = akey, dkey
+	}
+	if c.seal, err = newAEAD(suite, dkey); err != nil {
+		return err
+	}
+	if c.open, err = newAEAD(suite, akey); err != nil {
+		return err
+	}
+	// Confirm the keys and the hellos they were derived from
+	digest := sha256.New()
+	digest.Write(dh.bytes())
+	digest.Write(ah.bytes())
+	transcript := digest.Sum(nil)
+	if err = c.Write(transcript); err != nil {
+		return err
+	}
+	// The peer has proven nothing yet, so the confirmation is read with its exact size
+	confirm, err := c.read(uint64(sha256.Size + c.open.Overhead()))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(confirm, transcript) {
+		return ErrAuth
+	}
+	return nil
+}
+
+func newAEAD(suite Suite, key []byte) (cipher.AEAD, error) {
+	switch suite {
+	case AESGCM:
+		b, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(b)
+	case ChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	}
+	return nil, fmt.Errorf("cipher suite %v not supported", suite)
+}
+
+func nonce(a cipher.AEAD, seq uint64) []byte {
+	q := make([]byte, a.NonceSize())
+	binary.BigEndian.PutUint64(q[len(q)-8:], seq)
+	return q
+}
+
+func (c *codecConn) RemoteAddr() net.Addr {
+	return c.tcp.RemoteAddr()
+}
+
+func (c *codecConn) Read() (chunk []byte, err error) {
+	return c.read(maxChunk)
+}
+
+// read reads and opens a sealed chunk of at most max bytes. The chunk is read
+// in steps, so that its buffer grows with the bytes received, rather than
+// with the length the peer announces.
+func (c *codecConn) read(max uint64) (chunk []byte, err error) {
+	k, err := binary.ReadUvarint(c.r)
+	if err != nil {
+		return nil, err
+	}
+	if k > max {
+		return nil, ErrAuth
+	}
+	q := make([]byte, 0, min(k, readStep))
+	for uint64(len(q)) < k {
+		n := len(q)
+		q = append(q, make([]byte, min(k-uint64(n), readStep))...)
+		if _, err = io.ReadFull(c.r, q[n:]); err != nil {
+			return nil, err
+		}
+	}
+	chunk, err = c.open.Open(q[:0], nonce(c.open, c.rcvd), q, nil)
+	if err != nil {
+		return nil, ErrAuth
+	}
+	c.rcvd++
+	return chunk, nil
+}
+
+func (c *codecConn) Write(chunk []byte) (err error) {
+	q := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(chunk)+c.seal.Overhead())
+	n := binary.PutUvarint(q, uint64(len(chunk)+c.seal.Overhead()))
+	q = c.seal.Seal(q[:n], nonce(c.seal, c.sent), chunk, nil)
+	c.sent++
+	_, err = c.tcp.Write(q)
+	return err
+}
+
+func (c *codecConn) Close() (err error) {
+	return c.tcp.Close()
+}
@@ -0,0 +1,91 @@
+package aead
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"sync"
+)
+
+// Keyring holds the current private key of a circuit, followed by the keys
+// it replaced and which peers may still hold. Connections use the first key
+// of the dialing endpoint which the accepting endpoint holds as well, so that
+// keys can be rotated one host at a time.
+// Keyrings are safe for concurrent use; a changed keyring applies to
+// connections made afterwards.
+type Keyring struct {
+	sync.Mutex
+	keys [][]byte
+}
+
+// NewKeyring returns a keyring holding keys, the current key first
+func NewKeyring(keys ...[]byte) *Keyring {
+	k := &Keyring{}
+	k.Set(keys...)
+	return k
+}
+
+// Set replaces the keys in the keyring, the current key first
+func (k *Keyring) Set(keys ...[]byte) {
+	k.Lock()
+	defer k.Unlock()
+	k.keys = append([][]byte{}, keys...)
+}
+
+// Keys returns the keys in the keyring, the current key first
+func (k *Keyring) Keys() [][]byte {
+	k.Lock()
+	defer k.Unlock()
+	return append([][]byte{}, k.keys...)
+}
+
+// maxKeys bounds the number of keys announced in a handshake
+const maxKeys = 255
+
+// keyIDLen is the length of the key identifiers exchanged in handshakes
+const keyIDLen = 8
+
+// keyID identifies a key to peers, without disclosing it
+func keyID(key []byte) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte("circuit key id"))
+	return m.Sum(nil)[:keyIDLen]
+}
+
+// ParseKeyring parses base64-encoded keys, one per line, the current key first.
+// Blank lines and lines starting with # are skipped.
+func ParseKeyring(text []byte) ([][]byte, error) {
+	var keys [][]byte
+	s := bufio.NewScanner(bytes.NewReader(text))
+	for s.Scan() {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(string(line))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no keys")
+	}
+	if len(keys) > maxKeys {
+		return nil, errors.New("too many keys")
+	}
+	return keys, nil
+}
+
+// FormatKeyring encodes keys in the form parsed by ParseKeyring
+func FormatKeyring(keys [][]byte) []byte {
+	var b bytes.Buffer
+	for _, key := range keys {
+		b.WriteString(base64.StdEncoding.EncodeToString(key))
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
@@ -16,3 +16,13 @@ var (
 	ErrGone  = errors.New("gone")
 	ErrOff   = errors.New("off")
 )
+
+// abortError returns the error of an abort with the given reason
+func abortError(reason string) error {
+	for _, err := range []error{ErrClash, ErrGone, ErrOff} {
+		if err.Error() == reason {
+			return err
+		}
+	}
+	return errors.New(reason)
+}
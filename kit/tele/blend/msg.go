@@ -21,8 +21,10 @@ type PayloadMsg struct {
 	Payload interface{} // User-supplied type that can be coded by the underlying codec
 }
 
+// AbortMsg carries the reason for the abort as text, since the codec
+// cannot encode arbitrary error values.
 type AbortMsg struct {
-	Err error
+	Reason string
 }
 
 type Msg struct {
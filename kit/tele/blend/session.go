@@ -104,6 +104,11 @@ func (ssn *Session) RemoteAddr() net.Addr {
 	return ssn.subr.RemoteAddr()
 }
 
+// PeerName returns the authenticated name of the remote endpoint, if the carrier provides one
+func (ssn *Session) PeerName() string {
+	return ssn.subr.PeerName()
+}
+
 func (ssn *Session) hijack() (w_sub *codec.Conn) {
 	ssn.w_.Lock()
 	defer ssn.w_.Unlock()
@@ -194,7 +199,7 @@ func (ssn *Session) read() error {
 			return nil
 		}
 		ssn.scrub(msg.ConnID)
-		conn.prompt(nil, t.Err)
+		conn.prompt(nil, abortError(t.Reason))
 		return nil
 	}
 
@@ -244,7 +249,7 @@ func (ssn *Session) writeAbort(connID ConnID, reason error) error {
 	msg := &Msg{
 		ConnID: connID,
 		Demux: &AbortMsg{
-			Err: reason,
+			Reason: reason.Error(),
 		},
 	}
 	return ssn.write(msg)
@@ -37,6 +37,14 @@ func (c *Conn) RemoteAddr() net.Addr {
 	return c.carrier.RemoteAddr()
 }
 
+// PeerName returns the authenticated name of the remote endpoint, if the carrier provides one
+func (c *Conn) PeerName() string {
+	if nc, ok := c.carrier.(NamedCarrierConn); ok {
+		return nc.PeerName()
+	}
+	return ""
+}
+
 func (c *Conn) Write(v interface{}) (err error) {
 	chunk, err := c.enc.Encode(v)
 	if err != nil {
@@ -28,6 +28,14 @@ type CarrierConn interface {
 	Close() (err error)
 }
 
+// NamedCarrierConn is implemented by carrier connections which authenticate
+// the remote endpoint individually, such as by certificate.
+type NamedCarrierConn interface {
+	CarrierConn
+	// PeerName returns the authenticated name of the remote endpoint
+	PeerName() string
+}
+
 type Transport struct {
 	sub   CarrierTransport
 	codec Codec
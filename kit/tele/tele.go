@@ -8,10 +8,14 @@
 package tele
 
 import (
+	"crypto/tls"
+
+	"github.com/gocircuit/circuit/kit/tele/aead"
 	"github.com/gocircuit/circuit/kit/tele/blend"
 	"github.com/gocircuit/circuit/kit/tele/codec"
 	"github.com/gocircuit/circuit/kit/tele/hmac"
 	"github.com/gocircuit/circuit/kit/tele/tcp"
+	teletls "github.com/gocircuit/circuit/kit/tele/tls"
 	"github.com/gocircuit/circuit/kit/tele/trace"
 )
 
@@ -35,3 +39,27 @@ func NewStructOverTCPWithHMAC(key []byte) *blend.Transport {
 	// Blend
 	return blend.NewTransport(f.Refine("blend"), x3)
 }
+
+// NewStructOverAEAD returns a transport over TCP, authenticated and encrypted
+// with session keys derived from the shared private keys in keys
+func NewStructOverAEAD(keys *aead.Keyring) *blend.Transport {
+	f := trace.NewFrame("tele")
+	// Carrier
+	x2 := aead.NewTransport(keys)
+	// Codec
+	x3 := codec.NewTransport(x2, codec.GobCodec{})
+	// Blend
+	return blend.NewTransport(f.Refine("blend"), x3)
+}
+
+// NewStructOverTLS returns a transport over TCP, secured by TLS 1.3 with
+// mutual certificate authentication
+func NewStructOverTLS(config *tls.Config) *blend.Transport {
+	f := trace.NewFrame("tele")
+	// Carrier
+	x2 := teletls.NewTransport(config)
+	// Codec
+	x3 := codec.NewTransport(x2, codec.GobCodec{})
+	// Blend
+	return blend.NewTransport(f.Refine("blend"), x3)
+}
@@ -0,0 +1,202 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"time"
+)
+
+// Roles of certificate holders, recorded as the organizational unit of the
+// certificate subject
+const (
+	RoleNode   = "node"
+	RoleClient = "client"
+)
+
+// LoadConfig returns a mutual TLS configuration presenting the certificate
+// and key in the PEM files certFile and keyFile, and trusting peers with
+// certificates issued by the authority in the PEM file caFile.
+func LoadConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	ca := x509.NewCertPool()
+	if !ca.AppendCertsFromPEM(buf) {
+		return nil, fmt.Errorf("no certificates in %s", caFile)
+	}
+	return NewConfig(cert, ca), nil
+}
+
+// NewConfig returns a mutual TLS 1.3 configuration presenting cert, and
+// trusting peers with certificates issued by an authority in ca for the role
+// of a circuit server or client. Endpoints in the client role trust servers
+// only. The same configuration serves for dialing and listening.
+func NewConfig(cert tls.Certificate, ca *x509.CertPool) *tls.Config {
+	var role string
+	if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil {
+		role = roleOf(leaf)
+	}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS13,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca,
+		RootCAs:      ca,
+		// Servers are dialed by IP address, which their certificates need not
+		// name, so the chain of the server is verified without its host name.
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			return verifyChain(cs, ca, role)
+		},
+	}
+}
+
+// roleOf returns the role recorded in cert, if any
+func roleOf(cert *x509.Certificate) string {
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if ou == RoleNode || ou == RoleClient {
+			return ou
+		}
+	}
+	return ""
+}
+
+// verifyChain verifies the certificate chain of the peer. Circuit servers and
+// clients both dial and listen, so the certificate of the peer must be issued
+// for both server and client authentication, whatever side of the connection
+// it is on, and for a role. An endpoint in the client role accepts servers only.
+func verifyChain(cs tls.ConnectionState, ca *x509.CertPool, role string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("peer presented no certificate")
+	}
+	leaf := cs.PeerCertificates[0]
+	inter := x509.NewCertPool()
+	for _, c := range cs.PeerCertificates[1:] {
+		inter.AddCert(c)
+	}
+	for _, usage := range []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth} {
+		_, err := leaf.Verify(x509.VerifyOptions{
+			Roots:         ca,
+			Intermediates: inter,
+			KeyUsages:     []x509.ExtKeyUsage{usage},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if leaf.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		return fmt.Errorf("certificate of %s is not for digital signatures", leaf.Subject.CommonName)
+	}
+	switch peer := roleOf(leaf); {
+	case peer == "":
+		return fmt.Errorf("certificate of %s has no circuit role", leaf.Subject.CommonName)
+	case role == RoleClient && peer != RoleNode:
+		return fmt.Errorf("certificate of %s is for a %s, not a server", leaf.Subject.CommonName, peer)
+	}
+	return nil
+}
+
+// NewAuthority creates the self-signed certificate of a certificate
+// authority, and its private key, both PEM-encoded
+func NewAuthority(name string, validity time.Duration) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl, err := template(name, "", validity)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl.IsCA = true
+	tmpl.BasicConstraintsValid = true
+	tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encode(der, key)
+}
+
+// Issue creates a certificate for name in role, signed by the authority with
+// the PEM-encoded certificate and key caCertPEM and caKeyPEM. Hosts lists the
+// host names and IP addresses the certificate names, if any.
+// Circuit servers and clients both dial and listen, so certificates of either
+// role serve for both.
+func Issue(caCertPEM, caKeyPEM []byte, name, role string, hosts []string, validity time.Duration) (certPEM, keyPEM []byte, err error) {
+	if role != RoleNode && role != RoleClient {
+		return nil, nil, fmt.Errorf("certificate role %q not known", role)
+	}
+	ca, err := tls.X509KeyPair(caCertPEM, caKeyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	caCert, err := x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl, err := template(name, role, validity)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, &key.PublicKey, ca.PrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encode(der, key)
+}
+
+func template(name, role string, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	subject := pkix.Name{CommonName: name}
+	if role != "" {
+		subject.OrganizationalUnit = []string{role}
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      subject,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(validity),
+	}, nil
+}
+
+func encode(der []byte, key *ecdsa.PrivateKey) (certPEM, keyPEM []byte, err error) {
+	k, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: k})
+	return certPEM, keyPEM, nil
+}
@@ -0,0 +1,149 @@
+// Package tls implements carrier transports over TCP using TLS 1.3 with mutual
+// certificate authentication.
+//
+// Every endpoint presents a certificate issued by the circuit's authority, and
+// verifies that the certificate of its peer is issued by the same authority.
+// Host names are not verified, since circuit servers are addressed by IP.
+package tls
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gocircuit/circuit/kit/tele/codec"
+	"github.com/gocircuit/circuit/kit/tele/trace"
+)
+
+// HandshakeTimeout bounds the time an accepted connection may take to authenticate
+var HandshakeTimeout = 10 * time.Second
+
+func NewTransport(config *tls.Config) codec.CarrierTransport {
+	return &codecTransport{
+		Frame:  trace.NewFrame("tls"),
+		config: config,
+	}
+}
+
+type codecTransport struct {
+	trace.Frame
+	config *tls.Config
+}
+
+func (ct *codecTransport) Listen(addr net.Addr) codec.CarrierListener {
+	t := addr.String()
+	if strings.Index(t, ":") < 0 {
+		t = t + ":0"
+	}
+	l, err := net.Listen("tcp", t)
+	if err != nil {
+		return nil
+	}
+	return &codecListener{ct.config, l}
+}
+
+func (ct *codecTransport) Dial(addr net.Addr) (codec.CarrierConn, error) {
+	c, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		return nil, err
+	}
+	tc := tls.Client(c, ct.config)
+	if err = handshake(tc); err != nil {
+		return nil, err
+	}
+	return newCodecConn(trace.NewFrame("tls", "dial"), tc), nil
+}
+
+type codecListener struct {
+	config *tls.Config
+	net.Listener
+}
+
+func (l *codecListener) Addr() net.Addr {
+	return l.Listener.Addr()
+}
+
+func (l *codecListener) Accept() codec.CarrierConn {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			log.Printf("error accepting tcp connection: %v", err)
+			return nil
+		}
+		tc := tls.Server(c, l.config)
+		if err = handshake(tc); err != nil {
+			log.Printf("tls handshake with %s failed: %v", c.RemoteAddr(), err)
+			continue
+		}
+		return newCodecConn(trace.NewFrame("tls", "acpt"), tc)
+	}
+}
+
+func handshake(tc *tls.Conn) error {
+	if c, ok := tc.NetConn().(*net.TCPConn); ok {
+		c.SetKeepAlive(true)
+	}
+	tc.SetDeadline(time.Now().Add(HandshakeTimeout))
+	if err := tc.Handshake(); err != nil {
+		tc.Close()
+		return err
+	}
+	tc.SetDeadline(time.Time{})
+	return nil
+}
+
+type codecConn struct {
+	trace.Frame
+	tls *tls.Conn
+	r   *bufio.Reader
+}
+
+func newCodecConn(f trace.Frame, c *tls.Conn) *codecConn {
+	return &codecConn{f, c, bufio.NewReader(c)}
+}
+
+func (c *codecConn) RemoteAddr() net.Addr {
+	return c.tls.RemoteAddr()
+}
+
+// PeerName returns the common name of the verified certificate of the remote endpoint
+func (c *codecConn) PeerName() string {
+	cs := c.tls.ConnectionState()
+	if len(cs.PeerCertificates) == 0 {
+		return ""
+	}
+	return cs.PeerCertificates[0].Subject.CommonName
+}
+
+func (c *codecConn) Read() (chunk []byte, err error) {
+	k, err := binary.ReadUvarint(c.r)
+	if err != nil {
+		return nil, err
+	}
+	var q = make([]byte, k)
+	var n, m int
+	for m < len(q) && err == nil {
+		n, err = c.r.Read(q[m:])
+		m += n
+	}
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (c *codecConn) Write(chunk []byte) (err error) {
+	q := make([]byte, len(chunk)+8)
+	n := binary.PutUvarint(q, uint64(len(chunk)))
+	m := copy(q[n:], chunk)
+	_, err = c.tls.Write(q[:n+m])
+	return err
+}
+
+func (c *codecConn) Close() (err error) {
+	return c.tls.Close()
+}
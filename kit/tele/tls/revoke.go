@@ -0,0 +1,123 @@
+package tls
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrRevoked is the error of peers presenting a revoked certificate
+var ErrRevoked = errors.New("certificate revoked")
+
+// Revoked is a list of revoked certificates, identified by their serial
+// numbers. It is safe for concurrent use, and can be reloaded while in use.
+type Revoked struct {
+	sync.Mutex
+	file   string
+	serial map[string]bool // hexadecimal serial numbers
+}
+
+// LoadRevoked reads the list of revoked certificates in file. The file lists
+// serial numbers in hexadecimal, as printed by circuit certgen, one per line.
+// Colons between bytes are ignored, as are empty lines and lines starting with #.
+func LoadRevoked(file string) (*Revoked, error) {
+	r := &Revoked{file: file}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload rereads the file of the list, which applies to connections made afterwards.
+func (r *Revoked) Reload() error {
+	buf, err := os.ReadFile(r.file)
+	if err != nil {
+		return err
+	}
+	serial, err := parseRevoked(buf)
+	if err != nil {
+		return fmt.Errorf("revocation list %s: %v", r.file, err)
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.serial = serial
+	return nil
+}
+
+// Len returns the number of revoked certificates
+func (r *Revoked) Len() int {
+	r.Lock()
+	defer r.Unlock()
+	return len(r.serial)
+}
+
+func parseRevoked(buf []byte) (map[string]bool, error) {
+	serial := make(map[string]bool)
+	s := bufio.NewScanner(bytes.NewReader(buf))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		n, ok := new(big.Int).SetString(strings.ReplaceAll(line, ":", ""), 16)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a hexadecimal serial number", line)
+		}
+		serial[n.Text(16)] = true
+	}
+	return serial, s.Err()
+}
+
+// check returns an error if any of certs is revoked
+func (r *Revoked) check(certs []*x509.Certificate) error {
+	r.Lock()
+	defer r.Unlock()
+	for _, c := range certs {
+		if r.serial[c.SerialNumber.Text(16)] {
+			return fmt.Errorf("%w: %s, serial %s", ErrRevoked, c.Subject.CommonName, Serial(c))
+		}
+	}
+	return nil
+}
+
+// WithRevoked returns a copy of config, made by NewConfig or LoadConfig,
+// which also refuses peers whose certificate chain holds a revoked certificate.
+func WithRevoked(config *tls.Config, revoked *Revoked) *tls.Config {
+	c := config.Clone()
+	verify := config.VerifyConnection
+	c.VerifyConnection = func(cs tls.ConnectionState) error {
+		if verify != nil {
+			if err := verify(cs); err != nil {
+				return err
+			}
+		}
+		return revoked.check(cs.PeerCertificates)
+	}
+	return c
+}
+
+// Serial returns the serial number of cert, as listed in revocation lists
+func Serial(cert *x509.Certificate) string {
+	return cert.SerialNumber.Text(16)
+}
+
+// SerialPEM returns the serial number of the PEM-encoded certificate certPEM
+func SerialPEM(certPEM []byte) (string, error) {
+	b, _ := pem.Decode(certPEM)
+	if b == nil {
+		return "", errors.New("no PEM-encoded certificate")
+	}
+	cert, err := x509.ParseCertificate(b.Bytes)
+	if err != nil {
+		return "", err
+	}
+	return Serial(cert), nil
+}
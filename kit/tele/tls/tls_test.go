@@ -0,0 +1,188 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gocircuit/circuit/kit/tele/codec"
+)
+
+func config(t *testing.T, caCert, caKey []byte, name, role string) *tls.Config {
+	certPEM, keyPEM, err := Issue(caCert, caKey, name, role, []string{"127.0.0.1"}, time.Hour)
+	if err != nil {
+		t.Fatalf("issue (%v)", err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("key pair (%v)", err)
+	}
+	ca := x509.NewCertPool()
+	ca.AppendCertsFromPEM(caCert)
+	return NewConfig(cert, ca)
+}
+
+func TestTLS(t *testing.T) {
+	HandshakeTimeout = time.Second
+	caCert, caKey, err := NewAuthority("test", time.Hour)
+	if err != nil {
+		t.Fatalf("authority (%v)", err)
+	}
+	if _, _, err = Issue(caCert, caKey, "x", "admin", nil, time.Hour); err == nil {
+		t.Fatalf("unknown role accepted")
+	}
+	node := NewTransport(config(t, caCert, caKey, "node", RoleNode))
+	l := node.Listen(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if l == nil {
+		t.Fatalf("listen")
+	}
+	accepted := make(chan []byte)
+	go func() {
+		for {
+			c := l.Accept()
+			if c == nil {
+				return
+			}
+			chunk, err := c.Read()
+			if err != nil {
+				continue
+			}
+			accepted <- chunk
+			c.Write(chunk)
+		}
+	}()
+
+	// A client with a certificate of the same authority is accepted
+	c, err := NewTransport(config(t, caCert, caKey, "client", RoleClient)).Dial(l.Addr())
+	if err != nil {
+		t.Fatalf("dial (%v)", err)
+	}
+	if err = c.Write([]byte("hello")); err != nil {
+		t.Fatalf("write (%v)", err)
+	}
+	if chunk := <-accepted; string(chunk) != "hello" {
+		t.Fatalf("accepted %q", chunk)
+	}
+	if chunk, err := c.Read(); err != nil || string(chunk) != "hello" {
+		t.Fatalf("read %q (%v)", chunk, err)
+	}
+	c.Close()
+
+	// A client with a certificate of another authority is rejected
+	otherCert, otherKey, err := NewAuthority("other", time.Hour)
+	if err != nil {
+		t.Fatalf("authority (%v)", err)
+	}
+	c, err = NewTransport(config(t, otherCert, otherKey, "mallory", RoleClient)).Dial(l.Addr())
+	if err == nil {
+		if err = c.Write([]byte("hello")); err == nil {
+			_, err = c.Read()
+		}
+	}
+	if err == nil {
+		t.Fatalf("foreign certificate accepted")
+	}
+	select {
+	case chunk := <-accepted:
+		t.Fatalf("chunk %q from foreign certificate accepted", chunk)
+	default:
+	}
+}
+
+// echo serves the first chunk of connections accepted by l back to them
+func echo(l codec.CarrierListener) {
+	for {
+		c := l.Accept()
+		if c == nil {
+			return
+		}
+		if chunk, err := c.Read(); err == nil {
+			c.Write(chunk)
+		}
+	}
+}
+
+// exchange reports whether a chunk makes a round trip from config to addr
+func exchange(config *tls.Config, addr net.Addr) bool {
+	c, err := NewTransport(config).Dial(addr)
+	if err != nil {
+		return false
+	}
+	defer c.Close()
+	if err = c.Write([]byte("hello")); err != nil {
+		return false
+	}
+	chunk, err := c.Read()
+	return err == nil && string(chunk) == "hello"
+}
+
+func TestRevoked(t *testing.T) {
+	HandshakeTimeout = time.Second
+	caCert, caKey, err := NewAuthority("test", time.Hour)
+	if err != nil {
+		t.Fatalf("authority (%v)", err)
+	}
+	file := filepath.Join(t.TempDir(), "revoked")
+	if err = os.WriteFile(file, []byte("# none yet\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := LoadRevoked(file)
+	if err != nil {
+		t.Fatalf("load (%v)", err)
+	}
+	l := NewTransport(WithRevoked(config(t, caCert, caKey, "node", RoleNode), revoked)).Listen(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	go echo(l)
+
+	client := config(t, caCert, caKey, "client", RoleClient)
+	if !exchange(client, l.Addr()) {
+		t.Fatalf("client refused before revocation")
+	}
+	leaf, err := x509.ParseCertificate(client.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(file, []byte("# compromised\n"+Serial(leaf)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = revoked.Reload(); err != nil {
+		t.Fatalf("reload (%v)", err)
+	}
+	if exchange(client, l.Addr()) {
+		t.Fatalf("revoked client accepted")
+	}
+	if !exchange(config(t, caCert, caKey, "other", RoleClient), l.Addr()) {
+		t.Fatalf("client refused with another certificate revoked")
+	}
+
+	// A malformed list leaves the loaded list in place
+	if err = os.WriteFile(file, []byte("not a serial\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = revoked.Reload(); err == nil {
+		t.Fatalf("malformed list loaded")
+	}
+	if revoked.Len() != 1 {
+		t.Fatalf("revoked %d certificates", revoked.Len())
+	}
+}
+
+func TestRoles(t *testing.T) {
+	HandshakeTimeout = time.Second
+	caCert, caKey, err := NewAuthority("test", time.Hour)
+	if err != nil {
+		t.Fatalf("authority (%v)", err)
+	}
+	// An endpoint in the client role accepts servers, but not other clients
+	l := NewTransport(config(t, caCert, caKey, "client", RoleClient)).Listen(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	go echo(l)
+	if !exchange(config(t, caCert, caKey, "node", RoleNode), l.Addr()) {
+		t.Fatalf("server refused by client")
+	}
+	if exchange(config(t, caCert, caKey, "mallory", RoleClient), l.Addr()) {
+		t.Fatalf("client accepted by client")
+	}
+}
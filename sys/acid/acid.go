@@ -19,14 +19,17 @@ import (
 	"github.com/gocircuit/circuit/use/errors"
 )
 
-func New() *Acid {
-	return &Acid{}
+// New returns the acid service of the runtime rt
+func New(rt Runtime) *Acid {
+	return &Acid{rt: rt}
 }
 
-type Acid struct{}
+type Acid struct {
+	rt Runtime
+}
 
 func init() {
-	circuit.RegisterValue(New())
+	circuit.RegisterValue(New(nil))
 }
 
 /*
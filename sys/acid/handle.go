@@ -0,0 +1,60 @@
+// Copyright 2013 The Go Circuit Project
+// Use of this source code is governed by the license for
+// The Go Circuit Project, found in the LICENSE file.
+
+package acid
+
+import (
+	"time"
+
+	"github.com/gocircuit/circuit/use/errors"
+)
+
+// Runtime is implemented by the circuit runtime hosting the acid service
+type Runtime interface {
+	RuntimeStat() *RuntimeStat
+}
+
+// RuntimeStat lists the handles to local values that a runtime has issued
+// to other runtimes.
+type RuntimeStat struct {
+	Addr string    `json:"addr"`
+	Time time.Time `json:"time"`
+
+	NumPerm    int `json:"num_perm"`
+	NumNonPerm int `json:"num_nonperm"`
+
+	// Perm lists the permanent handles, which are never released
+	Perm []*HandleStat `json:"perm,omitempty"`
+
+	// Importers lists the non-permanent handles, by the runtime holding them
+	Importers []*ImporterStat `json:"importers,omitempty"`
+}
+
+// ImporterStat lists the non-permanent handles held by one runtime.
+type ImporterStat struct {
+	Addr string `json:"addr"`
+
+	// Orphan is set if the runtime no longer watches the importer for
+	// liveness. The handles of an orphan importer are not released when it
+	// dies and are likely leaked. Importers are orphan for a brief moment
+	// while they are being added or removed.
+	Orphan bool `json:"orphan,omitempty"`
+
+	Handles []*HandleStat `json:"handles"`
+}
+
+// HandleStat describes an exported handle
+type HandleStat struct {
+	ID   string        `json:"id"`
+	Type string        `json:"type"`
+	Age  time.Duration `json:"age"`
+}
+
+// RuntimeStat returns the handles this worker's runtime has exported, oldest first
+func (s *Acid) RuntimeStat() (*RuntimeStat, error) {
+	if s.rt == nil {
+		return nil, errors.NewError("runtime not introspectable")
+	}
+	return s.rt.RuntimeStat(), nil
+}
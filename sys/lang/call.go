@@ -8,15 +8,18 @@
 package lang
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"runtime/debug"
 
 	"github.com/gocircuit/circuit/sys/lang/types"
+	"github.com/gocircuit/circuit/use/errors"
 )
 
-// call invokes the method of r encoded by f with respect to t, with arguments a
-func call(recv reflect.Value, t *types.TypeChar, id types.FuncID, arg []interface{}) (reply []interface{}, err error) {
+// call invokes the method of r encoded by f with respect to t, with arguments a.
+// Methods taking a context are passed ctx.
+func call(ctx context.Context, recv reflect.Value, t *types.TypeChar, id types.FuncID, arg []interface{}) (reply []interface{}, err error) {
 	// Recover panic in user code and return it in error argument
 	defer func() {
 		p := recover()
@@ -26,7 +29,7 @@ func call(recv reflect.Value, t *types.TypeChar, id types.FuncID, arg []interfac
 		t := "server-side runtime.call(…):\n" + string(debug.Stack())
 		switch q := p.(type) {
 		case error:
-			err = NewError("%s", q.Error()+"\n"+t)
+			err = errors.Annotate(q, "\n"+t)
 		default:
 			err = NewError("%s", fmt.Sprintf("%#v\n%s", q, t))
 		}
@@ -36,8 +39,11 @@ func call(recv reflect.Value, t *types.TypeChar, id types.FuncID, arg []interfac
 	if fn == nil {
 		return nil, NewError("no func")
 	}
-	av := make([]reflect.Value, 0, 1+len(arg))
+	av := make([]reflect.Value, 0, 2+len(arg))
 	av = append(av, recv)
+	if fn.Context {
+		av = append(av, reflect.ValueOf(&ctx).Elem())
+	}
 	for _, a := range arg {
 		av = append(av, reflect.ValueOf(a))
 	}
@@ -0,0 +1,198 @@
+package lang
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocircuit/circuit/use/circuit"
+)
+
+type testSleeper struct {
+	done chan error
+}
+
+func (s *testSleeper) Sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		s.done <- nil
+		return true
+	case <-ctx.Done():
+		s.done <- ctx.Err()
+		return false
+	}
+}
+
+func TestCallContext(t *testing.T) {
+	sleeper := &testSleeper{done: make(chan error, 1)}
+	server, client := New(NewSandbox()), New(NewSandbox())
+	server.Listen("sleeper", sleeper)
+	x := client.Dial(server.ServerAddr(), "sleeper")
+
+	// Calls complete within their deadline, or without one
+	if !x.Call("Sleep", time.Millisecond)[0].(bool) || <-sleeper.done != nil {
+		t.Fatalf("call")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	if !x.CallContext(ctx, "Sleep", time.Millisecond)[0].(bool) || <-sleeper.done != nil {
+		t.Fatalf("call with deadline")
+	}
+	cancel()
+
+	// The deadline is propagated to the remote method, which gives up at
+	// the deadline by itself. The caller's context is never done, so that
+	// it sends no cancellation.
+	dctx := deadlineContext{context.Background(), time.Now().Add(50 * time.Millisecond)}
+	slept := make(chan bool, 1)
+	go func() {
+		s, _ := callPanic(dctx, x)
+		slept <- s
+	}()
+	select {
+	case err := <-sleeper.done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("remote deadline (%v)", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("remote call not given up")
+	}
+	if <-slept {
+		t.Fatalf("deadline")
+	}
+
+	// A caller whose deadline passes gives up, or receives the reply of the
+	// method giving up. The remote method gives up at its deadline, or when
+	// the caller's cancellation arrives, whichever is first.
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if slept, err := callPanic(ctx, x); slept || err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("caller deadline (%v)", err)
+	}
+	select {
+	case err := <-sleeper.done:
+		if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+			t.Fatalf("remote caller deadline (%v)", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("remote call not given up")
+	}
+
+	// So is cancellation
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := callPanic(ctx, x); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancel (%v)", err)
+	}
+	select {
+	case err := <-sleeper.done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("remote cancel (%v)", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("remote call not canceled")
+	}
+}
+
+// testHanger never replies until released, whatever its caller does
+type testHanger struct {
+	release chan struct{}
+}
+
+func (h *testHanger) Hang() { <-h.release }
+
+// The reply of an abandoned call is awaited for CancelGrace only
+func TestCallAbandoned(t *testing.T) {
+	defer func(d time.Duration) { CancelGrace = d }(CancelGrace)
+	CancelGrace = 50 * time.Millisecond
+	h := &testHanger{release: make(chan struct{})}
+	defer close(h.release)
+	server, client := New(NewSandbox()), New(NewSandbox())
+	server.Listen("hanger", h)
+	x := client.Dial(server.ServerAddr(), "hanger")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	func() {
+		defer func() {
+			if r := recover(); !errors.Is(r.(error), context.DeadlineExceeded) {
+				t.Fatalf("abandoned call (%v)", r)
+			}
+		}()
+		x.CallContext(ctx, "Hang")
+	}()
+	for i := 0; ; i++ {
+		buf := make([]byte, 1<<20)
+		stacks := string(buf[:runtime.Stack(buf, true)])
+		if !strings.Contains(stacks, "lang.(*_ptr).drain") && !strings.Contains(stacks, "lang.readContext.func") {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("abandoned call not drained:\n%s", stacks)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// deadlineContext has a deadline but is never done
+type deadlineContext struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c deadlineContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func callPanic(ctx context.Context, x interface {
+	CallContext(context.Context, string, ...interface{}) []interface{}
+}) (slept bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = r.(error)
+		}
+	}()
+	return x.CallContext(ctx, "Sleep", time.Hour)[0].(bool), nil
+}
+
+// testGuarded allows remote callers to call Open only
+type testGuarded struct {
+	called chan string
+}
+
+func (g *testGuarded) Authorize(method string) error {
+	if method != "Open" {
+		return errors.New("denied")
+	}
+	return nil
+}
+
+func (g *testGuarded) Open() { g.called <- "Open" }
+
+func (g *testGuarded) Closed() { g.called <- "Closed" }
+
+func TestCallAuthorize(t *testing.T) {
+	g := &testGuarded{called: make(chan string, 2)}
+	server, client := New(NewSandbox()), New(NewSandbox())
+	server.Listen("guarded", g)
+	x := client.Dial(server.ServerAddr(), "guarded")
+
+	x.Call("Open")
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = r.(error)
+			}
+		}()
+		x.Call("Closed")
+		return nil
+	}()
+	if !errors.Is(err, circuit.ErrRemote) || err.Error() != "denied" {
+		t.Fatalf("denied call (%v)", err)
+	}
+	if m := <-g.called; m != "Open" || len(g.called) != 0 {
+		t.Fatalf("called %s and %d more", m, len(g.called))
+	}
+}
@@ -11,6 +11,7 @@ import (
 	//"fmt"
 	//"runtime/debug"
 
+	"github.com/gocircuit/circuit/sys/lang/prof"
 	"github.com/gocircuit/circuit/sys/lang/types"
 	"github.com/gocircuit/circuit/use/circuit"
 	"github.com/gocircuit/circuit/use/n"
@@ -32,20 +33,26 @@ func (r *Runtime) Dial(addr n.Addr, service string) circuit.PermX {
 	}
 	ptr, err := r.TryDial(addr, service)
 	if err != nil {
-		panic(err)
+		panic(unreachable(err))
 	}
 	return ptr
 }
 
 // TryDial returns an ptr to the permanent xvalue of the addressed remote runtime
-func (r *Runtime) TryDial(addr n.Addr, service string) (circuit.PermX, error) {
+func (r *Runtime) TryDial(addr n.Addr, service string) (_ circuit.PermX, err error) {
 	conn, err := r.t.Dial(addr)
 	if err != nil {
 		return nil, err
 	}
 	defer conn.Close()
 
-	retrn, err := writeReturn(conn, &dialMsg{Service: service})
+	tr := r.traceCall(prof.SpanContext{}, "dial "+service, addr, map[string]string{
+		"circuit.service": service,
+	})
+	defer func() {
+		tr.End(err)
+	}()
+	retrn, err := writeReturn(conn, &dialMsg{Service: service, Trace: tr.Context()})
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +68,12 @@ func (r *Runtime) serveDial(req *dialMsg, conn n.Conn) {
 	// Go guarantees the defer runs even if panic occurs
 	defer conn.Close()
 
+	tr := r.traceReply(req.Trace, "dial "+req.Service, conn.Addr(), map[string]string{
+		"circuit.service": req.Service,
+	})
+
 	expDial, _ := r.exportValues([]interface{}{PermRef(r.srv.Get(req.Service))}, conn.Addr())
+	tr.End(nil)
 	conn.Write(&returnMsg{Out: expDial})
 	// Waiting for export acks not necessary since expDial is always a permptr.
 }
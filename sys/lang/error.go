@@ -10,6 +10,8 @@ package lang
 import (
 	"encoding/gob"
 	"fmt"
+
+	"github.com/gocircuit/circuit/use/circuit"
 )
 
 func init() {
@@ -30,3 +32,26 @@ type errorString struct {
 func (e *errorString) Error() string {
 	return e.S
 }
+
+// callError is the error a cross-call panics with, matching kind under errors.Is.
+type callError struct {
+	kind error
+	err  error
+}
+
+func (e *callError) Error() string {
+	return e.err.Error()
+}
+
+func (e *callError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *callError) Unwrap() error {
+	return e.err
+}
+
+// unreachable returns err as the error of a remote worker which cannot be reached
+func unreachable(err error) error {
+	return &callError{kind: circuit.ErrUnreachable, err: err}
+}
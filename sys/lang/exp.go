@@ -12,6 +12,7 @@ import (
 	"reflect"
 	"runtime"
 	"sync"
+	"time"
 
 	"github.com/gocircuit/circuit/sys/lang/types"
 	"github.com/gocircuit/circuit/use/circuit"
@@ -34,6 +35,7 @@ type expHandle struct {
 	Importer n.Addr
 	Value    reflect.Value // receiver of methods
 	Type     *types.TypeChar
+	Created  time.Time
 }
 
 func (exph *expHandle) String() string {
@@ -87,6 +89,7 @@ func (exp *expTabl) Add(receiver interface{}, importer n.Addr) *expHandle {
 		Importer: importer,
 		Value:    reflect.ValueOf(receiver),
 		Type:     typ,
+		Created:  time.Now(),
 	}
 
 	// Insert in handle map
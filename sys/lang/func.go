@@ -9,12 +9,14 @@ package lang
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"runtime/pprof"
 	"time"
 
+	"github.com/gocircuit/circuit/sys/lang/prof"
 	"github.com/gocircuit/circuit/sys/lang/types"
 	"github.com/gocircuit/circuit/use/circuit"
 	"github.com/gocircuit/circuit/use/errors"
@@ -64,6 +66,15 @@ func (r *Runtime) serveGo(req *goMsg, conn n.Conn) {
 	// the caller is waiting for a return message anyway
 	exit = "import"
 	mainID := t.MainID()
+	tr := r.traceReply(req.Trace, t.Type.String(), conn.Addr(), map[string]string{
+		"circuit.func": t.Type.String(),
+	})
+	var err error
+	defer func() {
+		tr.End(err)
+		// Spawned runtimes exit when the function returns
+		r.tracer.Load().Close()
+	}()
 	in, err := r.importValues(req.In, t.Func[mainID].InTypes, conn.Addr(), true, nil)
 	if err != nil {
 		conn.Write(&returnMsg{Err: err})
@@ -71,7 +82,11 @@ func (r *Runtime) serveGo(req *goMsg, conn n.Conn) {
 	}
 
 	exit = "call"
-	reply, err := call(t.Zero(), t, mainID, in)
+	ctx := context.Background()
+	if sc := tr.Context(); sc.IsValid() {
+		ctx = prof.ContextWithSpan(ctx, sc)
+	}
+	reply, err := call(ctx, t.Zero(), t, mainID, in)
 
 	if err != nil {
 		conn.Write(&returnMsg{Err: err})
@@ -117,7 +132,7 @@ func (r *Runtime) remoteGo(addr n.Addr, ufn circuit.Func, in ...interface{}) []i
 
 // TryGo runs the function ufn on the runtime behind c.
 // Any failure to obtain the return values causes a panic.
-func (r *Runtime) tryRemoteGo(addr n.Addr, ufn circuit.Func, in ...interface{}) ([]interface{}, error) {
+func (r *Runtime) tryRemoteGo(addr n.Addr, ufn circuit.Func, in ...interface{}) (_ []interface{}, err error) {
 	conn, err := r.t.Dial(addr)
 	if err != nil {
 		return nil, err
@@ -130,11 +145,18 @@ func (r *Runtime) tryRemoteGo(addr n.Addr, ufn circuit.Func, in ...interface{})
 	if t == nil {
 		panic(fmt.Sprintf("type '%T' is not a registered worker function type", ufn))
 	}
+	tr := r.traceCall(prof.SpanContext{}, t.Type.String(), addr, map[string]string{
+		"circuit.func": t.Type.String(),
+	})
+	defer func() {
+		tr.End(err)
+	}()
 	req := &goMsg{
 		// If TypeOf returns nil (causing panic), the user forgot to
 		// register the type of ufn
 		TypeID: t.ID,
 		In:     expGo,
+		Trace:  tr.Context(),
 	}
 	if err := conn.Write(req); err != nil {
 		return nil, NewError("remote write: " + err.Error())
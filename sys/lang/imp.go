@@ -9,6 +9,7 @@ package lang
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"runtime"
 	"sync"
@@ -116,6 +117,10 @@ func (u *_permptr) Call(proc string, in ...interface{}) []interface{} {
 	return u._ptr.Call(proc, in...)
 }
 
+func (u *_permptr) CallContext(ctx context.Context, proc string, in ...interface{}) []interface{} {
+	return u._ptr.CallContext(ctx, proc, in...)
+}
+
 // makeImpTable initializes and returns a new imports table
 func makeImpTabl(tt *types.TypeTabl) *impTabl {
 	return &impTabl{
@@ -9,6 +9,8 @@
 package lang
 
 import (
+	"context"
+
 	"github.com/gocircuit/circuit/kit/lang"
 	"github.com/gocircuit/circuit/use/circuit"
 	"github.com/gocircuit/circuit/use/n"
@@ -38,6 +40,10 @@ func (*_ref) Call(proc string, in ...interface{}) []interface{} {
 	panic("call on ref")
 }
 
+func (*_ref) CallContext(ctx context.Context, proc string, in ...interface{}) []interface{} {
+	panic("call on ref")
+}
+
 // _permref
 type _permref struct {
 	value interface{}
@@ -63,6 +69,10 @@ func (*_permref) Call(proc string, in ...interface{}) []interface{} {
 	panic("call on permref")
 }
 
+func (*_permref) CallContext(ctx context.Context, proc string, in ...interface{}) []interface{} {
+	panic("call on permref")
+}
+
 // Ref annotates a user value v, so that if the returned value is consequently
 // passed cross-runtime, the runtime will pass v as via a cross-runtime pointer
 // rather than by value.
@@ -8,9 +8,12 @@
 package lang
 
 import (
+	"context"
 	"encoding/gob"
 	"fmt"
+	"time"
 
+	"github.com/gocircuit/circuit/sys/lang/prof"
 	"github.com/gocircuit/circuit/sys/lang/types"
 	"github.com/gocircuit/circuit/use/circuit"
 	"github.com/gocircuit/circuit/use/n"
@@ -21,6 +24,7 @@ func init() {
 	// Func invokation-style commands
 	gob.Register(&goMsg{})
 	gob.Register(&callMsg{})
+	gob.Register(&cancelMsg{})
 	gob.Register(&dialMsg{})
 	gob.Register(&getPtrMsg{})
 	gob.Register(&returnMsg{})
@@ -47,12 +51,23 @@ type callMsg struct {
 	ReceiverID circuit.HandleID
 	FuncID     types.FuncID
 	In         []interface{}
+
+	// Timeout, if not zero, is the time left until the deadline of the call
+	// when it was sent. It is relative to spare the call from clock skew.
+	Timeout time.Duration
+
+	// Trace identifies the span of the caller, if the call is traced
+	Trace prof.SpanContext
 }
 
+// Cancel the call in progress on the connection
+type cancelMsg struct{}
+
 // Fork a go routine
 type goMsg struct {
 	TypeID types.TypeID
 	In     []interface{}
+	Trace  prof.SpanContext
 }
 
 type returnMsg struct {
@@ -75,6 +90,7 @@ type dontReplyMsg struct{}
 // dialMsg requests that the receiver send back a handle to its permanent.
 type dialMsg struct {
 	Service string
+	Trace   prof.SpanContext
 }
 
 // The importer of a handle sends a release request to the exporter to
@@ -104,6 +120,10 @@ func (msg *ptrMsg) Call(proc string, in ...interface{}) []interface{} {
 	panic("hack: not meant to be used")
 }
 
+func (msg *ptrMsg) CallContext(ctx context.Context, proc string, in ...interface{}) []interface{} {
+	panic("hack: not meant to be used")
+}
+
 func (msg *ptrMsg) String() string {
 	return fmt.Sprintf("ptrMsg: id=%v type=%v", msg.ID, msg.TypeID)
 }
@@ -128,6 +148,10 @@ func (msg *ptrPtrMsg) Call(proc string, in ...interface{}) []interface{} {
 	panic("hack: not meant to be used")
 }
 
+func (msg *ptrPtrMsg) CallContext(ctx context.Context, proc string, in ...interface{}) []interface{} {
+	panic("hack: not meant to be used")
+}
+
 func (msg *ptrPtrMsg) String() string {
 	return fmt.Sprintf("ptrPtrMsg: id=%v src=%v", msg.ID, msg.Src)
 }
@@ -154,6 +178,10 @@ func (msg *permPtrMsg) Call(proc string, in ...interface{}) []interface{} {
 	panic("hack: not meant to be used")
 }
 
+func (msg *permPtrMsg) CallContext(ctx context.Context, proc string, in ...interface{}) []interface{} {
+	panic("hack: not meant to be used")
+}
+
 func (msg *permPtrMsg) String() string {
 	return fmt.Sprintf("permPtrMsg: id=%v type=%v", msg.ID, msg.TypeID)
 }
@@ -182,6 +210,10 @@ func (msg *permPtrPtrMsg) Call(proc string, in ...interface{}) []interface{} {
 	panic("hack: not meant to be used")
 }
 
+func (msg *permPtrPtrMsg) CallContext(ctx context.Context, proc string, in ...interface{}) []interface{} {
+	panic("hack: not meant to be used")
+}
+
 func (msg *permPtrPtrMsg) String() string {
 	return fmt.Sprintf("permPtrPtrMsg: id=%v type=%v src=%v", msg.ID, msg.TypeID, msg.Src)
 }
@@ -0,0 +1,186 @@
+// Copyright 2013 The Go Circuit Project
+// Use of this source code is governed by the license for
+// The Go Circuit Project, found in the LICENSE file.
+
+package prof
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The types below follow the JSON encoding of the OTLP trace export request,
+// as accepted by OpenTelemetry collectors on /v1/traces.
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID      string         `json:"traceId"`
+	SpanID       string         `json:"spanId"`
+	ParentSpanID string         `json:"parentSpanId,omitempty"`
+	Name         string         `json:"name"`
+	Kind         SpanKind       `json:"kind"`
+	Start        string         `json:"startTimeUnixNano"`
+	End          string         `json:"endTimeUnixNano"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	Status       otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"` // 2 is error
+	Message string `json:"message,omitempty"`
+}
+
+// traceScope names the instrumentation in exported traces
+const traceScope = "github.com/gocircuit/circuit/sys/lang"
+
+// EncodeOTLP encodes spans as an OTLP JSON trace export request, from the
+// resource (e.g. the circuit server) described by the given attributes.
+func EncodeOTLP(resource map[string]string, spans []*Span) ([]byte, error) {
+	q := otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{Attributes: otlpAttributes(resource)},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: traceScope},
+				Spans: make([]otlpSpan, 0, len(spans)),
+			}},
+		}},
+	}
+	ss := &q.ResourceSpans[0].ScopeSpans[0]
+	for _, s := range spans {
+		o := otlpSpan{
+			TraceID:    hex.EncodeToString(s.TraceID[:]),
+			SpanID:     hex.EncodeToString(s.SpanID[:]),
+			Name:       s.Name,
+			Kind:       s.Kind,
+			Start:      strconv.FormatInt(s.Start.UnixNano(), 10),
+			End:        strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes: otlpAttributes(s.Attr),
+		}
+		if s.Parent != [8]byte{} {
+			o.ParentSpanID = hex.EncodeToString(s.Parent[:])
+		}
+		if s.Err != "" {
+			o.Status = otlpStatus{Code: 2, Message: s.Err}
+		}
+		ss.Spans = append(ss.Spans, o)
+	}
+	return json.Marshal(q)
+}
+
+func otlpAttributes(attr map[string]string) []otlpKeyValue {
+	var r []otlpKeyValue
+	for k, v := range attr {
+		r = append(r, otlpKeyValue{Key: k, Value: otlpValue{StringValue: v}})
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].Key < r[j].Key })
+	return r
+}
+
+// OpenTraceExporter returns an exporter of spans, from the resource described
+// by the given attributes, to dest. If dest is an http or https URL, spans are
+// posted to it as to the /v1/traces endpoint of an OTLP collector. Otherwise
+// dest is a file, to which export requests are appended one per line.
+func OpenTraceExporter(resource map[string]string, dest string) (Exporter, error) {
+	if strings.HasPrefix(dest, "http://") || strings.HasPrefix(dest, "https://") {
+		return &collectorExporter{
+			resource: resource,
+			url:      dest,
+			client:   &http.Client{Timeout: 10 * time.Second},
+		}, nil
+	}
+	file, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &fileExporter{resource: resource, file: file}, nil
+}
+
+type fileExporter struct {
+	resource map[string]string
+	sync.Mutex
+	file *os.File
+}
+
+func (x *fileExporter) Export(spans []*Span) error {
+	buf, err := EncodeOTLP(x.resource, spans)
+	if err != nil {
+		return err
+	}
+	x.Lock()
+	defer x.Unlock()
+	_, err = x.file.Write(append(buf, '\n'))
+	return err
+}
+
+func (x *fileExporter) Close() error {
+	x.Lock()
+	defer x.Unlock()
+	return x.file.Close()
+}
+
+type collectorExporter struct {
+	resource map[string]string
+	url      string
+	client   *http.Client
+}
+
+func (x *collectorExporter) Export(spans []*Span) error {
+	buf, err := EncodeOTLP(x.resource, spans)
+	if err != nil {
+		return err
+	}
+	resp, err := x.client.Post(x.url, "application/json", bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector replied %s", resp.Status)
+	}
+	return nil
+}
+
+func (x *collectorExporter) Close() error {
+	return nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2013 The Go Circuit Project
+// Use of this source code is governed by the license for
+// The Go Circuit Project, found in the LICENSE file.
+
+package prof
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"sync"
+	"time"
+)
+
+// SpanContext identifies a span and the trace it belongs to.
+// It travels along with cross-runtime calls.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// IsValid returns true if sc identifies a span
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+func (sc SpanContext) String() string {
+	return hex.EncodeToString(sc.TraceID[:]) + "/" + hex.EncodeToString(sc.SpanID[:])
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of ctx carrying sc, which spans started
+// with the returned context will take as their parent.
+func ContextWithSpan(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanKey{}, sc)
+}
+
+// SpanFromContext returns the span context carried by ctx, if any
+func SpanFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(spanKey{}).(SpanContext)
+	return sc
+}
+
+// SpanKind tells which side of a cross-runtime call a span records
+type SpanKind int
+
+const (
+	SpanServer SpanKind = 2 // The runtime serving the call
+	SpanClient SpanKind = 3 // The runtime making the call
+)
+
+// Span records the timing and outcome of one side of a cross-runtime call
+type Span struct {
+	SpanContext
+	Parent [8]byte
+	Name   string
+	Kind   SpanKind
+	Start  time.Time
+	End    time.Time
+	Attr   map[string]string
+	Err    string
+}
+
+// Exporter sends finished spans to a tracing backend
+type Exporter interface {
+	Export(spans []*Span) error
+	Close() error
+}
+
+// Tracer starts spans and exports them in batches once they end.
+// The methods of a nil Tracer do nothing, so that tracing costs nothing
+// unless it is turned on.
+type Tracer struct {
+	x     Exporter
+	lk    sync.Mutex
+	batch []*Span
+	flush chan struct{}
+	done  chan struct{}
+	quit  chan struct{}
+}
+
+// Spans are exported when this many have ended, or at this interval
+const (
+	traceBatch    = 512
+	traceInterval = time.Second
+)
+
+// NewTracer returns a tracer which exports spans with x
+func NewTracer(x Exporter) *Tracer {
+	t := &Tracer{
+		x:     x,
+		flush: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+		quit:  make(chan struct{}),
+	}
+	go t.loop()
+	return t
+}
+
+// Start starts a span of the given kind, as a child of parent or, if parent
+// is not valid, as the root of a new trace.
+func (t *Tracer) Start(parent SpanContext, name string, kind SpanKind, attr map[string]string) *Span {
+	if t == nil {
+		return nil
+	}
+	s := &Span{Name: name, Kind: kind, Start: time.Now(), Attr: attr}
+	if parent.IsValid() {
+		s.TraceID, s.Parent = parent.TraceID, parent.SpanID
+	} else {
+		rand.Read(s.TraceID[:])
+	}
+	rand.Read(s.SpanID[:])
+	return s
+}
+
+// End ends span s with the outcome err, and queues it for export
+func (t *Tracer) End(s *Span, err error) {
+	if t == nil || s == nil {
+		return
+	}
+	s.End = time.Now()
+	if err != nil {
+		s.Err = err.Error()
+	}
+	t.lk.Lock()
+	t.batch = append(t.batch, s)
+	full := len(t.batch) >= traceBatch
+	t.lk.Unlock()
+	if full {
+		select {
+		case t.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (t *Tracer) loop() {
+	defer close(t.quit)
+	tick := time.NewTicker(traceInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+		case <-t.flush:
+		case <-t.done:
+			t.export()
+			return
+		}
+		t.export()
+	}
+}
+
+func (t *Tracer) export() {
+	t.lk.Lock()
+	batch := t.batch
+	t.batch = nil
+	t.lk.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+	if err := t.x.Export(batch); err != nil {
+		log.Printf("trace export (%s), dropping %d spans", err, len(batch))
+	}
+}
+
+// Close exports the spans that have ended and closes the exporter
+func (t *Tracer) Close() error {
+	if t == nil {
+		return nil
+	}
+	close(t.done)
+	<-t.quit
+	return t.x.Close()
+}
@@ -58,12 +58,14 @@ func ReadWriterConn(addr n.Addr, rwc io.ReadWriteCloser) n.Conn {
 	}
 }
 
+// readWriterConn reads and writes concurrently, as the transport connections do.
 type readWriterConn struct {
 	addr n.Addr
-	sync.Mutex
-	rwc io.ReadWriteCloser
-	enc *gob.Encoder
-	dec *gob.Decoder
+	rlk  sync.Mutex
+	wlk  sync.Mutex
+	rwc  io.ReadWriteCloser
+	enc  *gob.Encoder
+	dec  *gob.Decoder
 }
 
 type blob struct {
@@ -71,8 +73,8 @@ type blob struct {
 }
 
 func (conn *readWriterConn) Read() (interface{}, error) {
-	conn.Lock()
-	defer conn.Unlock()
+	conn.rlk.Lock()
+	defer conn.rlk.Unlock()
 	var b blob
 	err := conn.dec.Decode(&b)
 	if err != nil {
@@ -82,23 +84,27 @@ func (conn *readWriterConn) Read() (interface{}, error) {
 }
 
 func (conn *readWriterConn) Write(cargo interface{}) error {
-	conn.Lock()
-	defer conn.Unlock()
+	conn.wlk.Lock()
+	defer conn.wlk.Unlock()
 	return conn.enc.Encode(&blob{cargo})
 }
 
 func (conn *readWriterConn) Close() error {
-	conn.Lock()
-	defer conn.Unlock()
+	conn.wlk.Lock()
+	defer conn.wlk.Unlock()
 	return conn.rwc.Close()
 }
 
 func (conn *readWriterConn) Abort(error) {
-	conn.Lock()
-	defer conn.Unlock()
+	conn.wlk.Lock()
+	defer conn.wlk.Unlock()
 	conn.rwc.Close()
 }
 
 func (conn *readWriterConn) Addr() n.Addr {
 	return conn.addr
 }
+
+func (conn *readWriterConn) Peer() n.Peer {
+	return n.Peer{}
+}
@@ -10,6 +10,7 @@ package lang
 import (
 	"log"
 	"sync"
+	"sync/atomic"
 
 	"github.com/gocircuit/circuit/sys/acid"
 	"github.com/gocircuit/circuit/sys/lang/prof"
@@ -31,23 +32,26 @@ type Runtime struct {
 	live map[n.WorkerID]struct{} // Set of peers we monitor for liveness
 	prof *prof.Profile
 	dwg  sync.WaitGroup
+
+	tracer atomic.Pointer[prof.Tracer] // Tracing is off if nil
+	conns  atomic.Int64                // Open connections
 }
 
 func New(t n.Transport) *Runtime {
 	r := &Runtime{
-		t:    t,
 		exp:  makeExpTabl(types.ValueTabl),
 		imp:  makeImpTabl(types.ValueTabl),
 		live: make(map[n.WorkerID]struct{}),
 		prof: prof.New(),
 	}
+	r.t = countTransport{Transport: t, open: &r.conns}
 	r.srv.Init()
 	go func() {
 		for {
-			r.accept(t)
+			r.accept(r.t)
 		}
 	}()
-	r.Listen("acid", acid.New())
+	r.Listen("acid", acid.New(r))
 	return r
 }
 
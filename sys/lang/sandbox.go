@@ -120,5 +120,6 @@ type halfconn struct {
 }
 
 func (h *halfconn) Close() error {
+	h.PipeReader.Close()
 	return h.PipeWriter.Close()
 }
@@ -0,0 +1,152 @@
+// Copyright 2013 The Go Circuit Project
+// Use of this source code is governed by the license for
+// The Go Circuit Project, found in the LICENSE file.
+
+package lang
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocircuit/circuit/sys/acid"
+	"github.com/gocircuit/circuit/use/n"
+)
+
+// Stat holds the sizes of the export and import tables of a runtime, and
+// the number of its open connections
+type Stat struct {
+	ExpPerm      int   // Permanent exported handles
+	ExpNonPerm   int   // Non-permanent exported handles
+	ExpImporters int   // Runtimes importing non-permanent handles
+	ImpPerm      int   // Permanent imported handles
+	ImpNonPerm   int   // Non-permanent imported handles
+	ImpExporters int   // Runtimes exporting the imported handles
+	Conns        int64 // Open connections, dialed or accepted
+}
+
+// Stat returns the table sizes and open connections of the runtime
+func (r *Runtime) Stat() *Stat {
+	s := &Stat{Conns: r.conns.Load()}
+
+	r.exp.lk.Lock()
+	s.ExpPerm = len(r.exp.perm)
+	s.ExpImporters = len(r.exp.nonperm)
+	for _, impTabl := range r.exp.nonperm {
+		s.ExpNonPerm += len(impTabl)
+	}
+	r.exp.lk.Unlock()
+
+	r.imp.lk.Lock()
+	exporters := make(map[n.WorkerID]struct{})
+	for _, imph := range r.imp.id {
+		if imph.Perm {
+			s.ImpPerm++
+		} else {
+			s.ImpNonPerm++
+		}
+		if imph.Exporter != nil {
+			exporters[imph.Exporter.WorkerID()] = struct{}{}
+		}
+	}
+	r.imp.lk.Unlock()
+	s.ImpExporters = len(exporters)
+
+	return s
+}
+
+// RuntimeStat lists the handles exported by the runtime, oldest first, and
+// flags the importers that the runtime no longer watches for liveness
+func (r *Runtime) RuntimeStat() *acid.RuntimeStat {
+	now := time.Now()
+	s := &acid.RuntimeStat{Addr: r.ServerAddr().String(), Time: now}
+
+	r.exp.lk.Lock()
+	for _, exph := range r.exp.perm {
+		s.Perm = append(s.Perm, exph.stat(now))
+	}
+	importers := make(map[n.WorkerID]*acid.ImporterStat, len(r.exp.nonperm))
+	for id, impTabl := range r.exp.nonperm {
+		imp := &acid.ImporterStat{}
+		for _, exph := range impTabl {
+			imp.Addr = exph.Importer.String()
+			imp.Handles = append(imp.Handles, exph.stat(now))
+		}
+		importers[id] = imp
+	}
+	r.exp.lk.Unlock()
+
+	// Importers enter the export table before the live set, and leave the
+	// live set before the export table. Reading the live set second, the
+	// importers found missing from it are being removed, or have leaked.
+	r.lk.Lock()
+	for id, imp := range importers {
+		_, live := r.live[id]
+		imp.Orphan = !live
+		s.Importers = append(s.Importers, imp)
+	}
+	r.lk.Unlock()
+
+	s.NumPerm = len(s.Perm)
+	sortHandles(s.Perm)
+	for _, imp := range s.Importers {
+		s.NumNonPerm += len(imp.Handles)
+		sortHandles(imp.Handles)
+	}
+	sort.Slice(s.Importers, func(i, j int) bool {
+		return s.Importers[i].Addr < s.Importers[j].Addr
+	})
+	return s
+}
+
+func (exph *expHandle) stat(now time.Time) *acid.HandleStat {
+	return &acid.HandleStat{
+		ID:   exph.ID.String(),
+		Type: exph.Type.Name(),
+		Age:  now.Sub(exph.Created),
+	}
+}
+
+func sortHandles(h []*acid.HandleStat) {
+	sort.Slice(h, func(i, j int) bool { return h[i].Age > h[j].Age })
+}
+
+// countTransport counts the open connections of the runtime in open
+type countTransport struct {
+	n.Transport
+	open *atomic.Int64
+}
+
+func (t countTransport) Dial(addr n.Addr) (n.Conn, error) {
+	conn, err := t.Transport.Dial(addr)
+	if err != nil {
+		return nil, err
+	}
+	return newCountConn(conn, t.open), nil
+}
+
+func (t countTransport) Accept() n.Conn {
+	return newCountConn(t.Transport.Accept(), t.open)
+}
+
+type countConn struct {
+	n.Conn
+	open *atomic.Int64
+	once sync.Once
+}
+
+func newCountConn(conn n.Conn, open *atomic.Int64) *countConn {
+	open.Add(1)
+	return &countConn{Conn: conn, open: open}
+}
+
+func (c *countConn) Close() error {
+	c.once.Do(func() { c.open.Add(-1) })
+	return c.Conn.Close()
+}
+
+func (c *countConn) Abort(reason error) {
+	c.once.Do(func() { c.open.Add(-1) })
+	c.Conn.Abort(reason)
+}
@@ -0,0 +1,65 @@
+package lang
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gocircuit/circuit/use/circuit"
+)
+
+func TestStat(t *testing.T) {
+	server, client := New(NewSandbox()), New(NewSandbox())
+	server.Listen("echo", testEcho{})
+	x := client.Dial(server.ServerAddr(), "echo")
+	x.Call("Echo", "hi")
+
+	if s := server.Stat(); s.ExpPerm != 1 || s.ExpNonPerm != 0 {
+		t.Fatalf("server exports %+v", s)
+	}
+	if s := client.Stat(); s.ImpPerm != 1 || s.ImpExporters != 1 {
+		t.Fatalf("client imports %+v", s)
+	}
+
+	// Connections of completed calls are closed on both ends
+	for i := 0; server.Stat().Conns+client.Stat().Conns > 0; i++ {
+		if i == 100 {
+			t.Fatalf("open connections %d, %d", server.Stat().Conns, client.Stat().Conns)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type testMaker struct{}
+
+func (testMaker) Make() circuit.X {
+	return Ref(&testEcho{})
+}
+
+func TestRuntimeStat(t *testing.T) {
+	server, client := New(NewSandbox()), New(NewSandbox())
+	server.RegisterValue(&testEcho{})
+	server.Listen("maker", testMaker{})
+	echo := client.Dial(server.ServerAddr(), "maker").Call("Make")[0].(circuit.X)
+
+	s := server.RuntimeStat()
+	if s.NumPerm != 1 || s.NumNonPerm != 1 || len(s.Importers) != 1 {
+		t.Fatalf("server exports %+v", s)
+	}
+	imp := s.Importers[0]
+	if imp.Orphan || !strings.HasSuffix(imp.Handles[0].Type, "(*lang.testEcho)") {
+		t.Fatalf("importer %+v, handle %+v", imp, imp.Handles[0])
+	}
+
+	// Handles of importers missing from the live set are flagged
+	server.lk.Lock()
+	for id := range server.live {
+		delete(server.live, id)
+	}
+	server.lk.Unlock()
+	if !server.RuntimeStat().Importers[0].Orphan {
+		t.Fatalf("orphan importer not flagged")
+	}
+	// The imported handle is not released before this point
+	echo.Call("Echo", "hi")
+}
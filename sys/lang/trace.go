@@ -0,0 +1,97 @@
+// Copyright 2013 The Go Circuit Project
+// Use of this source code is governed by the license for
+// The Go Circuit Project, found in the LICENSE file.
+
+package lang
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gocircuit/circuit/sys/lang/prof"
+	"github.com/gocircuit/circuit/use/n"
+)
+
+// SetTracer turns on the tracing of cross-runtime calls made and served by
+// this runtime, exporting spans with t. A nil t turns tracing off.
+func (r *Runtime) SetTracer(t *prof.Tracer) {
+	r.tracer.Store(t)
+}
+
+// Profile returns the call and reply statistics of this runtime
+func (r *Runtime) Profile() *prof.Profile {
+	return r.prof
+}
+
+// callTrace follows one side of a cross-runtime call in the profile of the
+// runtime and, if tracing is on, in a span.
+type callTrace struct {
+	tracer *prof.Tracer
+	span   *prof.Span
+	watch  prof.StopWatch
+	reply  bool
+}
+
+// traceCall starts following a call made by this runtime
+func (r *Runtime) traceCall(parent prof.SpanContext, name string, peer n.Addr, attr map[string]string) *callTrace {
+	return r.trace(parent, name, peer, attr, false)
+}
+
+// traceReply starts following a call served by this runtime
+func (r *Runtime) traceReply(parent prof.SpanContext, name string, peer n.Addr, attr map[string]string) *callTrace {
+	return r.trace(parent, name, peer, attr, true)
+}
+
+func (r *Runtime) trace(parent prof.SpanContext, name string, peer n.Addr, attr map[string]string, reply bool) *callTrace {
+	tr := &callTrace{tracer: r.tracer.Load(), reply: reply}
+	if reply {
+		tr.watch = r.prof.BeginReply(name)
+	} else {
+		tr.watch = r.prof.BeginCall(name)
+	}
+	if tr.tracer != nil {
+		kind := prof.SpanClient
+		if reply {
+			kind = prof.SpanServer
+		}
+		if peer != nil {
+			attr["circuit.peer"] = peer.String()
+		}
+		tr.span = tr.tracer.Start(parent, name, kind, attr)
+	}
+	return tr
+}
+
+// Context returns the span context to send along with the call, or to
+// continue the trace with on the serving side
+func (tr *callTrace) Context() prof.SpanContext {
+	if tr.span == nil {
+		return prof.SpanContext{}
+	}
+	return tr.span.SpanContext
+}
+
+// End records the outcome of the call
+func (tr *callTrace) End(err error) {
+	if err != nil && !tr.reply {
+		tr.watch.Abort()
+	} else {
+		tr.watch.Stop()
+	}
+	if err != nil {
+		// Spans keep the error, without the stack trace of the serving runtime
+		if i := strings.Index(err.Error(), "\nserver-side runtime.call("); i >= 0 {
+			err = errors.New(err.Error()[:i])
+		}
+	}
+	tr.tracer.End(tr.span, err)
+}
+
+// panicError returns the error of a recovered panic
+func panicError(r interface{}) error {
+	if err, ok := r.(error); ok {
+		return err
+	}
+	return fmt.Errorf("%v", r)
+}
@@ -0,0 +1,103 @@
+package lang
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/gocircuit/circuit/sys/lang/prof"
+	"github.com/gocircuit/circuit/use/circuit"
+)
+
+type memExporter struct {
+	sync.Mutex
+	spans []*prof.Span
+}
+
+func (x *memExporter) Export(spans []*prof.Span) error {
+	x.Lock()
+	defer x.Unlock()
+	x.spans = append(x.spans, spans...)
+	return nil
+}
+
+func (x *memExporter) Close() error {
+	return nil
+}
+
+type testEcho struct{}
+
+func (testEcho) Echo(s string) string {
+	return s
+}
+
+type testRelay struct {
+	next circuit.PermX
+}
+
+func (r *testRelay) Relay(ctx context.Context, s string) string {
+	return r.next.CallContext(ctx, "Echo", s)[0].(string)
+}
+
+func TestTrace(t *testing.T) {
+	var rt [3]*Runtime
+	var x [3]*memExporter
+	var tr [3]*prof.Tracer
+	for i := range rt {
+		rt[i], x[i] = New(NewSandbox()), &memExporter{}
+		tr[i] = prof.NewTracer(x[i])
+		rt[i].SetTracer(tr[i])
+	}
+	rt[2].Listen("echo", testEcho{})
+	rt[1].Listen("relay", &testRelay{next: rt[1].Dial(rt[2].ServerAddr(), "echo")})
+	relay := rt[0].Dial(rt[1].ServerAddr(), "relay")
+	if relay.Call("Relay", "hi")[0].(string) != "hi" {
+		t.Fatalf("relay")
+	}
+	for _, tr := range tr {
+		tr.Close()
+	}
+
+	// Caller and callee spans of each call chain into one trace
+	find := func(x *memExporter, name string, kind prof.SpanKind) *prof.Span {
+		for _, s := range x.spans {
+			if s.Name == name && s.Kind == kind {
+				return s
+			}
+		}
+		t.Fatalf("no %s span", name)
+		return nil
+	}
+	chain := []*prof.Span{
+		find(x[0], "*lang.testRelay.Relay", prof.SpanClient),
+		find(x[1], "*lang.testRelay.Relay", prof.SpanServer),
+		find(x[1], "lang.testEcho.Echo", prof.SpanClient),
+		find(x[2], "lang.testEcho.Echo", prof.SpanServer),
+	}
+	for i, s := range chain {
+		if s.TraceID != chain[0].TraceID {
+			t.Fatalf("span %d is in another trace", i)
+		}
+		if i > 0 && s.Parent != chain[i-1].SpanID {
+			t.Fatalf("span %d is not a child of span %d", i, i-1)
+		}
+		if s.End.Before(s.Start) || s.Attr["circuit.peer"] == "" {
+			t.Fatalf("span %d is malformed", i)
+		}
+	}
+	if chain[0].Parent != [8]byte{} {
+		t.Fatalf("root span has a parent")
+	}
+
+	// Spans are exported as OTLP JSON
+	buf, err := prof.EncodeOTLP(map[string]string{"service.name": "circuit"}, chain)
+	if err != nil {
+		t.Fatalf("encode (%v)", err)
+	}
+	for _, s := range []string{`"resourceSpans"`, `"parentSpanId"`, `"startTimeUnixNano"`, `"kind":3`, `"circuit.method"`} {
+		if !bytes.Contains(buf, []byte(s)) {
+			t.Fatalf("no %s in %s", s, buf)
+		}
+	}
+}
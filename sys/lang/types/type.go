@@ -9,6 +9,7 @@
 package types
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sync"
@@ -146,8 +147,14 @@ type funcChar struct {
 	Method   reflect.Method
 	InTypes  []reflect.Type
 	OutTypes []reflect.Type
+
+	// Context is set for methods whose first argument is a context.Context.
+	// The runtime supplies the context of the call; it is not in InTypes.
+	Context bool
 }
 
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
 func makeFunc(m reflect.Method, parent *TypeChar) *funcChar {
 	if m.PkgPath != "" {
 		// This is an unexported method
@@ -162,7 +169,13 @@ func makeFunc(m reflect.Method, parent *TypeChar) *funcChar {
 
 	// Reflect arguments
 	// Note that the 0-th argument is the receiver value
-	for i := 1; i < t.NumIn(); i++ {
+	first := 1
+	if t.NumIn() > 1 && t.In(1) == contextType {
+		p.Context = true
+		sign = append(sign, "context.Context")
+		first = 2
+	}
+	for i := first; i < t.NumIn(); i++ {
 		at := t.In(i)
 		if !isExportedOrBuiltinType(at) {
 			return nil
@@ -8,9 +8,16 @@
 package lang
 
 import (
+	"context"
+	"io"
 	"log"
+	"reflect"
 	"strings"
+	"time"
 
+	"github.com/gocircuit/circuit/sys/lang/prof"
+	"github.com/gocircuit/circuit/use/circuit"
+	"github.com/gocircuit/circuit/use/errors"
 	"github.com/gocircuit/circuit/use/n"
 )
 
@@ -23,19 +30,43 @@ func (r *Runtime) serveDropPtr(q *dropPtrMsg, conn n.Conn) {
 
 // Call invokes the method of the underlying remote receiver
 func (u *_ptr) Call(proc string, in ...interface{}) []interface{} {
+	return u.CallContext(context.Background(), proc, in...)
+}
+
+// CallContext is like Call. If ctx is done before the call returns, the call is
+// abandoned and canceled on the remote side, and CallContext panics with the
+// error of ctx. The deadline of ctx is sent along with the call. The
+// connection of an abandoned call is closed once its reply arrives, or after
+// CancelGrace.
+func (u *_ptr) CallContext(ctx context.Context, proc string, in ...interface{}) []interface{} {
 	// log.Printf("calling %s.%s on %s", u.imph.Type.Name(), proc, u.imph.Exporter.String())
+	recvType := u.imph.Type.Type.String()
+	tr := u.r.traceCall(prof.SpanFromContext(ctx), recvType+"."+proc, u.imph.Exporter, map[string]string{
+		"circuit.receiver": recvType,
+		"circuit.method":   proc,
+	})
 	defer func() {
 		if r := recover(); r != nil {
 			// log.Printf("call panic: %v", r)
+			tr.End(panicError(r))
 			panic(r)
 		}
+		tr.End(nil)
 	}()
 
+	if err := ctx.Err(); err != nil {
+		panic(err)
+	}
 	conn, err := u.r.t.Dial(u.imph.Exporter)
 	if err != nil {
-		panic(err)
+		panic(unreachable(err))
 	}
-	defer conn.Close()
+	var abandoned bool
+	defer func() {
+		if !abandoned {
+			conn.Close()
+		}
+	}()
 
 	fn := u.imph.Type.Proc[proc]
 	if fn == nil {
@@ -46,22 +77,36 @@ func (u *_ptr) Call(proc string, in ...interface{}) []interface{} {
 		ReceiverID: u.imph.ID,
 		FuncID:     fn.ID,
 		In:         expCall,
+		Trace:      tr.Context(),
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if q.Timeout = time.Until(deadline); q.Timeout <= 0 {
+			panic(context.DeadlineExceeded)
+		}
 	}
 	if err = conn.Write(q); err != nil {
-		panic(err)
+		panic(unreachable(err))
 	}
 	// When calling a function, it is implicit in the returned result that
 	// the other side has acquired its own copies of the PtrPtr values.
-	msg, err := conn.Read()
-	if err != nil {
+	msg, pending, err := readContext(ctx, conn)
+	if pending != nil {
+		// The reply is still due. It is received and released in the
+		// background, as the remote writing to a hung up connection would
+		// break the session it shares with other calls.
+		abandoned = true
+		go u.drain(conn, pending, fn.OutTypes)
 		panic(err)
 	}
+	if err != nil {
+		panic(unreachable(err))
+	}
 	retrn, ok := msg.(*returnMsg)
 	if !ok {
-		panic(NewError("foreign or no reply (msg=%T)", msg))
+		panic(unreachable(NewError("foreign or no reply (msg=%T)", msg)))
 	}
 	if retrn.Err != nil {
-		panic(retrn.Err)
+		panic(&callError{kind: circuit.ErrRemote, err: retrn.Err})
 	}
 
 	// Import return values
@@ -70,12 +115,112 @@ func (u *_ptr) Call(proc string, in ...interface{}) []interface{} {
 		// An error from importValues implies that the remote is using an
 		// incompatible protocol. Thus, we consider it dead to us.
 		// And in such cases, by design, we panic.
-		panic(err)
+		panic(unreachable(err))
 	}
 	return out
 }
 
-func (r *Runtime) serveCall(req *callMsg, conn n.Conn) {
+// CancelGrace is the time the reply of an abandoned call is awaited for,
+// after the call is canceled on the remote side
+var CancelGrace = 10 * time.Second
+
+// drain receives and releases the reply of an abandoned call, if it arrives
+// within CancelGrace, and closes the connection of the call.
+func (u *_ptr) drain(conn n.Conn, pending <-chan readResult, out []reflect.Type) {
+	defer conn.Close()
+	select {
+	case r := <-pending:
+		if retrn, ok := r.msg.(*returnMsg); ok && retrn.Err == nil {
+			u.r.importValues(retrn.Out, out, u.imph.Exporter, true, conn)
+		}
+	case <-time.After(CancelGrace):
+	}
+}
+
+// readContext reads the reply to a call from conn. If ctx is done first, it
+// cancels the call on the remote side and returns the error of ctx, along with
+// the channel on which the pending reply will be delivered.
+func readContext(ctx context.Context, conn n.Conn) (interface{}, <-chan readResult, error) {
+	if ctx.Done() == nil {
+		msg, err := conn.Read()
+		return msg, nil, err
+	}
+	ch := make(chan readResult, 1)
+	go func() {
+		msg, err := conn.Read()
+		ch <- readResult{msg, err}
+	}()
+	select {
+	case r := <-ch:
+		return r.msg, nil, r.err
+	case <-ctx.Done():
+		conn.Write(&cancelMsg{})
+		return nil, ch, ctx.Err()
+	}
+}
+
+type readResult struct {
+	msg interface{}
+	err error
+}
+
+// callConn is the connection of a call served by this runtime. It reads the
+// connection on behalf of the served method, so as to cancel its context
+// when the caller cancels the call or hangs up. Other messages are passed
+// on to Read.
+type callConn struct {
+	n.Conn
+	ch   chan readResult
+	done chan struct{}
+}
+
+func watchCall(req *callMsg, conn n.Conn) (context.Context, *callConn) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if req.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), req.Timeout)
+	}
+	c := &callConn{Conn: conn, ch: make(chan readResult), done: make(chan struct{})}
+	go func() {
+		defer cancel()
+		for {
+			msg, err := conn.Read()
+			if _, ok := msg.(*cancelMsg); ok {
+				cancel()
+				continue
+			}
+			if err != nil {
+				cancel()
+			}
+			select {
+			case c.ch <- readResult{msg, err}:
+			case <-c.done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return ctx, c
+}
+
+func (c *callConn) Read() (interface{}, error) {
+	select {
+	case r := <-c.ch:
+		return r.msg, r.err
+	case <-c.done:
+		return nil, io.ErrClosedPipe
+	}
+}
+
+// Close closes the connection and stops watching it
+func (c *callConn) Close() error {
+	close(c.done)
+	return c.Conn.Close()
+}
+
+func (r *Runtime) serveCall(req *callMsg, c n.Conn) {
+	ctx, conn := watchCall(req, c)
 	// Go guarantees the defer runs even if panic occurs
 	defer conn.Close()
 
@@ -105,19 +250,43 @@ func (r *Runtime) serveCall(req *callMsg, conn n.Conn) {
 		conn.Write(&returnMsg{Err: NewError("no func")})
 		return
 	}
+	recvType := h.Type.Type.String()
+	tr := r.traceReply(req.Trace, recvType+"."+fn.Method.Name, conn.Addr(), map[string]string{
+		"circuit.receiver": recvType,
+		"circuit.method":   fn.Method.Name,
+	})
+	// The span ends before the reply is sent, so as to precede the end of
+	// the caller's span
+	reply := func(msg *returnMsg) error {
+		tr.End(msg.Err)
+		return conn.Write(msg)
+	}
+	if sc := tr.Context(); sc.IsValid() {
+		ctx = prof.ContextWithSpan(ctx, sc)
+	}
 	in, err := r.importValues(req.In, fn.InTypes, conn.Addr(), true, nil)
 	if err != nil {
-		conn.Write(&returnMsg{Err: err})
+		reply(&returnMsg{Err: err})
 		return
 	}
 
-	reply, err := call(h.Value, h.Type, req.FuncID, in)
+	recv := h.Value
+	if b, ok := recv.Interface().(circuit.Bound); ok {
+		recv = reflect.ValueOf(b.Bind(circuit.Caller{Addr: conn.Addr(), Peer: conn.Peer()}))
+	}
+	if a, ok := recv.Interface().(circuit.Authorizer); ok {
+		if err = a.Authorize(fn.Method.Name); err != nil {
+			reply(&returnMsg{Err: errors.Pack(err)})
+			return
+		}
+	}
+	out, err := call(ctx, recv, h.Type, req.FuncID, in)
 	if err != nil {
-		conn.Write(&returnMsg{Err: err})
+		reply(&returnMsg{Err: err})
 		return
 	}
-	expReply, ptrPtr := r.exportValues(reply, conn.Addr())
-	if err = conn.Write(&returnMsg{Out: expReply}); err != nil {
+	expReply, ptrPtr := r.exportValues(out, conn.Addr())
+	if err = reply(&returnMsg{Out: expReply}); err != nil {
 		// This error occurs when a connection is lost unexpectedly.
 		// log.Printf("write error (%s)", err)
 	}
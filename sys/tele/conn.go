@@ -14,11 +14,12 @@ import (
 
 type Conn struct {
 	addr *Addr
+	peer n.Peer
 	sub  *blend.Conn
 }
 
-func NewConn(sub *blend.Conn, addr *Addr) *Conn {
-	return &Conn{addr: addr, sub: sub}
+func NewConn(sub *blend.Conn, addr *Addr, peer n.Peer) *Conn {
+	return &Conn{addr: addr, peer: peer, sub: sub}
 }
 
 func (c *Conn) Read() (v interface{}, err error) {
@@ -46,3 +47,7 @@ func (c *Conn) Abort(reason error) {
 func (c *Conn) Addr() n.Addr {
 	return c.addr
 }
+
+func (c *Conn) Peer() n.Peer {
+	return c.peer
+}
@@ -20,14 +20,16 @@ import (
 // Dialer
 type Dialer struct {
 	dialback n.Addr
+	token    string           // Access token presented to dialed workers
 	sub      *blend.Transport // Encloses *blend.Dialer
 	sync.Mutex
 	open map[n.WorkerID]*blend.DialSession // Open dial sessions
 }
 
-func newDialer(dialback n.Addr, sub *blend.Transport) *Dialer {
+func newDialer(dialback n.Addr, token string, sub *blend.Transport) *Dialer {
 	return &Dialer{
 		dialback: dialback,
+		token:    token,
 		sub:      sub,
 		open:     make(map[n.WorkerID]*blend.DialSession),
 	}
@@ -54,7 +56,7 @@ func (d *Dialer) Dial(addr n.Addr) (conn n.Conn, err error) {
 		d.open[workerID] = s
 		go d.watch(workerID, s) // Watch for idleness and close
 	}
-	return NewConn(s.Dial(), addr.(*Addr)), nil
+	return NewConn(s.Dial(), addr.(*Addr), n.Peer{Cert: s.PeerName()}), nil
 }
 
 // Idleness duration should be greater than the locus heartbeats over permanent cross-references
@@ -98,6 +100,7 @@ func (d *Dialer) auth(addr n.Addr, conn *blend.Conn) error {
 	if err := conn.Write(&HelloMsg{
 		SourceAddr: d.dialback,
 		TargetAddr: addr,
+		Token:      d.token,
 	}); err != nil {
 		return err
 	}
@@ -43,10 +43,11 @@ func (l *Listener) loop() {
 		go func() {
 			defer session.Close()
 			// Authenticate dialer on first connection
-			sourceAddr, err := l.handshake(session.Accept())
+			sourceAddr, token, err := l.handshake(session.Accept())
 			if err != nil {
 				return
 			}
+			peer := n.Peer{Cert: session.PeerName(), Token: token}
 			for {
 				conn := session.Accept()
 				if conn == nil {
@@ -56,23 +57,23 @@ func (l *Listener) loop() {
 				// For now, listening sessions do not expire themselves on inactivity to prevent
 				// race against DialSessions, who currently hold the sole responsibility.
 				l.ach__.Lock()
-				l.ach <- NewConn(conn, sourceAddr)
+				l.ach <- NewConn(conn, sourceAddr, peer)
 				l.ach__.Unlock()
 			}
 		}()
 	}
 }
 
-func (l *Listener) handshake(conn *blend.Conn) (sourceAddr *Addr, err error) {
+func (l *Listener) handshake(conn *blend.Conn) (sourceAddr *Addr, token string, err error) {
 	if conn == nil {
-		return nil, errors.NewError("listener off")
+		return nil, "", errors.NewError("listener off")
 	}
 	defer conn.Close()
 	//
 	var msg interface{}
 	msg, err = conn.Read()
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer func() {
 		if err != nil {
@@ -84,29 +85,29 @@ func (l *Listener) handshake(conn *blend.Conn) (sourceAddr *Addr, err error) {
 	hello, ok := msg.(*HelloMsg)
 	if !ok {
 		log.Println("rejecting", conn.RemoteAddr().String(), "unknown hello message type")
-		return nil, errors.NewError("rejecting unknown hello type")
+		return nil, "", errors.NewError("rejecting unknown hello type")
 	}
 	// Accept user connections
 	da, ok := hello.SourceAddr.(*Addr)
 	if !ok {
 		log.Println("rejecting", conn.RemoteAddr().String(), "unknown source address type")
-		return nil, errors.NewError("rejecting unknown source address type")
+		return nil, "", errors.NewError("rejecting unknown source address type")
 	}
 	reverseAddr(da, conn.RemoteAddr())
 	la, ok := hello.TargetAddr.(*Addr)
 	if !ok {
 		log.Println("rejecting ", conn.RemoteAddr().String(), "unknown target address type")
-		return nil, errors.NewError("rejecting unknown target address type")
+		return nil, "", errors.NewError("rejecting unknown target address type")
 	}
 	if la.WorkerID() != l.addr.WorkerID() {
 		log.Println("rejecting", conn.RemoteAddr().String(), "due to worker identity mismatch", "target addr=", la.WorkerID(), "listetener addr=", l.addr.WorkerID())
-		return nil, errors.NewError("rejecting worker identity mismatch, looks for %s, got %s", la.WorkerID(), l.addr.WorkerID())
+		return nil, "", errors.NewError("rejecting worker identity mismatch, looks for %s, got %s", la.WorkerID(), l.addr.WorkerID())
 	}
 	if la.PID != os.Getpid() {
 		log.Println("rejecting", conn.RemoteAddr().String(), "due to worker PID mismatch")
-		return nil, errors.NewError("rejecting worker PID mismatch, looks for %d, got %d", la.PID, os.Getpid())
+		return nil, "", errors.NewError("rejecting worker PID mismatch, looks for %d, got %d", la.PID, os.Getpid())
 	}
-	return da, nil
+	return da, hello.Token, nil
 }
 
 func reverseAddr(bound *Addr, seen net.Addr) {
@@ -128,10 +129,12 @@ func (l *Listener) Addr() n.Addr {
 
 func (l *Listener) Close() {}
 
-// Dialer sends HelloMsg to accepter when opening a session to advertise its workerID and local process ID
+// Dialer sends HelloMsg to accepter when opening a session to advertise its workerID and local process ID,
+// and the access token of the dialing process, if any
 type HelloMsg struct {
 	SourceAddr n.Addr
 	TargetAddr n.Addr
+	Token      string
 }
 
 type WelcomeMsg struct{}
@@ -26,17 +26,21 @@ type System struct{}
 
 // workerID is the ID for this transport endpoint.
 // addr is the networking address to listen to.
-func (s *System) NewTransport(workerID n.WorkerID, addr net.Addr, key []byte) n.Transport {
+// sec selects the carrier: mutual TLS, authenticated encryption with a shared key, or plain TCP.
+func (s *System) NewTransport(workerID n.WorkerID, addr net.Addr, sec n.Security) n.Transport {
 	var u *blend.Transport
-	if len(key) == 0 {
+	switch {
+	case sec.TLS != nil:
+		u = tele.NewStructOverTLS(sec.TLS)
+	case sec.Keys != nil:
+		u = tele.NewStructOverAEAD(sec.Keys)
+	default:
 		u = tele.NewStructOverTCP()
-	} else {
-		u = tele.NewStructOverTCPWithHMAC(key)
 	}
 	l := newListener(workerID, os.Getpid(), u.Listen(addr))
 	return &Transport{
 		WorkerID: workerID,
-		Dialer:   newDialer(l.Addr(), u),
+		Dialer:   newDialer(l.Addr(), sec.Token, u),
 		Listener: l,
 	}
 }
@@ -147,6 +147,11 @@ func (k *Kin) Avatar() KinAvatar {
 	return k.kinav
 }
 
+// NumNeighbors returns the number of peers in the neighborhood of this kin
+func (k *Kin) NumNeighbors() int {
+	return k.neighborhood.Len()
+}
+
 // If the neighborhood is too small, expand chooses random peers to refill it.
 func (k *Kin) expand() {
 	if k.neighborhood.Len() < ExpansionLow {
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/gocircuit/circuit/anchor"
+	cli "github.com/gocircuit/circuit/client/server"
 	srv "github.com/gocircuit/circuit/element/server"
 	"github.com/gocircuit/circuit/kit/pubsub"
 	"github.com/gocircuit/circuit/tissue"
@@ -23,8 +24,9 @@ import (
 // Locus is a device that listens to the join/leave events reported by the tissue social
 // system, and maintains an asynchronously-readable current list of known peers.
 type Locus struct {
-	Peer *Peer      // Client peer enclosure for this circuit locus
-	tube *tube.Tube // Kinfolk broadcasting system
+	Peer *Peer            // Client peer enclosure for this circuit locus
+	tube *tube.Tube       // Kinfolk broadcasting system
+	term *anchor.Terminal // Root anchor of this server
 }
 
 // NewLocus creates a new locus device.
@@ -33,7 +35,8 @@ func NewLocus(kin *tissue.Kin, rip <-chan tissue.KinAvatar) XLocus {
 		tube: tube.NewTube(kin, "locus"),
 	}
 	term, xterm := anchor.NewTerm(kin.Avatar().ID.String(), locus)
-	term.Attach(anchor.Server, srv.New(kin))
+	locus.term = term
+	term.Attach(anchor.Server, srv.New(kin, term))
 	locus.Peer = &Peer{
 		// It is crucial to use permanent cross-references, and not
 		// "plain" ones within values stored inside the tube table. If
@@ -48,6 +51,11 @@ func NewLocus(kin *tissue.Kin, rip <-chan tissue.KinAvatar) XLocus {
 	return XLocus{locus}
 }
 
+// Elements returns the number of elements on the anchors of this server, by kind
+func (locus *Locus) Elements() map[string]int {
+	return locus.term.Count()
+}
+
 // GetPeers asynchronously returns the current known list of live peers.
 func (locus *Locus) GetPeers() []*Peer {
 	rr := locus.tube.BulkRead()
@@ -58,8 +66,11 @@ func (locus *Locus) GetPeers() []*Peer {
 	return s
 }
 
+// Self returns the peer record of this server, describing its host as of now.
 func (locus *Locus) Self() *Peer {
-	return locus.Peer
+	p := *locus.Peer
+	p.Host = srv.HostStat()
+	return &p
 }
 
 // peerSubscription
@@ -83,6 +94,25 @@ func (a *peerSubscription) Consume() (interface{}, bool) {
 	return path.Join("/", v.(*tube.Record).Key), true
 }
 
+// Lookup returns the terminal cross-interface of the live peer with the given server ID.
+func (locus *Locus) Lookup(server string) (circuit.PermX, bool) {
+	for _, p := range locus.GetPeers() {
+		if p.Key() == server {
+			return p.Term, true
+		}
+	}
+	return nil, false
+}
+
+// Hosts returns the last announced host descriptions of the live peers, by server ID.
+func (locus *Locus) Hosts() map[string]cli.HostStat {
+	r := make(map[string]cli.HostStat)
+	for _, p := range locus.GetPeers() {
+		r[p.Key()] = p.Host
+	}
+	return r
+}
+
 func (locus *Locus) NewArrivals() pubsub.Consumer {
 	return &peerSubscription{locus.tube.NewArrivals()}
 }
@@ -91,7 +121,8 @@ func (locus *Locus) NewDepartures() pubsub.Consumer {
 	return &peerSubscription{locus.tube.NewDepartures()}
 }
 
-// loopAnnounceAndExpire writes a new version of this server's peer record to the tube view every 2 seconds,
+// loopAnnounceAndExpire writes a new version of this server's peer record, with current host
+// information, to the tube view every 2 seconds,
 // then it iterates through all peer records in the tube view, forgetting those older than 4 seconds.
 func (locus *Locus) loopAnnounceAndExpire() {
 	const GarbageDuration = time.Second * 4
@@ -99,7 +130,7 @@ func (locus *Locus) loopAnnounceAndExpire() {
 	for {
 		rev++
 		// log.Printf("(Re)announcing ourselves (%s,%d,%v)", locus.Peer.Key(), rev, locus.Peer)
-		locus.tube.Write(locus.Peer.Key(), rev, locus.Peer)
+		locus.tube.Write(locus.Peer.Key(), rev, locus.Self())
 		//
 		time.Sleep(GarbageDuration / 2)
 		deadline := time.Now().Add(-GarbageDuration)
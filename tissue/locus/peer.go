@@ -10,6 +10,7 @@ package locus
 import (
 	"encoding/gob"
 
+	cli "github.com/gocircuit/circuit/client/server"
 	"github.com/gocircuit/circuit/kit/lang"
 	"github.com/gocircuit/circuit/tissue"
 	"github.com/gocircuit/circuit/use/circuit"
@@ -20,6 +21,7 @@ import (
 type Peer struct {
 	Kin  tissue.KinAvatar // Cross-interface to the kin system at this locus
 	Term circuit.PermX    // Cross-interface to anchor.XTerminal
+	Host cli.HostStat     // Host of the worker, as of the last announcement
 }
 
 func (i Peer) Key() string {
@@ -19,6 +19,11 @@ type XLocus struct {
 	l *Locus
 }
 
+// LocusOf returns the locus served by x
+func LocusOf(x XLocus) *Locus {
+	return x.l
+}
+
 func (x XLocus) GetPeers() []*Peer {
 	return x.l.GetPeers()
 }
@@ -10,9 +10,9 @@ package main
 
 import (
 	"os"
-	"strconv"
 
 	"github.com/gocircuit/circuit/client"
+	"github.com/gocircuit/circuit/client/job"
 	"github.com/gocircuit/circuit/client/makers"
 )
 
@@ -31,34 +31,19 @@ func main() {
 	// The first argument is the circuit server address that this execution will use.
 	c := client.Dial(os.Args[1], nil)
 
-	// Fire-off n payload processes.
-	ch := make(chan int)
-	for i := 0; i < n; i++ {
-		cmd := client.Cmd{
-			Path: "/bin/sleep",
-			Args: []string{strconv.Itoa(3 + i*3)},
-		}
-		i_ := i
-		go func() {
-			// Pick a random circuit server to run payload on.
-			t := pickServer(c).Walk([]string{"wait-all", strconv.Itoa(i_)})
-			// Execute the process and store it in the anchor.
-			pa, _ := t.Make(makers.ProcType, cmd)
-			p := pa.(client.Proc)
-			// Close the process standard input to indicte no intention to write data.
-			p.Stdin().Close()
-			// Block until the process exits.
-			p.Wait()
-			// Notify the unifying waiting logic.
-			ch <- 1
-			// Remove the anchor storing the process.
-			t.Scrub()
-			println("Payload", i_+1, "finished.")
-		}()
+	// The job element fires off n payload processes, spread across the circuit servers,
+	// sleeping 3, 6, 9, … seconds, and removes the anchor of each process as it finishes.
+	ja, err := pickServer(c).Walk([]string{"wait-all"}).Make(makers.JobType, job.Spec{
+		Tasks: n,
+		Cmd: client.Cmd{
+			Path: "/bin/sh",
+			Args: []string{"-c", "sleep $((3 + {{.Index}}*3))"},
+		},
+	})
+	if err != nil {
+		panic(err)
 	}
 	// Wait for all payload processes to complete.
-	for i := 0; i < n; i++ {
-		<-ch
-	}
-	println("All done.")
+	stat, _ := ja.(job.Job).Wait()
+	println("All done:", stat.Done, "of", n, "succeeded.")
 }
@@ -8,6 +8,8 @@
 package circuit
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 
@@ -26,6 +28,38 @@ func ChooseHandleID() HandleID {
 	return HandleID(rand.Int63())
 }
 
+// Caller describes the remote worker calling a method of an exported value.
+type Caller struct {
+	Addr n.Addr
+	Peer n.Peer
+}
+
+// Bound is implemented by exported values whose methods depend on who calls them.
+// Before serving a remote call, the runtime substitutes the receiver with the
+// value returned by Bind, which must be of the same type.
+// Calls from within the hosting worker are not bound.
+type Bound interface {
+	Bind(Caller) interface{}
+}
+
+// Authorizer is implemented by exported values that restrict the methods
+// remote callers may call. Before serving a remote call, and after binding
+// the receiver, the runtime calls Authorize with the name of the method, and
+// replies with its error, if not nil, instead of calling the method.
+type Authorizer interface {
+	Authorize(method string) error
+}
+
+// Cross-calls panic with errors matching one of these under errors.Is, unless
+// they are given up on with their context.
+var (
+	// ErrUnreachable reports a remote worker which cannot be reached, or whose reply cannot be read
+	ErrUnreachable = errors.New("remote worker unreachable")
+
+	// ErrRemote reports a remote method which panicked, or a call which the remote worker refused
+	ErrRemote = errors.New("remote call failed")
+)
+
 // X represents a cross-interface value.
 type X interface {
 
@@ -41,10 +75,19 @@ type X interface {
 	// the returned slice.
 	//
 	// Errors can only occur as a result of physical/external circumstances
-	// that impede cross-worker communication. Such errors are returned in
-	// the form of panics.
+	// that impede cross-worker communication, or of panics of the remote
+	// method. Such errors are returned in the form of panics, with errors
+	// matching ErrUnreachable or ErrRemote respectively.
 	Call(proc string, in ...interface{}) []interface{}
 
+	// CallContext is like Call, except that the call is abandoned if ctx is
+	// done before it returns, in which case CallContext panics with the
+	// error of ctx. Cancellation and the deadline of ctx are propagated to
+	// the remote side: methods whose first argument is a context.Context
+	// receive a context which is done when the caller's is, and which is
+	// omitted from the arguments in in.
+	CallContext(ctx context.Context, proc string, in ...interface{}) []interface{}
+
 	// IsX is used internally.
 	IsX()
 
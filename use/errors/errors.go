@@ -9,14 +9,43 @@ package errors
 
 import (
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"runtime"
+	"sync"
 )
 
 func init() {
 	gob.Register(&Error{})
 }
 
+var kinds = struct {
+	sync.Mutex
+	err map[string]error
+}{err: make(map[string]error)}
+
+// Register makes errors matching err under errors.Is keep matching it once
+// packed and passed across workers. The text of err identifies it, and must be
+// unique among registered errors. Both the sending and the receiving workers
+// must register err.
+func Register(err error) {
+	kinds.Lock()
+	defer kinds.Unlock()
+	kinds.err[err.Error()] = err
+}
+
+// kindOf returns the text of the registered error that err matches, if any
+func kindOf(err error) string {
+	kinds.Lock()
+	defer kinds.Unlock()
+	for kind, k := range kinds.err {
+		if errors.Is(err, k) {
+			return kind
+		}
+	}
+	return ""
+}
+
 // NewError creates a simple text-based error that is registered with package
 // encoding/gob and therefore can be used in places of error interfaces during
 // cross-calls. In contrast, note that due to the rules of gob encoding error objects
@@ -51,7 +80,19 @@ func Pack(err error) error {
 	if err == nil {
 		return nil
 	}
-	return NewErrorCaller(1, "%s", err.Error())
+	e := NewErrorCaller(1, "%s", err.Error()).(*Error)
+	e.Kind = kindOf(err)
+	return e
+}
+
+// Annotate is like Pack, with note appended to the text of err.
+func Annotate(err error, note string) error {
+	if err == nil {
+		return nil
+	}
+	e := NewErrorCaller(1, "%s", err.Error()+note).(*Error)
+	e.Kind = kindOf(err)
+	return e
 }
 
 // Error is cross-value that can be used to dynamically wrap a native error value into
@@ -61,6 +102,7 @@ type Error struct {
 	Line int
 	Func string
 	Body string
+	Kind string // Text of the registered error the packed error matched
 }
 
 func (e *Error) Error() string {
@@ -68,6 +110,16 @@ func (e *Error) Error() string {
 	//return fmt.Sprintf("%s:%d <%s> %s", e.File, e.Line, e.Func, e.Body)
 }
 
+// Is reports whether target is the registered error the packed error matched.
+func (e *Error) Is(target error) bool {
+	if e.Kind == "" {
+		return false
+	}
+	kinds.Lock()
+	defer kinds.Unlock()
+	return kinds.err[e.Kind] == target
+}
+
 func Unpack(x interface{}) error {
 	if x == nil {
 		return nil
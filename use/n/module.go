@@ -8,8 +8,11 @@
 package n
 
 import (
-	"github.com/gocircuit/circuit/kit/module"
+	"crypto/tls"
 	"net"
+
+	"github.com/gocircuit/circuit/kit/module"
+	"github.com/gocircuit/circuit/kit/tele/aead"
 )
 
 var mod = module.Slot{Name: "network"}
@@ -28,9 +31,26 @@ func ServerAddr() Addr {
 	return workeraddr
 }
 
+// Security configures the authentication and encryption of a transport.
+// The zero value configures plaintext transport.
+type Security struct {
+	// Keys holds the private keys shared by all servers and clients of a
+	// circuit, from which the keys of AES-GCM or ChaCha20-Poly1305 sessions
+	// are derived. The keyring may be changed to rotate keys.
+	Keys *aead.Keyring
+
+	// TLS configures mutual TLS authentication and encryption with
+	// certificates issued by a common authority. It takes precedence over Keys.
+	TLS *tls.Config
+
+	// Token is presented to the servers dialed, which may grant access by it.
+	// Tokens are sent in plaintext unless Keys or TLS are set.
+	Token string
+}
+
 // NewTransport creates a new transport framework for the given local address.
-func NewTransport(workerID WorkerID, addr net.Addr, key []byte) Transport {
-	t := get().NewTransport(workerID, addr, key)
+func NewTransport(workerID WorkerID, addr net.Addr, sec Security) Transport {
+	t := get().NewTransport(workerID, addr, sec)
 	workeraddr = t.Addr()
 	return t
 }
@@ -76,10 +76,22 @@ type Conn interface {
 	// Addr returns the address of the remote endpoint.
 	Addr() Addr
 
+	// Peer returns the credentials the remote endpoint presented.
+	Peer() Peer
+
 	// TODO: ReverseDial is potentially a better abstraction for the heartbeat connections created when passing cross-interfaces
 	// ReverseDial() (Conn, error)
 }
 
+// Peer holds the credentials the remote endpoint of a connection presented.
+// Its zero value describes an anonymous endpoint.
+type Peer struct {
+	// Cert is the common name of the verified certificate of the endpoint
+	Cert string
+	// Token is the access token the endpoint presented
+	Token string
+}
+
 // Listener is a device for accepting incoming connections.
 type Listener interface {
 
@@ -108,7 +120,7 @@ type Transport interface {
 
 // System creates a new transport framework for the given local address
 type System interface {
-	NewTransport(workerID WorkerID, addr net.Addr, key []byte) Transport
+	NewTransport(workerID WorkerID, addr net.Addr, sec Security) Transport
 	ParseNetAddr(s string) (net.Addr, error)
 	ParseAddr(s string) (Addr, error)
 }